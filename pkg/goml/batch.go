@@ -0,0 +1,181 @@
+package goml
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BatchError reports the rows of a batch that could not be predicted
+// Rows that succeeded are still returned; failed rows are nil in the results
+type BatchError struct {
+	Errors map[int]error // Row index -> prediction error
+}
+
+// Error summarizes the failed rows
+func (e *BatchError) Error() string {
+	rows := e.Rows()
+	messages := make([]string, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, fmt.Sprintf("row %d: %v", row, e.Errors[row]))
+	}
+	return fmt.Sprintf("%d of the batch rows failed: %s", len(rows), strings.Join(messages, "; "))
+}
+
+// Unwrap exposes the per-row errors to errors.Is and errors.As
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, row := range e.Rows() {
+		errs = append(errs, e.Errors[row])
+	}
+	return errs
+}
+
+// Rows returns the indices of the failed rows in ascending order
+func (e *BatchError) Rows() []int {
+	rows := make([]int, 0, len(e.Errors))
+	for row := range e.Errors {
+		rows = append(rows, row)
+	}
+	sort.Ints(rows)
+	return rows
+}
+
+// PredictResult is the outcome of predicting a single streamed row
+type PredictResult struct {
+	Index  int                    // Position of the row in the input stream
+	Output map[string]interface{} // Prediction, nil if Err is set
+	Err    error                  // Prediction error for this row
+}
+
+// PredictBatch performs inference on many rows using a pool of Config.Parallelism workers
+// Results are returned in input order. A failing row does not abort the batch: its result
+// is nil and its error is reported through a *BatchError alongside the other results
+// The whole batch is scored with the model and weights that were current when it started
+func (e *Engine) PredictBatch(inputs []map[string]interface{}) ([]map[string]interface{}, error) {
+	model, weights, config := e.snapshot()
+	return predictBatchWith(model, weights, config, inputs)
+}
+
+// predictBatchWith performs batch inference with a snapshot of the engine's model, weights and config
+func predictBatchWith(model *Model, weights *Weights, config *Config, inputs []map[string]interface{}) ([]map[string]interface{}, error) {
+	results := make([]map[string]interface{}, len(inputs))
+	errs := make([]error, len(inputs))
+
+	parallelFor(len(inputs), config.workers(), func(i int) {
+		results[i], errs[i] = predictWith(model, weights, config, inputs[i])
+	})
+
+	batchErr := &BatchError{Errors: make(map[int]error)}
+	for i, err := range errs {
+		if err != nil {
+			results[i] = nil
+			batchErr.Errors[i] = err
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return results, batchErr
+	}
+	return results, nil
+}
+
+// PredictStream performs inference on rows read from a channel using a pool of
+// Config.Parallelism workers and emits one result per row, in input order
+// The result channel is closed once the input channel is closed and drained, or the
+// context is cancelled. The stream is scored with the model and weights current when it starts
+func (e *Engine) PredictStream(ctx context.Context, inputs <-chan map[string]interface{}) <-chan PredictResult {
+	model, weights, config := e.snapshot()
+	workers := config.workers()
+
+	type job struct {
+		index int
+		input map[string]interface{}
+	}
+
+	jobs := make(chan job)
+	done := make(chan PredictResult, workers)
+	out := make(chan PredictResult)
+
+	// Limit the rows in flight so a slow row cannot make the reorder buffer grow unbounded
+	inFlight := make(chan struct{}, 2*workers)
+
+	// Dispatcher: number the incoming rows and hand them to the workers
+	go func() {
+		defer close(jobs)
+		index := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case input, ok := <-inputs:
+				if !ok {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case inFlight <- struct{}{}:
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case jobs <- job{index, input}:
+				}
+				index++
+			}
+		}
+	}()
+
+	// Workers: predict rows independently
+	workersDone := make(chan struct{})
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer func() { workersDone <- struct{}{} }()
+			for j := range jobs {
+				output, err := predictWith(model, weights, config, j.input)
+				if err != nil {
+					output = nil
+				}
+				select {
+				case <-ctx.Done():
+				case done <- PredictResult{Index: j.index, Output: output, Err: err}:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for w := 0; w < workers; w++ {
+			<-workersDone
+		}
+		close(done)
+	}()
+
+	// Sequencer: buffer out-of-order results and emit them in input order
+	go func() {
+		defer close(out)
+		pending := make(map[int]PredictResult)
+		next := 0
+		for result := range done {
+			pending[result.Index] = result
+			for {
+				ready, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				// After cancellation results are dropped, but draining continues so the workers can exit
+				select {
+				case <-ctx.Done():
+				case out <- ready:
+				}
+				<-inFlight
+				next++
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,121 @@
+package goml
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// newBatchEngine creates an engine that predicts y = 2*x + 1
+func newBatchEngine(parallelism int) *Engine {
+	engine := New()
+	engine.WithConfig(&Config{Parallelism: parallelism})
+	engine.Swap(NewLinearModel(), &Weights{
+		Values: map[string]interface{}{
+			"x->y":    2.0,
+			"bias->y": 1.0,
+		},
+	})
+	return engine
+}
+
+// TestPredictBatchPreservesOrder tests that batch results line up with their inputs
+func TestPredictBatchPreservesOrder(t *testing.T) {
+	engine := newBatchEngine(4)
+
+	inputs := make([]map[string]interface{}, 100)
+	for i := range inputs {
+		inputs[i] = map[string]interface{}{"x": float64(i)}
+	}
+
+	results, err := engine.PredictBatch(inputs)
+	if err != nil {
+		t.Fatalf("Batch prediction failed: %v", err)
+	}
+
+	if len(results) != len(inputs) {
+		t.Fatalf("Expected %d results, got %d", len(inputs), len(results))
+	}
+
+	for i, result := range results {
+		if y := result["y"].(float64); y != 2*float64(i)+1 {
+			t.Errorf("Row %d: expected %v, got %v", i, 2*float64(i)+1, y)
+		}
+	}
+}
+
+// TestPredictBatchReportsRowErrors tests that failing rows do not abort the batch
+func TestPredictBatchReportsRowErrors(t *testing.T) {
+	engine := newBatchEngine(2)
+	engine.Swap(&Model{Type: "unsupported"}, &Weights{Values: map[string]interface{}{}})
+
+	results, err := engine.PredictBatch([]map[string]interface{}{{"x": 1.0}, {"x": 2.0}})
+	if err == nil {
+		t.Fatal("Expected a batch error")
+	}
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected *BatchError, got %T", err)
+	}
+
+	if rows := batchErr.Rows(); len(rows) != 2 || rows[0] != 0 || rows[1] != 1 {
+		t.Errorf("Expected rows [0 1] to fail, got %v", rows)
+	}
+
+	if !errors.Is(err, ErrUnsupportedModelType) {
+		t.Errorf("Expected batch error to wrap ErrUnsupportedModelType, got %v", err)
+	}
+
+	if len(results) != 2 || results[0] != nil || results[1] != nil {
+		t.Errorf("Expected nil results for failed rows, got %v", results)
+	}
+}
+
+// TestPredictStreamPreservesOrder tests that streamed results are emitted in input order
+func TestPredictStreamPreservesOrder(t *testing.T) {
+	engine := newBatchEngine(4)
+
+	inputs := make(chan map[string]interface{})
+	go func() {
+		defer close(inputs)
+		for i := 0; i < 200; i++ {
+			inputs <- map[string]interface{}{"x": float64(i)}
+		}
+	}()
+
+	count := 0
+	for result := range engine.PredictStream(context.Background(), inputs) {
+		if result.Index != count {
+			t.Fatalf("Expected result %d, got %d", count, result.Index)
+		}
+		if result.Err != nil {
+			t.Fatalf("Row %d failed: %v", result.Index, result.Err)
+		}
+		if y := result.Output["y"].(float64); y != 2*float64(count)+1 {
+			t.Errorf("Row %d: expected %v, got %v", count, 2*float64(count)+1, y)
+		}
+		count++
+	}
+
+	if count != 200 {
+		t.Errorf("Expected 200 results, got %d", count)
+	}
+}
+
+// TestPredictStreamCancel tests that cancelling the context closes the result stream
+func TestPredictStreamCancel(t *testing.T) {
+	engine := newBatchEngine(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inputs := make(chan map[string]interface{})
+	results := engine.PredictStream(ctx, inputs)
+
+	inputs <- map[string]interface{}{"x": 1.0}
+	<-results
+	cancel()
+
+	// The stream must close even though the input channel is never closed
+	for range results {
+	}
+}
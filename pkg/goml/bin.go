@@ -0,0 +1,267 @@
+package goml
+
+import (
+	"fmt"
+	"sort"
+)
+
+// BinMethod selects how the bin edges of a numeric feature are chosen
+type BinMethod string
+
+const (
+	BinEqualWidth BinMethod = "equal_width" // Bins of equal width between the training minimum and maximum
+	BinQuantile   BinMethod = "quantile"    // Bins with about the same number of training rows
+	BinTree       BinMethod = "tree"        // Edges of a decision tree fitted to the target
+)
+
+// Binner discretizes a numeric feature so that linear and logistic models can learn
+// non-linear effects, such as a fraud rate that rises for both small and large amounts
+// A value falls in bin i when Edges[i-1] <= value < Edges[i]; the first and last bins are
+// open-ended, so values outside the training range are binned too. Ordinal output replaces
+// the value with its bin number; one-hot output replaces it with a 1 for the feature
+// "<feature>=bin<i>"
+type Binner struct {
+	Method     BinMethod `json:"method"`
+	Bins       int       `json:"bins,omitempty"`        // Number of bins (default 5); the most leaves of a tree
+	OneHot     bool      `json:"one_hot,omitempty"`     // One feature per bin instead of the bin number
+	Target     string    `json:"target,omitempty"`      // Target the tree is fitted to; may be omitted when there is only one
+	MinSamples int       `json:"min_samples,omitempty"` // Fewest training rows in a tree bin (default 1)
+
+	Edges []float64 `json:"edges,omitempty"` // Inner bin edges in ascending order, learned by Train
+}
+
+// WithBinner configures how one or more numeric features are binned
+// The bin edges are learned when the model is trained and saved with the model
+func (m *Model) WithBinner(binner Binner, features ...string) *Model {
+	for _, feature := range features {
+		spec := m.featureSpec(feature)
+		binnerCopy := binner
+		spec.Bin = &binnerCopy
+	}
+	return m
+}
+
+// validate checks the binner configuration
+func (b *Binner) validate(feature string) error {
+	switch b.Method {
+	case BinEqualWidth, BinQuantile, BinTree:
+	default:
+		return fmt.Errorf("feature %q: unknown binning method %q: %w", feature, b.Method, ErrInvalidInput)
+	}
+	if b.Bins < 0 || b.MinSamples < 0 {
+		return fmt.Errorf("feature %q: bins and min samples must not be negative: %w", feature, ErrInvalidInput)
+	}
+	return nil
+}
+
+// binCount returns the number of bins
+func (b *Binner) binCount() int {
+	if b.Bins == 0 {
+		return 5
+	}
+	return b.Bins
+}
+
+// Bin returns the bin of a value
+func (b *Binner) Bin(x float64) int {
+	return sort.Search(len(b.Edges), func(i int) bool { return b.Edges[i] > x })
+}
+
+// apply replaces a numeric value with its bin; other values are left unchanged
+func (b *Binner) apply(feature string, row map[string]interface{}) {
+	x, ok := numericValue(row[feature])
+	if !ok || !IsSupportedNumericType(row[feature]) {
+		return
+	}
+
+	bin := b.Bin(x)
+	if !b.OneHot {
+		row[feature] = float64(bin)
+		return
+	}
+	delete(row, feature)
+	row[fmt.Sprintf("%s=bin%d", feature, bin)] = 1.0
+}
+
+// binObservation collects the training values, and the targets for tree binning
+type binObservation struct {
+	values    []float64
+	targets   []interface{}
+	ambiguous bool
+}
+
+// observe records one training value
+func (o *binObservation) observe(b *Binner, val interface{}, output map[string]interface{}) {
+	if !IsSupportedNumericType(val) || isMissing(val) {
+		return
+	}
+	x, _ := ConvertToFloat64(val, "")
+
+	if b.Method != BinTree {
+		o.values = append(o.values, x)
+		return
+	}
+
+	if b.Target == "" {
+		if len(output) != 1 {
+			o.ambiguous = true
+			return
+		}
+		for target := range output {
+			b.Target = target
+		}
+	}
+	if y, exists := output[b.Target]; exists && !isMissing(y) {
+		o.values = append(o.values, x)
+		o.targets = append(o.targets, y)
+	}
+}
+
+// finish stores the learned bin edges in the binner
+func (o *binObservation) finish(b *Binner, feature string) error {
+	if o.ambiguous {
+		return fmt.Errorf("feature %q: tree binning needs a Target when rows have several: %w", feature, ErrInvalidInput)
+	}
+
+	b.Edges = nil
+	if len(o.values) == 0 {
+		return nil
+	}
+
+	switch b.Method {
+	case BinEqualWidth:
+		lo, hi := o.values[0], o.values[0]
+		for _, x := range o.values {
+			lo = min(lo, x)
+			hi = max(hi, x)
+		}
+		if hi > lo {
+			width := (hi - lo) / float64(b.binCount())
+			for i := 1; i < b.binCount(); i++ {
+				b.Edges = append(b.Edges, lo+float64(i)*width)
+			}
+		}
+	case BinQuantile:
+		sorted := append([]float64(nil), o.values...)
+		sort.Float64s(sorted)
+		for i := 1; i < b.binCount(); i++ {
+			edge := sorted[i*len(sorted)/b.binCount()]
+			// Repeated values would give empty bins
+			if edge > sorted[0] && (len(b.Edges) == 0 || edge > b.Edges[len(b.Edges)-1]) {
+				b.Edges = append(b.Edges, edge)
+			}
+		}
+	case BinTree:
+		b.Edges = treeEdges(o.values, o.targets, b.binCount(), max(b.MinSamples, 1))
+	}
+	return nil
+}
+
+// treeEdges fits a one-dimensional decision tree and returns its split points
+// Leaves are split best first, each time at the split that most reduces the squared error of
+// the target. String targets are one-hot encoded, which makes the squared error the Gini impurity
+func treeEdges(values []float64, targets []interface{}, leaves int, minSamples int) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return values[order[i]] < values[order[j]] })
+
+	xs := make([]float64, len(values))
+	ys := targetVectors(targets, order)
+	for i, idx := range order {
+		xs[i] = values[idx]
+	}
+
+	// Prefix sums of the targets give the squared error of any range in constant time
+	dims := 0
+	if len(ys) > 0 {
+		dims = len(ys[0])
+	}
+	sums := make([][]float64, len(xs)+1)
+	squares := make([][]float64, len(xs)+1)
+	sums[0] = make([]float64, dims)
+	squares[0] = make([]float64, dims)
+	for i, y := range ys {
+		sums[i+1] = make([]float64, dims)
+		squares[i+1] = make([]float64, dims)
+		for d := range y {
+			sums[i+1][d] = sums[i][d] + y[d]
+			squares[i+1][d] = squares[i][d] + y[d]*y[d]
+		}
+	}
+	sse := func(lo, hi int) float64 {
+		n := float64(hi - lo)
+		total := 0.0
+		for d := 0; d < dims; d++ {
+			s := sums[hi][d] - sums[lo][d]
+			total += squares[hi][d] - squares[lo][d] - s*s/n
+		}
+		return total
+	}
+
+	// bestSplit returns the position and gain of the best split of the range [lo, hi)
+	bestSplit := func(lo, hi int) (int, float64) {
+		best, bestGain := -1, 0.0
+		parent := sse(lo, hi)
+		for i := lo + minSamples; i <= hi-minSamples; i++ {
+			if xs[i] == xs[i-1] {
+				continue
+			}
+			if gain := parent - sse(lo, i) - sse(i, hi); gain > bestGain+1e-12 {
+				best, bestGain = i, gain
+			}
+		}
+		return best, bestGain
+	}
+
+	type leaf struct{ lo, hi int }
+	ranges := []leaf{{0, len(xs)}}
+	var edges []float64
+	for len(ranges) < leaves {
+		bestLeaf, bestPos, bestGain := -1, -1, 0.0
+		for i, r := range ranges {
+			if pos, gain := bestSplit(r.lo, r.hi); pos >= 0 && gain > bestGain {
+				bestLeaf, bestPos, bestGain = i, pos, gain
+			}
+		}
+		if bestLeaf < 0 {
+			break
+		}
+
+		r := ranges[bestLeaf]
+		ranges = append(ranges[:bestLeaf], append([]leaf{{r.lo, bestPos}, {bestPos, r.hi}}, ranges[bestLeaf+1:]...)...)
+		edges = append(edges, (xs[bestPos-1]+xs[bestPos])/2)
+	}
+
+	sort.Float64s(edges)
+	return edges
+}
+
+// targetVectors converts targets to vectors in the given order: numbers and booleans to a
+// single value, strings to a one-hot vector over the categories
+func targetVectors(targets []interface{}, order []int) [][]float64 {
+	categories := make(map[string]int)
+	for _, y := range targets {
+		if category, ok := y.(string); ok {
+			categories[category] = 0
+		}
+	}
+	for i, category := range sortedCategories(categories) {
+		categories[category] = i
+	}
+
+	vectors := make([][]float64, len(order))
+	for i, idx := range order {
+		if len(categories) > 0 {
+			vectors[i] = make([]float64, len(categories))
+			if category, ok := targets[idx].(string); ok {
+				vectors[i][categories[category]] = 1
+			}
+			continue
+		}
+		y, _ := numericValue(targets[idx])
+		vectors[i] = []float64{y}
+	}
+	return vectors
+}
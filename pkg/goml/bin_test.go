@@ -0,0 +1,138 @@
+package goml
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fitBinner fits a binner on values with optional targets
+func fitBinner(t *testing.T, binner Binner, values []float64, targets []interface{}) *Binner {
+	t.Helper()
+	model := NewLinearModel().WithBinner(binner, "x")
+
+	inputs := make([]map[string]interface{}, len(values))
+	outputs := make([]map[string]interface{}, len(values))
+	for i, x := range values {
+		inputs[i] = map[string]interface{}{"x": x}
+		outputs[i] = map[string]interface{}{}
+		if targets != nil {
+			outputs[i]["y"] = targets[i]
+		}
+	}
+	if err := model.fitPreprocessing(inputs, outputs); err != nil {
+		t.Fatalf("Fitting failed: %v", err)
+	}
+	return model.Features["x"].Bin
+}
+
+// TestBinEdges tests the edges of equal-width, quantile and tree binning
+func TestBinEdges(t *testing.T) {
+	width := fitBinner(t, Binner{Method: BinEqualWidth}, []float64{0, 3, 10, 7}, nil)
+	if !reflect.DeepEqual(width.Edges, []float64{2, 4, 6, 8}) {
+		t.Errorf("Expected equal-width edges 2, 4, 6, 8, got %v", width.Edges)
+	}
+	for x, want := range map[float64]int{-1: 0, 2: 1, 5.5: 2, 10: 4, 100: 4} {
+		if got := width.Bin(x); got != want {
+			t.Errorf("Expected %v in bin %d, got %d", x, want, got)
+		}
+	}
+
+	quantile := fitBinner(t, Binner{Method: BinQuantile, Bins: 4}, []float64{8, 7, 6, 5, 4, 3, 2, 1}, nil)
+	if !reflect.DeepEqual(quantile.Edges, []float64{3, 5, 7}) {
+		t.Errorf("Expected quantile edges 3, 5, 7, got %v", quantile.Edges)
+	}
+	repeated := fitBinner(t, Binner{Method: BinQuantile, Bins: 2}, []float64{1, 1, 1, 1, 2}, nil)
+	if len(repeated.Edges) != 0 {
+		t.Errorf("Expected no empty bins for repeated values, got edges %v", repeated.Edges)
+	}
+
+	// Fraud happens for the smallest and largest amounts
+	var amounts []float64
+	var labels, rates []interface{}
+	for x := 1; x <= 10; x++ {
+		amounts = append(amounts, float64(x))
+		if x <= 2 || x >= 9 {
+			labels, rates = append(labels, "fraud"), append(rates, 1.0)
+		} else {
+			labels, rates = append(labels, "ok"), append(rates, 0.0)
+		}
+	}
+	for _, targets := range [][]interface{}{labels, rates} {
+		tree := fitBinner(t, Binner{Method: BinTree, Bins: 3}, amounts, targets)
+		if !reflect.DeepEqual(tree.Edges, []float64{2.5, 8.5}) {
+			t.Errorf("Expected tree edges 2.5, 8.5 for %T targets, got %v", targets[0], tree.Edges)
+		}
+	}
+
+	// Bins smaller than MinSamples are not split off
+	tree := fitBinner(t, Binner{Method: BinTree, Bins: 3, MinSamples: 3}, amounts, labels)
+	for _, edge := range tree.Edges {
+		if edge == 2.5 || edge == 8.5 {
+			t.Errorf("Expected no bins of two rows with MinSamples 3, got edges %v", tree.Edges)
+		}
+	}
+}
+
+// TestBinOneHotEngine tests that one-hot bins let a linear model learn a non-linear effect
+func TestBinOneHotEngine(t *testing.T) {
+	engine := New()
+	engine.WithModel(NewLinearModel().WithBinner(Binner{Method: BinTree, Bins: 3, OneHot: true}, "amount").JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 300, BatchSize: 10})
+
+	var inputs, outputs []map[string]interface{}
+	for x := 1; x <= 10; x++ {
+		risk := 0.0
+		if x <= 2 || x >= 9 {
+			risk = 1.0
+		}
+		inputs = append(inputs, map[string]interface{}{"amount": float64(x * 100)})
+		outputs = append(outputs, map[string]interface{}{"risk": risk})
+	}
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	_, weights, _ := engine.snapshot()
+	for _, key := range []string{"amount=bin0->risk", "amount=bin1->risk", "amount=bin2->risk"} {
+		if _, exists := weights.Get(key); !exists {
+			t.Errorf("Expected weight %s", key)
+		}
+	}
+
+	predict := func(amount float64) float64 {
+		prediction, err := engine.Predict(map[string]interface{}{"amount": amount})
+		if err != nil {
+			t.Fatalf("Prediction failed: %v", err)
+		}
+		return prediction["risk"].(float64)
+	}
+	small, medium, large := predict(50), predict(500), predict(5000)
+	if small <= medium || large <= medium {
+		t.Errorf("Expected higher risk for small and large amounts, got %v, %v and %v", small, medium, large)
+	}
+
+	modelJSON, _ := engine.GetModel()
+	var saved Model
+	json.Unmarshal([]byte(*modelJSON), &saved)
+	if edges := saved.Features["amount"].Bin.Edges; !reflect.DeepEqual(edges, []float64{250, 850}) {
+		t.Errorf("Expected the bin edges to be saved, got %v", edges)
+	}
+}
+
+// TestBinInvalidConfiguration tests rejection of unknown methods and ambiguous tree targets
+func TestBinInvalidConfiguration(t *testing.T) {
+	engine := New()
+	engine.WithModel(NewLinearModel().WithBinner(Binner{Method: "kmeans"}, "x").JSON())
+	err := engine.Train([]map[string]interface{}{{"x": 1.0}}, []map[string]interface{}{{"y": 1.0}})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for an unknown method, got %v", err)
+	}
+
+	engine.WithModel(NewLinearModel().WithBinner(Binner{Method: BinTree}, "x").JSON())
+	err = engine.Train([]map[string]interface{}{{"x": 1.0}}, []map[string]interface{}{{"y": 1.0, "z": 2.0}})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput without a target for several targets, got %v", err)
+	}
+}
@@ -0,0 +1,454 @@
+package goml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// CalibrationMethod selects how predicted probabilities are calibrated
+type CalibrationMethod string
+
+const (
+	CalibratePlatt    CalibrationMethod = "platt"    // A sigmoid of the log odds; suits small held-out sets
+	CalibrateIsotonic CalibrationMethod = "isotonic" // A non-decreasing step function; needs more rows but fits any distortion
+)
+
+// reliabilityBins is the number of equal-width probability bins of calibration reports
+const reliabilityBins = 10
+
+// Calibrator maps a predicted probability to a calibrated one
+// Platt scaling computes sigmoid(A * logit(p) + B). Isotonic regression interpolates linearly
+// between the points (Thresholds[i], Values[i]) and is constant outside them
+type Calibrator struct {
+	Method     CalibrationMethod `json:"method"`
+	A          float64           `json:"a,omitempty"`
+	B          float64           `json:"b,omitempty"`
+	Thresholds []float64         `json:"thresholds,omitempty"`
+	Values     []float64         `json:"values,omitempty"`
+}
+
+// Apply returns the calibrated probability
+func (c *Calibrator) Apply(p float64) float64 {
+	if c.Method == CalibratePlatt {
+		return sigmoid(c.A*logit(p) + c.B)
+	}
+
+	if len(c.Thresholds) == 0 {
+		return p
+	}
+	i := sort.SearchFloat64s(c.Thresholds, p)
+	switch {
+	case i == 0:
+		return c.Values[0]
+	case i == len(c.Thresholds):
+		return c.Values[len(c.Values)-1]
+	}
+	x0, x1 := c.Thresholds[i-1], c.Thresholds[i]
+	y0, y1 := c.Values[i-1], c.Values[i]
+	return y0 + (y1-y0)*(p-x0)/(x1-x0)
+}
+
+// fitCalibrator fits a calibrator to predicted probabilities and the observed outcomes
+func fitCalibrator(method CalibrationMethod, probabilities []float64, outcomes []bool) *Calibrator {
+	if method == CalibratePlatt {
+		a, b := fitPlatt(probabilities, outcomes)
+		return &Calibrator{Method: method, A: a, B: b}
+	}
+	thresholds, values := fitIsotonic(probabilities, outcomes)
+	return &Calibrator{Method: method, Thresholds: thresholds, Values: values}
+}
+
+// logit returns the log odds of a probability, clipped away from 0 and 1
+func logit(p float64) float64 {
+	p = math.Min(math.Max(p, 1e-12), 1-1e-12)
+	return math.Log(p / (1 - p))
+}
+
+// fitPlatt fits sigmoid(a * logit(p) + b) to the outcomes by Newton's method
+// The outcomes are smoothed towards 1/2 as Platt proposed, so the fit stays finite when the
+// classes are perfectly separated
+func fitPlatt(probabilities []float64, outcomes []bool) (float64, float64) {
+	positives := 0
+	for _, outcome := range outcomes {
+		if outcome {
+			positives++
+		}
+	}
+	negatives := len(outcomes) - positives
+	high := (float64(positives) + 1) / (float64(positives) + 2)
+	low := 1 / (float64(negatives) + 2)
+
+	scores := make([]float64, len(probabilities))
+	targets := make([]float64, len(probabilities))
+	for i, p := range probabilities {
+		scores[i] = logit(p)
+		targets[i] = low
+		if outcomes[i] {
+			targets[i] = high
+		}
+	}
+
+	loss := func(a, b float64) float64 {
+		total := 0.0
+		for i, z := range scores {
+			q := math.Min(math.Max(sigmoid(a*z+b), 1e-15), 1-1e-15)
+			total -= targets[i]*math.Log(q) + (1-targets[i])*math.Log(1-q)
+		}
+		return total
+	}
+
+	a, b := 1.0, 0.0
+	current := loss(a, b)
+	for iteration := 0; iteration < 100; iteration++ {
+		// Gradient and Hessian of the log loss, with a little damping for flat directions
+		var ga, gb, haa, hab, hbb float64
+		for i, z := range scores {
+			q := sigmoid(a*z + b)
+			d := q - targets[i]
+			w := q * (1 - q)
+			ga += d * z
+			gb += d
+			haa += w * z * z
+			hab += w * z
+			hbb += w
+		}
+		haa += 1e-12
+		hbb += 1e-12
+		det := haa*hbb - hab*hab
+		if det <= 0 {
+			break
+		}
+		da := (hbb*ga - hab*gb) / det
+		db := (haa*gb - hab*ga) / det
+
+		// Halve the step until the loss decreases
+		step := 1.0
+		for ; step > 1e-10; step /= 2 {
+			if next := loss(a-step*da, b-step*db); next <= current {
+				a, b, current = a-step*da, b-step*db, next
+				break
+			}
+		}
+		if step <= 1e-10 || math.Abs(da)+math.Abs(db) < 1e-10 {
+			break
+		}
+	}
+	return a, b
+}
+
+// fitIsotonic fits a non-decreasing step function to the outcomes with the pool adjacent
+// violators algorithm, and returns the first and last probability of every step with its value
+func fitIsotonic(probabilities []float64, outcomes []bool) ([]float64, []float64) {
+	order := make([]int, len(probabilities))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return probabilities[order[i]] < probabilities[order[j]] })
+
+	type block struct {
+		lo, hi float64 // First and last probability in the block
+		sum    float64
+		count  float64
+	}
+	var blocks []block
+	for _, idx := range order {
+		p := probabilities[idx]
+		current := block{lo: p, hi: p, sum: indicator(outcomes[idx]), count: 1}
+
+		// Equal probabilities must get the same value
+		if n := len(blocks); n > 0 && blocks[n-1].hi == p {
+			current.lo = blocks[n-1].lo
+			current.sum += blocks[n-1].sum
+			current.count += blocks[n-1].count
+			blocks = blocks[:n-1]
+		}
+		for n := len(blocks); n > 0 && blocks[n-1].sum/blocks[n-1].count >= current.sum/current.count; n = len(blocks) {
+			current.lo = blocks[n-1].lo
+			current.sum += blocks[n-1].sum
+			current.count += blocks[n-1].count
+			blocks = blocks[:n-1]
+		}
+		blocks = append(blocks, current)
+	}
+
+	var thresholds, values []float64
+	for _, b := range blocks {
+		value := b.sum / b.count
+		thresholds = append(thresholds, b.lo)
+		values = append(values, value)
+		if b.hi > b.lo {
+			thresholds = append(thresholds, b.hi)
+			values = append(values, value)
+		}
+	}
+	return thresholds, values
+}
+
+// ReliabilityBin is one bin of a reliability curve
+type ReliabilityBin struct {
+	Lower     float64 `json:"lower"`
+	Upper     float64 `json:"upper"`
+	Count     int     `json:"count"`
+	Predicted float64 `json:"predicted"` // Mean predicted probability of the rows in the bin
+	Observed  float64 `json:"observed"`  // Share of the rows in the bin whose outcome occurred
+}
+
+// Reliability describes how well predicted probabilities match observed frequencies
+type Reliability struct {
+	Bins []ReliabilityBin `json:"bins"` // Equal-width bins of the predicted probability; empty bins are left out
+	ECE  float64          `json:"ece"`  // Expected calibration error, the row-weighted mean gap between predicted and observed
+}
+
+// ReliabilityCurve bins predicted probabilities and compares them with the observed outcomes
+func ReliabilityCurve(probabilities []float64, outcomes []bool, bins int) *Reliability {
+	if bins < 1 {
+		bins = reliabilityBins
+	}
+	stats := make([]ReliabilityBin, bins)
+	for b := range stats {
+		stats[b].Lower = float64(b) / float64(bins)
+		stats[b].Upper = float64(b+1) / float64(bins)
+	}
+	for i, p := range probabilities {
+		b := min(max(int(p*float64(bins)), 0), bins-1)
+		stats[b].Count++
+		stats[b].Predicted += p
+		stats[b].Observed += indicator(outcomes[i])
+	}
+
+	reliability := &Reliability{}
+	for _, bin := range stats {
+		if bin.Count == 0 {
+			continue
+		}
+		bin.Predicted /= float64(bin.Count)
+		bin.Observed /= float64(bin.Count)
+		reliability.ECE += float64(bin.Count) / float64(len(probabilities)) * math.Abs(bin.Predicted-bin.Observed)
+		reliability.Bins = append(reliability.Bins, bin)
+	}
+	return reliability
+}
+
+// TargetCalibration compares the probabilities of a target before and after calibration
+// Categorical targets are measured by the probability of the predicted category
+type TargetCalibration struct {
+	Target string       `json:"target"`
+	Before *Reliability `json:"before"`
+	After  *Reliability `json:"after"`
+}
+
+// CalibrationReport is the result of calibrating a model
+type CalibrationReport struct {
+	Method  CalibrationMethod   `json:"method"`
+	Targets []TargetCalibration `json:"targets"`
+}
+
+// calibratedProbability calibrates the probability of a boolean target
+func (m *Model) calibratedProbability(target string, p float64) float64 {
+	if calibrator := m.Calibration[target]; calibrator != nil {
+		return calibrator.Apply(p)
+	}
+	return p
+}
+
+// calibratedCategories calibrates the probability of every category of a target one against
+// the rest, and normalizes them to sum to 1 again
+func (m *Model) calibratedCategories(target string, probabilities map[string]float64) map[string]float64 {
+	if len(m.Calibration) == 0 {
+		return probabilities
+	}
+
+	calibrated := make(map[string]float64, len(probabilities))
+	total := 0.0
+	for category, p := range probabilities {
+		if calibrator := m.Calibration[target+":"+category]; calibrator != nil {
+			p = calibrator.Apply(p)
+		}
+		calibrated[category] = p
+		total += p
+	}
+	if total <= 0 {
+		return probabilities
+	}
+	for category := range calibrated {
+		calibrated[category] /= total
+	}
+	return calibrated
+}
+
+// probabilities returns the uncalibrated probabilities of the boolean targets and of the
+// categories of the categorical targets of a logistic, categorical or mixed model
+func (m *Model) probabilities(input map[string]interface{}, weights *Weights) (map[string]float64, map[string]map[string]float64, error) {
+	row := m.preprocess(input)
+	binary := make(map[string]float64)
+	categorical := make(map[string]map[string]float64)
+
+	if m.Type == "logistic" || m.Type == "mixed" {
+		prediction, err := predictLogisticModel(row, weights)
+		if err != nil {
+			return nil, nil, err
+		}
+		for target, val := range prediction {
+			if p, ok := val.(float64); ok && (m.Type == "logistic" || m.Targets[target] == "boolean") {
+				binary[target] = p
+			}
+		}
+	}
+
+	if m.Type == "categorical" || m.Type == "mixed" {
+		uncalibrated := *m
+		uncalibrated.Calibration = nil
+		prediction, err := predictCategoricalModel(row, weights, &uncalibrated)
+		if err != nil {
+			return nil, nil, err
+		}
+		for target := range m.Categories {
+			if probs, ok := prediction[target+"_probs"].(map[string]float64); ok && (m.Type == "categorical" || m.Targets[target] == "categorical") {
+				categorical[target] = probs
+			}
+		}
+	}
+
+	if m.Type != "logistic" && m.Type != "categorical" && m.Type != "mixed" {
+		return nil, nil, fmt.Errorf("model type %q does not predict probabilities: %w", m.Type, ErrUnsupportedModelType)
+	}
+	return binary, categorical, nil
+}
+
+// isPositive reports whether an observed value of a boolean target is true
+func isPositive(val interface{}) bool {
+	if b, ok := ConvertToBool(val); ok {
+		return b
+	}
+	x, _ := numericValue(val)
+	return x >= 0.5
+}
+
+// Calibrate fits probability calibrators to held-out rows and stores them with the model
+// The probabilities of logistic targets, the boolean targets of mixed models and every
+// category of categorical targets are calibrated, and Predict applies the calibrators from
+// then on. Category probabilities are calibrated one against the rest and normalized, so the
+// predicted category may change. The rows must not have been used for training; the report
+// compares the reliability of the probabilities on them before and after calibration.
+// Train, PartialFit and TrainStream discard the calibrators, since they describe the previous weights
+func (e *Engine) Calibrate(inputs []map[string]interface{}, outputs []map[string]interface{}, method CalibrationMethod) (*CalibrationReport, error) {
+	switch method {
+	case CalibratePlatt, CalibrateIsotonic:
+	default:
+		return nil, fmt.Errorf("unknown calibration method %q: %w", method, ErrInvalidInput)
+	}
+	if len(inputs) != len(outputs) {
+		return nil, fmt.Errorf("number of input samples (%d) must match number of output samples (%d)", len(inputs), len(outputs))
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no calibration data provided")
+	}
+
+	e.trainMu.Lock()
+	defer e.trainMu.Unlock()
+
+	model, weights, _ := e.snapshot()
+	if model == nil {
+		return nil, fmt.Errorf("model not initialized")
+	}
+	if weights == nil {
+		return nil, ErrModelNotTrained
+	}
+
+	// Published models are never mutated, so the calibrators go on a copy
+	calibrated, err := model.clone()
+	if err != nil {
+		return nil, err
+	}
+	calibrated.Calibration = nil
+
+	// Held-out probabilities and outcomes, per boolean target and per category
+	binaryProbs := make(map[string][]float64)
+	binaryOutcomes := make(map[string][]bool)
+	categoryProbs := make(map[string]map[string][]float64)
+	observed := make(map[string][]string)
+	for i, input := range inputs {
+		binary, categorical, err := calibrated.probabilities(input, weights)
+		if err != nil {
+			return nil, err
+		}
+		for target, p := range binary {
+			if y, exists := outputs[i][target]; exists && !isMissing(y) {
+				binaryProbs[target] = append(binaryProbs[target], p)
+				binaryOutcomes[target] = append(binaryOutcomes[target], isPositive(y))
+			}
+		}
+		for target, probs := range categorical {
+			y, exists := outputs[i][target]
+			if !exists || isMissing(y) {
+				continue
+			}
+			if categoryProbs[target] == nil {
+				categoryProbs[target] = make(map[string][]float64)
+			}
+			for category := range calibrated.Categories[target] {
+				categoryProbs[target][category] = append(categoryProbs[target][category], probs[category])
+			}
+			observed[target] = append(observed[target], fmt.Sprintf("%v", y))
+		}
+	}
+	if len(binaryProbs) == 0 && len(categoryProbs) == 0 {
+		return nil, fmt.Errorf("no calibration rows have a boolean or categorical target: %w", ErrInvalidOutput)
+	}
+
+	calibrated.Calibration = make(map[string]*Calibrator)
+	report := &CalibrationReport{Method: method}
+	for target, probs := range binaryProbs {
+		calibrator := fitCalibrator(method, probs, binaryOutcomes[target])
+		calibrated.Calibration[target] = calibrator
+
+		after := make([]float64, len(probs))
+		for i, p := range probs {
+			after[i] = calibrator.Apply(p)
+		}
+		report.Targets = append(report.Targets, TargetCalibration{
+			Target: target,
+			Before: ReliabilityCurve(probs, binaryOutcomes[target], reliabilityBins),
+			After:  ReliabilityCurve(after, binaryOutcomes[target], reliabilityBins),
+		})
+	}
+
+	for target, byCategory := range categoryProbs {
+		for category, probs := range byCategory {
+			outcomes := make([]bool, len(probs))
+			for i := range outcomes {
+				outcomes[i] = observed[target][i] == category
+			}
+			calibrated.Calibration[target+":"+category] = fitCalibrator(method, probs, outcomes)
+		}
+
+		// Categorical targets are measured by the confidence in the predicted category
+		rows := len(observed[target])
+		before, after := make([]float64, rows), make([]float64, rows)
+		correctBefore, correctAfter := make([]bool, rows), make([]bool, rows)
+		for i := 0; i < rows; i++ {
+			probs := make(map[string]float64, len(byCategory))
+			for category, values := range byCategory {
+				probs[category] = values[i]
+			}
+			best := mostProbable(probs)
+			before[i], correctBefore[i] = probs[best], best == observed[target][i]
+
+			probs = calibrated.calibratedCategories(target, probs)
+			best = mostProbable(probs)
+			after[i], correctAfter[i] = probs[best], best == observed[target][i]
+		}
+		report.Targets = append(report.Targets, TargetCalibration{
+			Target: target,
+			Before: ReliabilityCurve(before, correctBefore, reliabilityBins),
+			After:  ReliabilityCurve(after, correctAfter, reliabilityBins),
+		})
+	}
+	sort.Slice(report.Targets, func(i, j int) bool { return report.Targets[i].Target < report.Targets[j].Target })
+
+	e.mu.Lock()
+	e.model = calibrated
+	e.mu.Unlock()
+	return report, nil
+}
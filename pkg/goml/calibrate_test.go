@@ -0,0 +1,177 @@
+package goml
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// TestCalibrators tests Platt scaling and isotonic regression
+func TestCalibrators(t *testing.T) {
+	// The model is underconfident: the true log odds are twice the predicted ones
+	rng := rand.New(rand.NewSource(3))
+	var probabilities []float64
+	var outcomes []bool
+	for i := 0; i < 5000; i++ {
+		p := 0.05 + 0.9*rng.Float64()
+		probabilities = append(probabilities, p)
+		outcomes = append(outcomes, rng.Float64() < sigmoid(2*logit(p)))
+	}
+	platt := fitCalibrator(CalibratePlatt, probabilities, outcomes)
+	if math.Abs(platt.A-2) > 0.2 || math.Abs(platt.B) > 0.2 {
+		t.Errorf("Expected Platt scaling near a=2, b=0, got a=%v, b=%v", platt.A, platt.B)
+	}
+
+	isotonic := fitCalibrator(CalibrateIsotonic, []float64{0.1, 0.2, 0.3, 0.4}, []bool{false, true, false, true})
+	if !reflect.DeepEqual(isotonic.Thresholds, []float64{0.1, 0.2, 0.3, 0.4}) || !reflect.DeepEqual(isotonic.Values, []float64{0, 0.5, 0.5, 1}) {
+		t.Errorf("Expected the violating rows to be pooled, got %v and %v", isotonic.Thresholds, isotonic.Values)
+	}
+	for p, want := range map[float64]float64{0.05: 0, 0.15: 0.25, 0.25: 0.5, 0.9: 1} {
+		if got := isotonic.Apply(p); math.Abs(got-want) > 1e-12 {
+			t.Errorf("Expected isotonic calibration of %v to be %v, got %v", p, want, got)
+		}
+	}
+
+	reliability := ReliabilityCurve([]float64{0.05, 0.15, 0.95, 0.95}, []bool{false, false, true, false}, 10)
+	if len(reliability.Bins) != 3 || reliability.Bins[2].Count != 2 || reliability.Bins[2].Observed != 0.5 {
+		t.Errorf("Expected three non-empty bins, got %+v", reliability.Bins)
+	}
+	if math.Abs(reliability.ECE-0.275) > 1e-12 {
+		t.Errorf("Expected an expected calibration error of 0.275, got %v", reliability.ECE)
+	}
+}
+
+// calibrationRows returns rows whose outcome is random with a probability rising with x
+func calibrationRows(seed int64, n int) ([]map[string]interface{}, []map[string]interface{}) {
+	rng := rand.New(rand.NewSource(seed))
+	var inputs, outputs []map[string]interface{}
+	for i := 0; i < n; i++ {
+		x := rng.Float64()*4 + 1
+		positive := rng.Float64() < (x-1)/4
+		label := "no"
+		if positive {
+			label = "yes"
+		}
+		inputs = append(inputs, map[string]interface{}{"x": x})
+		outputs = append(outputs, map[string]interface{}{"flag": indicator(positive), "label": label})
+	}
+	return inputs, outputs
+}
+
+// TestCalibrateLogistic tests that calibrators are stored with the model and applied by Predict
+func TestCalibrateLogistic(t *testing.T) {
+	inputs, outputs := calibrationRows(1, 200)
+	for i := range outputs {
+		outputs[i] = map[string]interface{}{"flag": outputs[i]["flag"]}
+	}
+
+	engine := New()
+	engine.WithModel(NewLogisticModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 50, BatchSize: 20})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+	before, _ := engine.Predict(map[string]interface{}{"x": 4.0})
+
+	heldInputs, heldOutputs := calibrationRows(2, 500)
+	report, err := engine.Calibrate(heldInputs, heldOutputs, CalibrateIsotonic)
+	if err != nil {
+		t.Fatalf("Calibration failed: %v", err)
+	}
+	if len(report.Targets) != 1 || report.Targets[0].Target != "flag" {
+		t.Fatalf("Expected a report for flag, got %+v", report.Targets)
+	}
+	if calibration := report.Targets[0]; calibration.After.ECE > calibration.Before.ECE {
+		t.Errorf("Expected calibration to reduce the calibration error, got %v before and %v after", calibration.Before.ECE, calibration.After.ECE)
+	}
+
+	after, _ := engine.Predict(map[string]interface{}{"x": 4.0})
+	model, _, _ := engine.snapshot()
+	if want := model.Calibration["flag"].Apply(before["flag"].(float64)); after["flag"] != want {
+		t.Errorf("Expected Predict to return the calibrated probability %v, got %v", want, after["flag"])
+	}
+
+	modelJSON, _ := engine.GetModel()
+	var saved Model
+	json.Unmarshal([]byte(*modelJSON), &saved)
+	if saved.Calibration["flag"] == nil || saved.Calibration["flag"].Method != CalibrateIsotonic {
+		t.Errorf("Expected the calibrator to be saved with the model, got %+v", saved.Calibration)
+	}
+
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Retraining failed: %v", err)
+	}
+	if model, _, _ := engine.snapshot(); model.Calibration != nil {
+		t.Errorf("Expected retraining to discard the calibrators")
+	}
+
+	// Every way of changing the weights discards the calibrators
+	for name, train := range map[string]func() error{
+		"partial fit":  func() error { return engine.PartialFit(inputs, outputs) },
+		"stream train": func() error { return engine.TrainStream(NewSliceDataset(inputs, outputs)) },
+	} {
+		if _, err := engine.Calibrate(heldInputs, heldOutputs, CalibratePlatt); err != nil {
+			t.Fatalf("Calibration failed: %v", err)
+		}
+		if err := train(); err != nil {
+			t.Fatalf("Training with %s failed: %v", name, err)
+		}
+		if model, _, _ := engine.snapshot(); model.Calibration != nil {
+			t.Errorf("Expected %s to discard the calibrators", name)
+		}
+	}
+
+	if _, err := engine.Calibrate(heldInputs, heldOutputs, "beta"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for an unknown method, got %v", err)
+	}
+}
+
+// TestCalibrateCategorical tests calibration of category probabilities
+func TestCalibrateCategorical(t *testing.T) {
+	inputs, outputs := calibrationRows(1, 200)
+	for i := range outputs {
+		outputs[i] = map[string]interface{}{"label": outputs[i]["label"]}
+	}
+
+	engine := New()
+	engine.WithModel(NewCategoricalModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 50, BatchSize: 20})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	heldInputs, heldOutputs := calibrationRows(2, 500)
+	report, err := engine.Calibrate(heldInputs, heldOutputs, CalibratePlatt)
+	if err != nil {
+		t.Fatalf("Calibration failed: %v", err)
+	}
+	if len(report.Targets) != 1 || report.Targets[0].After == nil {
+		t.Fatalf("Expected a report for label, got %+v", report.Targets)
+	}
+
+	model, _, _ := engine.snapshot()
+	for _, key := range []string{"label:yes", "label:no"} {
+		if model.Calibration[key] == nil {
+			t.Errorf("Expected a calibrator for %s", key)
+		}
+	}
+
+	prediction, err := engine.Predict(map[string]interface{}{"x": 4.5})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	probs := prediction["label_probs"].(map[string]float64)
+	if math.Abs(probs["yes"]+probs["no"]-1) > 1e-9 || prediction["label"] != "yes" {
+		t.Errorf("Expected normalized calibrated probabilities favouring yes, got %v", prediction)
+	}
+
+	linear := New()
+	linear.WithModel(NewLinearModel().JSON())
+	linear.Train(heldInputs[:2], []map[string]interface{}{{"y": 1.0}, {"y": 2.0}})
+	if _, err := linear.Calibrate(heldInputs, heldOutputs, CalibratePlatt); !errors.Is(err, ErrUnsupportedModelType) {
+		t.Errorf("Expected ErrUnsupportedModelType for a linear model, got %v", err)
+	}
+}
@@ -3,31 +3,27 @@ package goml
 import (
 	"fmt"
 	"math"
+	"math/rand"
+	"sort"
 	"strings"
 )
 
 // trainCategoricalModel implements categorical classification training
 func trainCategoricalModel(inputs []map[string]interface{}, outputs []map[string]interface{}, weights *Weights, config *Config, model *Model) error {
-	// Get feature names from the first input
+	// Get feature names from the inputs
 	if len(inputs) == 0 {
 		return ErrInvalidInput
 	}
 
-	// Extract feature names from first input
-	features := make([]string, 0, len(inputs[0]))
-	for key := range inputs[0] {
-		features = append(features, key)
-	}
+	// Extract feature names from all inputs, since rows may omit missing features
+	features := sortedRowKeys(inputs)
 
-	// Extract target variable names from first output
+	// Extract target variable names from all outputs
 	if len(outputs) == 0 {
 		return ErrInvalidOutput
 	}
 
-	targets := make([]string, 0, len(outputs[0]))
-	for key := range outputs[0] {
-		targets = append(targets, key)
-	}
+	targets := sortedRowKeys(outputs)
 
 	// Initialize or clear the categories map if needed
 	if model.Categories == nil {
@@ -50,9 +46,9 @@ func trainCategoricalModel(inputs []map[string]interface{}, outputs []map[string
 			}
 		}
 
-		// Assign indices to categories
-		idx := 0
-		for category := range categoryCount {
+		// Assign indices to new categories in lexical order, after any existing ones
+		idx := len(model.Categories[target])
+		for _, category := range sortedCategories(categoryCount) {
 			if _, exists := model.Categories[target][category]; !exists {
 				model.Categories[target][category] = idx
 				idx++
@@ -61,150 +57,160 @@ func trainCategoricalModel(inputs []map[string]interface{}, outputs []map[string
 	}
 
 	// For each target (output variable), we train a separate set of weights
-	for _, target := range targets {
-		categories := model.Categories[target]
-		numCategories := len(categories)
+	// Targets share no weights, so they are trained concurrently
+	workers := config.workers()
+	parallelFor(len(targets), workers, func(t int) {
+		trainCategoricalTarget(inputs, outputs, targets[t], features, model.Categories[targets[t]], weights, config, newSeededRand(config.Seed, t))
+	})
 
-		if numCategories <= 1 {
-			// Trivial case, only one category
-			continue
-		}
+	return nil
+}
 
-		// For each category, we create a set of weights
-		for category, _ := range categories {
-			// For each feature, we need a weight
-			for _, feature := range features {
-				weightKey := fmt.Sprintf("%s->%s:%s", feature, target, category)
-				if _, exists := weights.Get(weightKey); !exists {
-					weights.Set(weightKey, 0.0)
-				}
-			}
+// trainCategoricalTarget runs softmax SGD for a single categorical target
+func trainCategoricalTarget(inputs []map[string]interface{}, outputs []map[string]interface{}, target string, features []string, categoryIndex map[string]int, weights *Weights, config *Config, rng *rand.Rand) {
+	categories := sortedCategories(categoryIndex)
+	numCategories := len(categories)
 
-			// Add bias term
-			biasKey := fmt.Sprintf("bias->%s:%s", target, category)
-			if _, exists := weights.Get(biasKey); !exists {
-				weights.Set(biasKey, 0.0)
+	if numCategories <= 1 {
+		// Trivial case, only one category
+		return
+	}
+
+	// For each category, we create a set of weights
+	for _, category := range categories {
+		// For each feature, we need a weight
+		for _, feature := range features {
+			weightKey := fmt.Sprintf("%s->%s:%s", feature, target, category)
+			if _, exists := weights.Get(weightKey); !exists {
+				weights.Set(weightKey, 0.0)
 			}
 		}
 
-		// We use a softmax approach for multi-class classification
-		// Similar to logistic regression but with multiple outputs
-		for epoch := 0; epoch < config.Epochs; epoch++ {
-			// Use stochastic gradient descent
-			for i := range inputs {
-				// First calculate scores for each category
-				categoryScores := make(map[string]float64)
-
-				for category := range categories {
-					score := 0.0
-
-					// Compute weighted sum for this category
-					for _, feature := range features {
-						weightKey := fmt.Sprintf("%s->%s:%s", feature, target, category)
-						featureWeight, _ := weights.GetFloat(weightKey)
-
-						featureVal, ok := inputs[i][feature]
-						if !ok {
-							continue
-						}
+		// Add bias term
+		biasKey := fmt.Sprintf("bias->%s:%s", target, category)
+		if _, exists := weights.Get(biasKey); !exists {
+			weights.Set(biasKey, 0.0)
+		}
+	}
 
-						// Convert feature value
-						var featureValFloat float64
-						switch v := featureVal.(type) {
-						case float64:
-							featureValFloat = v
-						case int:
-							featureValFloat = float64(v)
-						case string:
-							// One-hot encoding for string features
-							if v == feature {
-								featureValFloat = 1.0
-							} else {
-								featureValFloat = 0.0
-							}
-						default:
-							continue
-						}
+	// We use a softmax approach for multi-class classification
+	// Similar to logistic regression but with multiple outputs
+	for epoch := 0; epoch < config.Epochs; epoch++ {
+		// Visit samples in a seeded random order when shuffling is enabled
+		epochInputs, epochOutputs := shuffleSamples(inputs, outputs, rng)
 
-						score += featureWeight * featureValFloat
+		// Use stochastic gradient descent
+		for i := range epochInputs {
+			// First calculate scores for each category
+			categoryScores := make(map[string]float64)
+
+			for _, category := range categories {
+				score := 0.0
+
+				// Compute weighted sum for this category
+				for _, feature := range features {
+					weightKey := fmt.Sprintf("%s->%s:%s", feature, target, category)
+					featureWeight, _ := weights.GetFloat(weightKey)
+
+					featureVal, ok := epochInputs[i][feature]
+					if !ok {
+						continue
 					}
 
-					// Add bias
-					biasKey := fmt.Sprintf("bias->%s:%s", target, category)
-					bias, _ := weights.GetFloat(biasKey)
-					score += bias
+					// Convert feature value
+					var featureValFloat float64
+					switch v := featureVal.(type) {
+					case float64:
+						featureValFloat = v
+					case int:
+						featureValFloat = float64(v)
+					case string:
+						// One-hot encoding for string features
+						if v == feature {
+							featureValFloat = 1.0
+						} else {
+							featureValFloat = 0.0
+						}
+					default:
+						continue
+					}
 
-					categoryScores[category] = score
+					score += featureWeight * featureValFloat
 				}
 
-				// Apply softmax to get probabilities
-				probabilities := softmax(categoryScores)
+				// Add bias
+				biasKey := fmt.Sprintf("bias->%s:%s", target, category)
+				bias, _ := weights.GetFloat(biasKey)
+				score += bias
 
-				// Get actual output category
-				actualValue, ok := outputs[i][target]
-				if !ok {
-					continue
-				}
+				categoryScores[category] = score
+			}
 
-				actualCategory := fmt.Sprintf("%v", actualValue)
+			// Apply softmax to get probabilities
+			probabilities := softmax(categoryScores)
 
-				// Update weights using the difference between predicted and actual
-				for category := range categories {
-					// Target probability (1 for the true category, 0 for others)
-					targetProbability := 0.0
-					if category == actualCategory {
-						targetProbability = 1.0
-					}
+			// Get actual output category
+			actualValue, ok := epochOutputs[i][target]
+			if !ok {
+				continue
+			}
 
-					// Calculate gradient
-					gradient := probabilities[category] - targetProbability
+			actualCategory := fmt.Sprintf("%v", actualValue)
 
-					// Update weights for this category
-					for _, feature := range features {
-						weightKey := fmt.Sprintf("%s->%s:%s", feature, target, category)
-						currentWeight, _ := weights.GetFloat(weightKey)
+			// Update weights using the difference between predicted and actual
+			for _, category := range categories {
+				// Target probability (1 for the true category, 0 for others)
+				targetProbability := 0.0
+				if category == actualCategory {
+					targetProbability = 1.0
+				}
 
-						featureVal, ok := inputs[i][feature]
-						if !ok {
-							continue
-						}
+				// Calculate gradient
+				gradient := probabilities[category] - targetProbability
 
-						// Convert feature value
-						var featureValFloat float64
-						switch v := featureVal.(type) {
-						case float64:
-							featureValFloat = v
-						case int:
-							featureValFloat = float64(v)
-						case string:
-							if v == feature {
-								featureValFloat = 1.0
-							} else {
-								featureValFloat = 0.0
-							}
-						default:
-							continue
-						}
+				// Update weights for this category
+				for _, feature := range features {
+					weightKey := fmt.Sprintf("%s->%s:%s", feature, target, category)
+					currentWeight, _ := weights.GetFloat(weightKey)
 
-						// Apply regularization
-						regularizationTerm := config.Regularize * currentWeight
+					featureVal, ok := epochInputs[i][feature]
+					if !ok {
+						continue
+					}
 
-						// Update weight
-						newWeight := currentWeight - config.LearningRate*(gradient*featureValFloat+regularizationTerm)
-						weights.Set(weightKey, newWeight)
+					// Convert feature value
+					var featureValFloat float64
+					switch v := featureVal.(type) {
+					case float64:
+						featureValFloat = v
+					case int:
+						featureValFloat = float64(v)
+					case string:
+						if v == feature {
+							featureValFloat = 1.0
+						} else {
+							featureValFloat = 0.0
+						}
+					default:
+						continue
 					}
 
-					// Update bias term (no regularization for bias)
-					biasKey := fmt.Sprintf("bias->%s:%s", target, category)
-					currentBias, _ := weights.GetFloat(biasKey)
-					newBias := currentBias - config.LearningRate*gradient
-					weights.Set(biasKey, newBias)
+					// Apply regularization
+					regularizationTerm := config.Regularize * currentWeight
+
+					// Update weight
+					newWeight := currentWeight - config.LearningRate*(gradient*featureValFloat+regularizationTerm)
+					weights.Set(weightKey, newWeight)
 				}
+
+				// Update bias term (no regularization for bias)
+				biasKey := fmt.Sprintf("bias->%s:%s", target, category)
+				currentBias, _ := weights.GetFloat(biasKey)
+				newBias := currentBias - config.LearningRate*gradient
+				weights.Set(biasKey, newBias)
 			}
 		}
 	}
-
-	return nil
 }
 
 // predictCategoricalModel implements categorical classification prediction
@@ -262,43 +268,14 @@ func predictCategoricalModel(input map[string]interface{}, weights *Weights, mod
 		}
 
 		// Apply softmax to get probabilities
-		probabilities := softmax(categoryScores)
-
-		// Find the category with the highest probability
-		var bestCategory string
-		var bestProb float64
+		probabilities := model.calibratedCategories(target, softmax(categoryScores))
 
-		for category, prob := range probabilities {
-			if prob > bestProb {
-				bestProb = prob
-				bestCategory = category
-			}
-		}
+		// Find the category with the highest probability, the first in lexical order on ties
+		bestCategory := mostProbable(probabilities)
 
 		// Store both the predicted category and the probabilities
 		if bestCategory != "" {
-			// Convert back to the original type if possible
-			// Try conversion to number if the category looks like a number
-			if isNumeric(bestCategory) {
-				if strings.Contains(bestCategory, ".") {
-					// Try as float64
-					if val, err := stringToFloat64(bestCategory); err == nil {
-						result[target] = val
-					} else {
-						result[target] = bestCategory
-					}
-				} else {
-					// Try as int
-					if val, err := stringToInt(bestCategory); err == nil {
-						result[target] = val
-					} else {
-						result[target] = bestCategory
-					}
-				}
-			} else {
-				// Use as string
-				result[target] = bestCategory
-			}
+			result[target] = categoryValue(bestCategory)
 
 			// Store probabilities in a nested map
 			probsMap := make(map[string]float64)
@@ -312,6 +289,68 @@ func predictCategoricalModel(input map[string]interface{}, weights *Weights, mod
 	return result, nil
 }
 
+// categoryValue converts a category back to its original type: categories that look like
+// numbers are returned as float64 or int
+func categoryValue(category string) interface{} {
+	if !isNumeric(category) {
+		return category
+	}
+	if strings.Contains(category, ".") {
+		if val, err := stringToFloat64(category); err == nil {
+			return val
+		}
+		return category
+	}
+	if val, err := stringToInt(category); err == nil {
+		return val
+	}
+	return category
+}
+
+// RankedCategory is a category of a categorical target with its predicted probability
+type RankedCategory struct {
+	Category    string  `json:"category"`
+	Probability float64 `json:"probability"`
+}
+
+// rankCategories orders categories by decreasing probability, ties in lexical order
+func rankCategories(probs map[string]float64) []RankedCategory {
+	ranked := make([]RankedCategory, 0, len(probs))
+	for category, p := range probs {
+		ranked = append(ranked, RankedCategory{Category: category, Probability: p})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Probability != ranked[j].Probability {
+			return ranked[i].Probability > ranked[j].Probability
+		}
+		return ranked[i].Category < ranked[j].Category
+	})
+	return ranked
+}
+
+// categoryOptions applies the prediction options of a config to a categorical target: the
+// probability of the predicted category under "<target>_prob", the Config.TopK most probable
+// categories under "<target>_top", and abstention below Config.MinConfidence under
+// "<target>_abstain". Abstention leaves the target at its most probable category, so that it
+// cannot be mistaken for a category of the training data
+func (m *Model) categoryOptions(result map[string]interface{}, target string, config *Config) {
+	probs, ok := result[target+"_probs"].(map[string]float64)
+	if config == nil || !ok || len(probs) == 0 {
+		return
+	}
+
+	ranked := rankCategories(probs)
+	if config.Probabilities {
+		result[target+"_prob"] = ranked[0].Probability
+	}
+	if config.TopK > 0 {
+		result[target+"_top"] = ranked[:min(config.TopK, len(ranked))]
+	}
+	if config.MinConfidence > 0 {
+		result[target+"_abstain"] = ranked[0].Probability < config.MinConfidence
+	}
+}
+
 // softmax computes the softmax of a set of scores
 func softmax(scores map[string]float64) map[string]float64 {
 	// Find the maximum score to avoid overflow
@@ -323,11 +362,12 @@ func softmax(scores map[string]float64) map[string]float64 {
 	}
 
 	// Compute exp(score - maxScore) for each category
+	// Categories are summed in lexical order so probabilities are reproducible
 	expScores := make(map[string]float64)
 	var sumExp float64
 
-	for category, score := range scores {
-		expScore := math.Exp(score - maxScore)
+	for _, category := range sortedScoreKeys(scores) {
+		expScore := math.Exp(scores[category] - maxScore)
 		expScores[category] = expScore
 		sumExp += expScore
 	}
@@ -341,6 +381,26 @@ func softmax(scores map[string]float64) map[string]float64 {
 	return probabilities
 }
 
+// sortedCategories returns the categories of a category->value map in lexical order
+func sortedCategories(categories map[string]int) []string {
+	keys := make([]string, 0, len(categories))
+	for category := range categories {
+		keys = append(keys, category)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedScoreKeys returns the categories of a score map in lexical order
+func sortedScoreKeys(scores map[string]float64) []string {
+	keys := make([]string, 0, len(scores))
+	for category := range scores {
+		keys = append(keys, category)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Helper functions for type conversion
 func isNumeric(s string) bool {
 	// Check if the string represents a number
@@ -0,0 +1,93 @@
+package goml
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// colorRows returns rows whose color depends on x, with "blue" and "green" sharing the high
+// values and "unknown" as a real color of the low values
+func colorRows() ([]map[string]interface{}, []map[string]interface{}) {
+	var inputs, outputs []map[string]interface{}
+	for i := 0; i < 60; i++ {
+		x := float64(i%6 + 1)
+		color := "unknown"
+		if x > 3 {
+			color = []string{"blue", "green"}[(i/6)%2]
+		}
+		inputs = append(inputs, map[string]interface{}{"x": x})
+		outputs = append(outputs, map[string]interface{}{"color": color})
+	}
+	return inputs, outputs
+}
+
+// TestRankCategories tests ranking with lexical tie-breaking
+func TestRankCategories(t *testing.T) {
+	probs := map[string]float64{"c": 0.2, "b": 0.4, "a": 0.4}
+	want := []RankedCategory{{"a", 0.4}, {"b", 0.4}, {"c", 0.2}}
+	if ranked := rankCategories(probs); !reflect.DeepEqual(ranked, want) {
+		t.Errorf("Expected %v, got %v", want, ranked)
+	}
+
+	// Untrained weights give every category the same probability
+	inputs, outputs := colorRows()
+	engine := New()
+	engine.WithModel(NewCategoricalModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 0, BatchSize: 10})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		prediction, _ := engine.Predict(map[string]interface{}{"x": 2.0})
+		if prediction["color"] != "blue" {
+			t.Fatalf("Expected ties to go to the first category in lexical order, got %v", prediction)
+		}
+	}
+}
+
+// TestPredictTopK tests ranked categories and abstention on low confidence
+func TestPredictTopK(t *testing.T) {
+	inputs, outputs := colorRows()
+	engine := New()
+	engine.WithModel(NewCategoricalModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 200, BatchSize: 10, Seed: 1, TopK: 2})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	high := map[string]interface{}{"x": 6.0}
+	prediction, err := engine.Predict(high)
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	top := prediction["color_top"].([]RankedCategory)
+	probs := prediction["color_probs"].(map[string]float64)
+	if len(top) != 2 || top[0].Category != prediction["color"] || top[0].Probability < top[1].Probability || top[1].Category == "unknown" {
+		t.Errorf("Expected blue and green as the two best categories, got %v", top)
+	}
+	for _, ranked := range top {
+		if probs[ranked.Category] != ranked.Probability {
+			t.Errorf("Expected the probability %v for %s, got %v", probs[ranked.Category], ranked.Category, ranked.Probability)
+		}
+	}
+	if _, exists := prediction["color_abstain"]; exists {
+		t.Errorf("Expected no abstention without a minimum confidence, got %v", prediction)
+	}
+
+	// Blue and green are equally likely for high values, so the model must abstain on them
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 200, BatchSize: 10, Seed: 1, TopK: 5, MinConfidence: 0.7})
+	prediction, _ = engine.Predict(high)
+	if prediction["color"] != top[0].Category || prediction["color_abstain"] != true {
+		t.Errorf("Expected an abstention on the most probable of blue and green, got %v", prediction)
+	}
+	if top := prediction["color_top"].([]RankedCategory); len(top) != 3 || math.Abs(top[0].Probability+top[1].Probability+top[2].Probability-1) > 1e-9 {
+		t.Errorf("Expected all three categories when K exceeds them, got %v", top)
+	}
+
+	// A confident prediction of the category "unknown" is not an abstention
+	prediction, _ = engine.Predict(map[string]interface{}{"x": 1.0})
+	if prediction["color"] != "unknown" || prediction["color_abstain"] != false {
+		t.Errorf("Expected a confident prediction of the color unknown, got %v", prediction)
+	}
+}
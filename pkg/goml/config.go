@@ -1,15 +1,26 @@
 package goml
 
-// Config includes training configuration parameters
+// Config includes training configuration parameters and prediction options
+// Probabilities, TopK and MinConfidence only change what Predict returns, and apply to
+// trained models without retraining
 type Config struct {
 	LearningRate float64 `json:"learning_rate"`
 	Epochs       int     `json:"epochs"`
 	BatchSize    int     `json:"batch_size"`
-	Regularize   float64 `json:"regularize"` // L2 regularization parameter
-	Tolerance    float64 `json:"tolerance"`  // Convergence tolerance
+	Regularize   float64 `json:"regularize"`            // L2 regularization parameter
+	Tolerance    float64 `json:"tolerance"`             // Convergence tolerance
+	Parallelism  int     `json:"parallelism,omitempty"` // Number of goroutines used for training and batch prediction (0 or 1 runs serially)
+	Seed         int64   `json:"seed,omitempty"`        // Seed for shuffling samples every epoch (0 keeps the input order)
+
+	PartialFitEpochs int  `json:"partial_fit_epochs,omitempty"` // Passes over the new data made by each PartialFit call
+	StrictSchema     bool `json:"strict_schema,omitempty"`      // Also reject missing, unknown and out-of-range features and unseen categories
+
+	Probabilities bool    `json:"probabilities,omitempty"`  // Predict labels with their probabilities under "<target>_prob"
+	TopK          int     `json:"top_k,omitempty"`          // Also predict the K most probable categories under "<target>_top"
+	MinConfidence float64 `json:"min_confidence,omitempty"` // Abstain under "<target>_abstain" when the best category is less probable
 }
 
-// DefaultConfig returns default training configuration
+// DefaultConfig returns the default training configuration, without any prediction options
 func DefaultConfig() *Config {
 	return &Config{
 		LearningRate: 0.01,
@@ -17,5 +28,18 @@ func DefaultConfig() *Config {
 		BatchSize:    32,
 		Regularize:   0.0001,
 		Tolerance:    0.0001,
+		Parallelism:  1,
+
+		PartialFitEpochs: 1,
 	}
 }
+
+// strictSchema reports whether schema validation runs in strict mode, safe on a nil config
+func (c *Config) strictSchema() bool {
+	return c != nil && c.StrictSchema
+}
+
+// probabilities reports whether predictions include the probabilities of labels, safe on a nil config
+func (c *Config) probabilities() bool {
+	return c != nil && c.Probabilities
+}
@@ -0,0 +1,424 @@
+package goml
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColumnRole describes how a loaded column is used
+type ColumnRole int
+
+const (
+	RoleInput  ColumnRole = iota // Column is a model input feature
+	RoleOutput                   // Column is a target the model predicts
+	RoleIgnore                   // Column is skipped
+	RoleID                       // Column identifies the row and is kept aside for joining predictions
+)
+
+// ColumnType is the value type inferred for a column
+type ColumnType int
+
+const (
+	ColumnUnknown ColumnType = iota // No non-empty values seen yet
+	ColumnInt                       // Values parse as integers and load as int
+	ColumnFloat                     // Values parse as numbers and load as float64
+	ColumnBool                      // Values are true/false and load as bool
+	ColumnString                    // Anything else, loaded as string
+)
+
+// String returns the name of the column type
+func (t ColumnType) String() string {
+	switch t {
+	case ColumnInt:
+		return "int"
+	case ColumnFloat:
+		return "float"
+	case ColumnBool:
+		return "bool"
+	case ColumnString:
+		return "string"
+	default:
+		return "unknown"
+	}
+}
+
+// CSVLoader reads CSV data into the input and output rows Engine.Train expects
+type CSVLoader struct {
+	Delimiter   rune                  // Field delimiter
+	Comment     rune                  // Lines starting with this rune are skipped (0 disables comments)
+	Header      bool                  // First record holds the column names
+	LazyQuotes  bool                  // Allow quotes in unquoted fields and non-doubled quotes in quoted fields
+	TrimSpace   bool                  // Trim surrounding whitespace from every field
+	Columns     []string              // Column names; required when Header is false, overrides the header otherwise
+	Roles       map[string]ColumnRole // Role per column name
+	DefaultRole ColumnRole            // Role of columns not listed in Roles
+	Types       map[string]ColumnType // Column types that override inference
+}
+
+// CSVData holds the rows produced by a CSVLoader
+type CSVData struct {
+	Inputs  []map[string]interface{} // Input features per row
+	Outputs []map[string]interface{} // Targets per row
+	IDs     []map[string]interface{} // ID columns per row, empty when no column has RoleID
+	Types   map[string]ColumnType    // Type of every loaded column
+}
+
+// NewCSVLoader creates a loader for comma separated files with a header row
+// Every column is an input unless assigned another role
+func NewCSVLoader() *CSVLoader {
+	return &CSVLoader{
+		Delimiter:   ',',
+		Header:      true,
+		TrimSpace:   true,
+		Roles:       make(map[string]ColumnRole),
+		DefaultRole: RoleInput,
+	}
+}
+
+// WithRole assigns a role to one or more columns
+func (l *CSVLoader) WithRole(role ColumnRole, columns ...string) *CSVLoader {
+	if l.Roles == nil {
+		l.Roles = make(map[string]ColumnRole)
+	}
+	for _, column := range columns {
+		l.Roles[column] = role
+	}
+	return l
+}
+
+// LoadFile reads a CSV file; see Load
+func (l *CSVLoader) LoadFile(path string) (*CSVData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer file.Close()
+
+	return l.Load(file)
+}
+
+// Load reads all CSV records, infers a type per column and splits each row by column role
+// Empty fields are treated as missing values and left out of the row maps
+func (l *CSVLoader) Load(r io.Reader) (*CSVData, error) {
+	reader := l.newReader(r)
+
+	columns, err := l.readColumns(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	types := l.inferTypes(columns, records)
+
+	data := &CSVData{Types: types}
+	hasIDs := l.hasRole(columns, RoleID)
+	for i, record := range records {
+		input, output, id, err := l.convertRecord(columns, types, record)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		data.Inputs = append(data.Inputs, input)
+		data.Outputs = append(data.Outputs, output)
+		if hasIDs {
+			data.IDs = append(data.IDs, id)
+		}
+	}
+
+	return data, nil
+}
+
+// CSVDataset streams rows from a seekable CSV source for Engine.TrainStream
+// Column types are inferred by one streaming pass when the dataset is created
+type CSVDataset struct {
+	loader  *CSVLoader
+	source  io.ReadSeeker
+	reader  *csv.Reader
+	columns []string
+	types   map[string]ColumnType
+}
+
+// NewCSVDataset creates a dataset over a seekable CSV source such as an *os.File
+func (l *CSVLoader) NewCSVDataset(source io.ReadSeeker) (*CSVDataset, error) {
+	ds := &CSVDataset{loader: l, source: source}
+
+	// Infer column types in a streaming pass, keeping only the running type per column
+	if err := ds.Reset(); err != nil {
+		return nil, err
+	}
+	ds.types = make(map[string]ColumnType)
+	for {
+		record, err := ds.reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		l.observeRecord(ds.columns, ds.types, record)
+	}
+	l.applyTypeOverrides(ds.columns, ds.types)
+
+	if err := ds.Reset(); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// Types returns the inferred type of every column
+func (d *CSVDataset) Types() map[string]ColumnType {
+	return d.types
+}
+
+// Next returns the input and output maps of the next CSV record, or io.EOF at the end
+func (d *CSVDataset) Next() (map[string]interface{}, map[string]interface{}, error) {
+	record, err := d.reader.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	input, output, _, err := d.loader.convertRecord(d.columns, d.types, record)
+	return input, output, err
+}
+
+// Reset seeks back to the start of the source and skips the header
+func (d *CSVDataset) Reset() error {
+	if _, err := d.source.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind CSV source: %w", err)
+	}
+
+	d.reader = d.loader.newReader(d.source)
+	columns, err := d.loader.readColumns(d.reader)
+	if err != nil {
+		return err
+	}
+	d.columns = columns
+	return nil
+}
+
+// newReader creates a csv.Reader configured from the loader options
+func (l *CSVLoader) newReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	if l.Delimiter != 0 {
+		reader.Comma = l.Delimiter
+	}
+	reader.Comment = l.Comment
+	reader.LazyQuotes = l.LazyQuotes
+	reader.TrimLeadingSpace = l.TrimSpace
+	return reader
+}
+
+// readColumns determines the column names, consuming the header record if there is one
+func (l *CSVLoader) readColumns(reader *csv.Reader) ([]string, error) {
+	var columns []string
+	if l.Header {
+		header, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("CSV has no header: %w", ErrInvalidInput)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		for _, name := range header {
+			columns = append(columns, strings.TrimSpace(name))
+		}
+	}
+
+	if len(l.Columns) > 0 {
+		columns = l.Columns
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("CSV column names required when there is no header: %w", ErrInvalidInput)
+	}
+	return columns, nil
+}
+
+// inferTypes determines the type of every column from all records
+func (l *CSVLoader) inferTypes(columns []string, records [][]string) map[string]ColumnType {
+	types := make(map[string]ColumnType)
+	for _, record := range records {
+		l.observeRecord(columns, types, record)
+	}
+	l.applyTypeOverrides(columns, types)
+	return types
+}
+
+// observeRecord widens the column types to accommodate the values of one record
+func (l *CSVLoader) observeRecord(columns []string, types map[string]ColumnType, record []string) {
+	for i, column := range columns {
+		if i >= len(record) || l.role(column) == RoleIgnore {
+			continue
+		}
+		types[column] = mergeColumnTypes(types[column], inferValueType(l.field(record[i])))
+	}
+}
+
+// applyTypeOverrides applies explicit types and loads columns without values as strings
+func (l *CSVLoader) applyTypeOverrides(columns []string, types map[string]ColumnType) {
+	for _, column := range columns {
+		if override, ok := l.Types[column]; ok {
+			types[column] = override
+		} else if types[column] == ColumnUnknown && l.role(column) != RoleIgnore {
+			types[column] = ColumnString
+		}
+	}
+}
+
+// convertRecord converts one record into input, output and ID maps
+func (l *CSVLoader) convertRecord(columns []string, types map[string]ColumnType, record []string) (map[string]interface{}, map[string]interface{}, map[string]interface{}, error) {
+	input := make(map[string]interface{})
+	output := make(map[string]interface{})
+	id := make(map[string]interface{})
+
+	for i, column := range columns {
+		if i >= len(record) {
+			break
+		}
+
+		role := l.role(column)
+		if role == RoleIgnore {
+			continue
+		}
+
+		field := l.field(record[i])
+		if field == "" || (isNumericColumn(types[column]) && isNonFinite(field)) {
+			// Missing value
+			continue
+		}
+
+		val, err := parseColumnValue(field, types[column])
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("column %q: %w", column, err)
+		}
+
+		switch role {
+		case RoleOutput:
+			output[column] = val
+		case RoleID:
+			id[column] = val
+		default:
+			input[column] = val
+		}
+	}
+
+	return input, output, id, nil
+}
+
+// hasRole reports whether any column has the given role
+func (l *CSVLoader) hasRole(columns []string, role ColumnRole) bool {
+	for _, column := range columns {
+		if l.role(column) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// role returns the role of a column
+func (l *CSVLoader) role(column string) ColumnRole {
+	if role, ok := l.Roles[column]; ok {
+		return role
+	}
+	return l.DefaultRole
+}
+
+// field normalizes a raw field value
+func (l *CSVLoader) field(raw string) string {
+	if l.TrimSpace {
+		return strings.TrimSpace(raw)
+	}
+	return raw
+}
+
+// inferValueType returns the narrowest type that can hold a single field value
+// NaN and infinities, which strconv.ParseFloat accepts, say nothing about the type, like
+// empty fields; in numeric columns they load as missing values
+func inferValueType(field string) ColumnType {
+	if field == "" || isNonFinite(field) {
+		return ColumnUnknown
+	}
+	if _, err := strconv.Atoi(field); err == nil {
+		return ColumnInt
+	}
+	if _, err := strconv.ParseFloat(field, 64); err == nil {
+		return ColumnFloat
+	}
+	if _, ok := parseBoolField(field); ok {
+		return ColumnBool
+	}
+	return ColumnString
+}
+
+// isNonFinite reports whether a field parses as NaN or an infinity, such as "NaN", "inf" or
+// "-Infinity" in any letter case
+func isNonFinite(field string) bool {
+	f, err := strconv.ParseFloat(field, 64)
+	return err == nil && (math.IsNaN(f) || math.IsInf(f, 0))
+}
+
+// isNumericColumn reports whether a column loads as numbers
+func isNumericColumn(columnType ColumnType) bool {
+	return columnType == ColumnInt || columnType == ColumnFloat
+}
+
+// mergeColumnTypes returns the narrowest type that can hold values of both types
+func mergeColumnTypes(a, b ColumnType) ColumnType {
+	switch {
+	case a == ColumnUnknown:
+		return b
+	case b == ColumnUnknown || a == b:
+		return a
+	case (a == ColumnInt && b == ColumnFloat) || (a == ColumnFloat && b == ColumnInt):
+		return ColumnFloat
+	default:
+		return ColumnString
+	}
+}
+
+// parseColumnValue converts a field to the Go type used for the column type
+// Numbers load as int or float64 so they pass IsSupportedNumericType and ConvertToFloat64
+func parseColumnValue(field string, columnType ColumnType) (interface{}, error) {
+	switch columnType {
+	case ColumnInt:
+		return strconv.Atoi(field)
+	case ColumnFloat:
+		return strconv.ParseFloat(field, 64)
+	case ColumnBool:
+		if val, ok := parseBoolField(field); ok {
+			return val, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q: %w", field, ErrInvalidInput)
+	default:
+		return field, nil
+	}
+}
+
+// parseBoolField accepts true and false in any letter case
+// Unlike strconv.ParseBool it rejects 0 and 1, which are loaded as numbers
+func parseBoolField(field string) (bool, bool) {
+	switch strings.ToLower(field) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	default:
+		return false, false
+	}
+}
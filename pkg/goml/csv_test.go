@@ -0,0 +1,168 @@
+package goml
+
+import (
+	"strings"
+	"testing"
+)
+
+const testCSV = `id,size,rooms,location,garden,price,sold
+h1,1000,2,suburban,true,200000.5,yes
+h2,1500,3,suburban,false,300000,no
+h3,800.5,,urban,TRUE,220000,yes
+h4,2000,4,"rural, north",false,350000,no
+`
+
+// TestCSVLoaderInfersTypesAndRoles tests type inference, column roles and missing values
+func TestCSVLoaderInfersTypesAndRoles(t *testing.T) {
+	loader := NewCSVLoader().
+		WithRole(RoleID, "id").
+		WithRole(RoleOutput, "price").
+		WithRole(RoleIgnore, "sold")
+
+	data, err := loader.Load(strings.NewReader(testCSV))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	expectedTypes := map[string]ColumnType{
+		"id":       ColumnString,
+		"size":     ColumnFloat,
+		"rooms":    ColumnInt,
+		"location": ColumnString,
+		"garden":   ColumnBool,
+		"price":    ColumnFloat,
+	}
+	for column, expected := range expectedTypes {
+		if data.Types[column] != expected {
+			t.Errorf("Column %s: expected type %v, got %v", column, expected, data.Types[column])
+		}
+	}
+	if _, ok := data.Types["sold"]; ok {
+		t.Error("Ignored column should not be typed")
+	}
+
+	if len(data.Inputs) != 4 || len(data.Outputs) != 4 || len(data.IDs) != 4 {
+		t.Fatalf("Expected 4 rows, got %d inputs, %d outputs, %d ids", len(data.Inputs), len(data.Outputs), len(data.IDs))
+	}
+
+	first := data.Inputs[0]
+	if first["size"] != 1000.0 || first["rooms"] != 2 || first["location"] != "suburban" || first["garden"] != true {
+		t.Errorf("Unexpected first input: %v", first)
+	}
+	if _, ok := first["sold"]; ok {
+		t.Error("Ignored column should not be loaded")
+	}
+	if data.Outputs[0]["price"] != 200000.5 || data.IDs[0]["id"] != "h1" {
+		t.Errorf("Unexpected first output or id: %v %v", data.Outputs[0], data.IDs[0])
+	}
+
+	// Empty fields are missing values
+	if _, ok := data.Inputs[2]["rooms"]; ok {
+		t.Errorf("Expected missing rooms in row 3, got %v", data.Inputs[2])
+	}
+	if data.Inputs[3]["location"] != "rural, north" {
+		t.Errorf("Expected quoted field to keep its delimiter, got %v", data.Inputs[3]["location"])
+	}
+
+	// Loaded values are compatible with the numeric helpers
+	if !IsSupportedNumericType(first["rooms"]) || !IsSupportedNumericType(first["size"]) {
+		t.Error("Expected numeric columns to load as supported numeric types")
+	}
+}
+
+// TestCSVLoaderNonFinite tests that NaN and infinities load as missing numbers but stay in text
+func TestCSVLoaderNonFinite(t *testing.T) {
+	data, err := NewCSVLoader().WithRole(RoleOutput, "y").Load(strings.NewReader("x,name,y\n1.5,Nan,1\nNaN,Ann,2\n-Infinity,Bob,inf\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	expectedTypes := map[string]ColumnType{"x": ColumnFloat, "name": ColumnString, "y": ColumnInt}
+	for column, expected := range expectedTypes {
+		if data.Types[column] != expected {
+			t.Errorf("Column %s: expected type %v, got %v", column, expected, data.Types[column])
+		}
+	}
+	if _, ok := data.Inputs[1]["x"]; ok {
+		t.Errorf("Expected NaN to be missing, got %v", data.Inputs[1])
+	}
+	if _, ok := data.Inputs[2]["x"]; ok {
+		t.Errorf("Expected -Infinity to be missing, got %v", data.Inputs[2])
+	}
+	if _, ok := data.Outputs[2]["y"]; ok {
+		t.Errorf("Expected inf to be a missing target, got %v", data.Outputs[2])
+	}
+	if data.Inputs[0]["name"] != "Nan" {
+		t.Errorf("Expected text columns to keep NaN-like values, got %v", data.Inputs[0])
+	}
+}
+
+// TestCSVLoaderOptions tests custom delimiters, headerless files and type overrides
+func TestCSVLoaderOptions(t *testing.T) {
+	loader := NewCSVLoader()
+	loader.Delimiter = ';'
+	loader.Header = false
+	loader.Columns = []string{"x", "code", "y"}
+	loader.Types = map[string]ColumnType{"code": ColumnString}
+	loader.WithRole(RoleOutput, "y")
+
+	data, err := loader.Load(strings.NewReader("1;007;2\n2;008;4\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if data.Inputs[0]["code"] != "007" {
+		t.Errorf("Expected type override to keep code as string, got %v", data.Inputs[0]["code"])
+	}
+	if data.Inputs[1]["x"] != 2 || data.Outputs[1]["y"] != 4 {
+		t.Errorf("Unexpected second row: %v %v", data.Inputs[1], data.Outputs[1])
+	}
+
+	if _, err := (&CSVLoader{Header: false}).Load(strings.NewReader("1,2\n")); err == nil {
+		t.Error("Expected error when a headerless file has no column names")
+	}
+}
+
+// TestCSVLoaderTrainAuto tests that loaded rows can be fed straight into TrainAuto
+func TestCSVLoaderTrainAuto(t *testing.T) {
+	csvData := "x1,x2,y\n1,2,5\n2,3,8\n3,4,11\n4,5,14\n"
+
+	data, err := NewCSVLoader().WithRole(RoleOutput, "y").Load(strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	engine, err := TrainAuto(data.Inputs, data.Outputs)
+	if err != nil {
+		t.Fatalf("TrainAuto failed: %v", err)
+	}
+	if _, err := engine.Predict(map[string]interface{}{"x1": 5, "x2": 6}); err != nil {
+		t.Errorf("Prediction failed: %v", err)
+	}
+}
+
+// TestCSVDatasetStreams tests streaming a CSV source through TrainStream
+func TestCSVDatasetStreams(t *testing.T) {
+	csvData := "x,label\n1,small\n2,small\n8,large\n9,large\n"
+
+	ds, err := NewCSVLoader().WithRole(RoleOutput, "label").NewCSVDataset(strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("Creating dataset failed: %v", err)
+	}
+	if ds.Types()["x"] != ColumnInt || ds.Types()["label"] != ColumnString {
+		t.Errorf("Unexpected inferred types: %v", ds.Types())
+	}
+
+	engine := New()
+	engine.WithModel(NewCategoricalModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 10, BatchSize: 2})
+
+	if err := engine.TrainStream(ds); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+
+	model, _, _ := engine.snapshot()
+	if len(model.Categories["label"]) != 2 {
+		t.Errorf("Expected 2 categories, got %v", model.Categories["label"])
+	}
+}
@@ -0,0 +1,525 @@
+package goml
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Dataset is a source of training rows that can be read more than once
+// Implementations can stream rows from files or other sources larger than memory
+type Dataset interface {
+	// Next returns the next input/output pair, or io.EOF when the dataset is exhausted
+	Next() (map[string]interface{}, map[string]interface{}, error)
+
+	// Reset rewinds the dataset to its first row
+	Reset() error
+}
+
+// SliceDataset is a Dataset backed by in-memory input and output slices
+type SliceDataset struct {
+	inputs  []map[string]interface{}
+	outputs []map[string]interface{}
+	pos     int
+}
+
+// NewSliceDataset creates a dataset over paired inputs and outputs
+func NewSliceDataset(inputs []map[string]interface{}, outputs []map[string]interface{}) *SliceDataset {
+	return &SliceDataset{
+		inputs:  inputs,
+		outputs: outputs,
+	}
+}
+
+// Next returns the next input/output pair, or io.EOF at the end of the slices
+func (d *SliceDataset) Next() (map[string]interface{}, map[string]interface{}, error) {
+	if d.pos >= len(d.inputs) || d.pos >= len(d.outputs) {
+		return nil, nil, io.EOF
+	}
+	input, output := d.inputs[d.pos], d.outputs[d.pos]
+	d.pos++
+	return input, output, nil
+}
+
+// Reset rewinds the dataset to its first row
+func (d *SliceDataset) Reset() error {
+	d.pos = 0
+	return nil
+}
+
+// TrainStream trains the model on a dataset that is read again for every epoch
+// Only one mini-batch of Config.BatchSize rows is held in memory at a time, so it can
+// train on exports larger than memory. The exceptions are models with a Pipeline and PCA
+// models: pipeline steps and principal components are fitted on complete row sets, so the
+// dataset is read into memory once for that pass. Like Train, it runs on copies of the model and
+// weights and publishes them when training completes, unless the model or weights were
+// replaced meanwhile, in which case it returns ErrConcurrentUpdate
+func (e *Engine) TrainStream(ds Dataset) error {
+	e.trainMu.Lock()
+	defer e.trainMu.Unlock()
+
+	model, weights, config := e.snapshot()
+	if model == nil {
+		return fmt.Errorf("model not initialized")
+	}
+
+	if ds == nil {
+		return fmt.Errorf("no training data provided")
+	}
+
+	// Train on copies so published models and weights are never mutated
+	trainModel, err := model.clone()
+	if err != nil {
+		return err
+	}
+	trainWeights := weights.clone()
+
+	if err := trainModel.TrainStream(ds, trainWeights, config); err != nil {
+		return err
+	}
+
+	return e.publish(model, weights, trainModel, trainWeights)
+}
+
+// TrainStream trains the model from a dataset in mini-batches
+// A first pass over the dataset computes the normalization statistics, the feature
+// preprocessing and the output categories, then every epoch re-reads the dataset and
+// takes one step per mini-batch. Fitting a Pipeline or a PCA model holds the whole dataset
+// in memory
+func (m *Model) TrainStream(ds Dataset, weights *Weights, config *Config) error {
+	rows, err := m.scanDataset(ds, config.strictSchema())
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("no training data provided")
+	}
+
+	// Calibrators describe the previous weights
+	m.Calibration = nil
+
+	// Principal components are fitted on the complete dataset, which is read into memory
+	if m.Type == "pca" {
+		if err := ds.Reset(); err != nil {
+			return fmt.Errorf("failed to reset dataset: %w", err)
+		}
+		inputs, outputs, err := readBatch(ds, rows)
+		if err != nil {
+			return err
+		}
+		return m.train(m.preprocessRows(inputs, 0), outputs, weights, config)
+	}
+
+	batchSize := config.BatchSize
+	if batchSize < 1 {
+		batchSize = DefaultConfig().BatchSize
+	}
+
+	// Categorical targets shuffle every batch with their own seeded source, which carries on
+	// from batch to batch
+	categoricalTargets := make([]string, 0, len(m.Categories))
+	for target := range m.Categories {
+		categoricalTargets = append(categoricalTargets, target)
+	}
+	sort.Strings(categoricalTargets)
+	rngs := make(map[string]*rand.Rand, len(categoricalTargets))
+	for t, target := range categoricalTargets {
+		rngs[target] = newSeededRand(config.Seed, t)
+	}
+
+	// Each mini-batch takes a single gradient step and the epochs are driven by re-reading
+	// the dataset. Like Train, numeric and boolean targets stop once the mean loss of an
+	// epoch changes by less than Config.Tolerance; categorical targets run every epoch
+	converged := make(map[string]bool)
+	prevLoss := make(map[string]float64)
+	for epoch := 0; epoch < config.Epochs; epoch++ {
+		if err := ds.Reset(); err != nil {
+			return fmt.Errorf("failed to reset dataset: %w", err)
+		}
+
+		// Position of the batch in the dataset, for out-of-fold target encoding
+		offset := 0
+		losses := make(map[string]float64)
+		for {
+			inputs, outputs, err := readBatch(ds, batchSize)
+			if err != nil {
+				return err
+			}
+			if len(inputs) == 0 {
+				break
+			}
+
+			for kind, loss := range m.trainBatch(m.preprocessRows(inputs, offset), outputs, weights, config, converged, rngs) {
+				losses[kind] += loss
+			}
+			offset += len(inputs)
+		}
+
+		for kind, loss := range losses {
+			loss /= float64(rows)
+			if kind != "categorical" && epoch > 0 && math.Abs(prevLoss[kind]-loss) < config.Tolerance {
+				converged[kind] = true
+			}
+			prevLoss[kind] = loss
+		}
+		if len(losses) == 0 || allConverged(losses, converged) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// streamTargetKind returns how a target of a streamed model is trained: "numeric",
+// "boolean" or "categorical", or "" for targets a mixed model did not see in the first pass
+func (m *Model) streamTargetKind(target string) string {
+	switch m.Type {
+	case "linear":
+		return "numeric"
+	case "logistic":
+		return "boolean"
+	case "categorical":
+		return "categorical"
+	}
+	kind, _ := m.Targets[target].(string)
+	return kind
+}
+
+// trainBatch takes one gradient step on a mini-batch for the targets whose type has not
+// converged, against the target types and categories found by scanDataset
+// Categorical targets shuffle the batch with their source in rngs, nil when shuffling is off
+// It returns the loss of every type before the step, summed over the rows of the batch
+func (m *Model) trainBatch(inputs []map[string]interface{}, outputs []map[string]interface{}, weights *Weights, config *Config, converged map[string]bool, rngs map[string]*rand.Rand) map[string]float64 {
+	// Split the outputs by target type
+	byKind := make(map[string][]map[string]interface{})
+	for i, output := range outputs {
+		for target, val := range output {
+			kind := m.streamTargetKind(target)
+			if kind == "" || converged[kind] {
+				continue
+			}
+			if byKind[kind] == nil {
+				byKind[kind] = make([]map[string]interface{}, len(outputs))
+				for j := range byKind[kind] {
+					byKind[kind][j] = make(map[string]interface{})
+				}
+			}
+			byKind[kind][i][target] = val
+		}
+	}
+
+	features := sortedRowKeys(inputs)
+	workers := config.workers()
+	losses := make(map[string]float64)
+
+	for _, kind := range []string{"numeric", "categorical", "boolean"} {
+		kindOutputs, exists := byKind[kind]
+		if !exists {
+			continue
+		}
+		targets := sortedRowKeys(kindOutputs)
+		targetWorkers := splitWorkers(workers, len(targets))
+
+		switch kind {
+		case "numeric":
+			initWeights(weights, features, targets)
+			featureMeans := m.featureMeans(features)
+			losses[kind] = calculateMSE(inputs, kindOutputs, weights, features, targets) * float64(len(inputs))
+			parallelFor(len(targets), workers, func(t int) {
+				updateLinearTarget(inputs, kindOutputs, targets[t], features, featureMeans, weights, config, targetWorkers)
+			})
+		case "boolean":
+			initWeights(weights, features, targets)
+			losses[kind] = calculateLogLoss(inputs, kindOutputs, weights, features, targets) * float64(len(inputs))
+			parallelFor(len(targets), workers, func(t int) {
+				updateLogisticTarget(inputs, kindOutputs, targets[t], features, weights, config, targetWorkers)
+			})
+		case "categorical":
+			stepConfig := *config
+			stepConfig.Epochs = 1
+			losses[kind] = 0
+			parallelFor(len(targets), workers, func(t int) {
+				trainCategoricalTarget(inputs, kindOutputs, targets[t], features, m.Categories[targets[t]], weights, &stepConfig, rngs[targets[t]])
+			})
+		}
+	}
+	return losses
+}
+
+// initWeights adds zero weights for the features and bias of targets that have none yet
+func initWeights(weights *Weights, features []string, targets []string) {
+	for _, target := range targets {
+		for _, feature := range features {
+			weightKey := fmt.Sprintf("%s->%s", feature, target)
+			if _, exists := weights.Get(weightKey); !exists {
+				weights.Set(weightKey, 0.0)
+			}
+		}
+		biasKey := fmt.Sprintf("bias->%s", target)
+		if _, exists := weights.Get(biasKey); !exists {
+			weights.Set(biasKey, 0.0)
+		}
+	}
+}
+
+// allConverged reports whether every target type trained in an epoch has converged
+func allConverged(losses map[string]float64, converged map[string]bool) bool {
+	for kind := range losses {
+		if !converged[kind] {
+			return false
+		}
+	}
+	return true
+}
+
+// scanDataset makes the first streaming pass over a dataset
+// It recomputes the normalization statistics and the schema, and registers the output
+// categories so that every mini-batch trains against the complete category set. It returns the row count
+func (m *Model) scanDataset(ds Dataset, strict bool) (int, error) {
+	if err := ds.Reset(); err != nil {
+		return 0, fmt.Errorf("failed to reset dataset: %w", err)
+	}
+
+	m.Stats = nil
+	categoryCounts := make(map[string]map[string]int)
+	rows := 0
+
+	// Mixed models fix the type of every target from all rows, so that every mini-batch
+	// trains a target the same way
+	kinds := make(targetKinds)
+	for key, kind := range m.Targets {
+		if k, ok := kind.(string); ok {
+			kinds[key] = k
+		}
+	}
+
+	// Declared schemas check every row; otherwise one is inferred in the same pass
+	declared := m.Schema != nil && !m.Schema.Inferred
+	builder := newSchemaBuilder()
+
+	fitter, err := m.newPreprocessFitter()
+	if err != nil {
+		return 0, err
+	}
+	preprocessing := m.hasPreprocessing()
+
+	for {
+		input, output, err := ds.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return rows, fmt.Errorf("failed to read row %d: %w", rows, err)
+		}
+
+		if declared {
+			if err := m.Schema.ValidateInput(input, strict); err != nil {
+				return rows, fmt.Errorf("row %d: %w", rows, err)
+			}
+			if m.Schema.Outputs != nil {
+				if err := m.Schema.ValidateOutput(output, strict); err != nil {
+					return rows, fmt.Errorf("row %d: %w", rows, err)
+				}
+			}
+		} else {
+			builder.observe(input, output)
+		}
+
+		if preprocessing {
+			fitter.observe(input, output)
+		} else {
+			// Dates are expanded without learned state, so their features count right away
+			m.updateStats([]map[string]interface{}{m.preprocess(input)})
+		}
+
+		if m.Type == "mixed" {
+			kinds.observe(output)
+		}
+		for target, val := range output {
+			// Categorical models treat every output as a category; mixed models only the
+			// values of categorical targets, which are known after the pass
+			if m.Type != "categorical" && m.Type != "mixed" {
+				continue
+			}
+			if categoryCounts[target] == nil {
+				categoryCounts[target] = make(map[string]int)
+			}
+			categoryCounts[target][fmt.Sprintf("%v", val)]++
+		}
+		rows++
+	}
+
+	if !declared {
+		m.Schema = builder.build()
+		m.relaxSchema()
+	}
+
+	// Statistics describe the preprocessed features, which needs a second pass once the
+	// preprocessing has been learned
+	if preprocessing {
+		if err := fitter.finish(); err != nil {
+			return rows, err
+		}
+		if err := m.fitPipelineStream(ds); err != nil {
+			return rows, err
+		}
+		if err := m.scanPreprocessedStats(ds); err != nil {
+			return rows, err
+		}
+	}
+
+	if m.Type == "mixed" {
+		if m.Targets == nil {
+			m.Targets = make(map[string]interface{})
+		}
+		for target, kind := range kinds {
+			m.Targets[target] = kind
+			if kind != "categorical" {
+				delete(categoryCounts, target)
+			}
+		}
+	}
+
+	if len(categoryCounts) > 0 && m.Categories == nil {
+		m.Categories = make(map[string]map[string]int)
+	}
+	for target, counts := range categoryCounts {
+		if m.Categories[target] == nil {
+			m.Categories[target] = make(map[string]int)
+		}
+
+		// Assign indices to new categories in lexical order, after any existing ones
+		idx := len(m.Categories[target])
+		for _, category := range sortedCategories(counts) {
+			if _, exists := m.Categories[target][category]; !exists {
+				m.Categories[target][category] = idx
+				idx++
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+// fitPipelineStream fits the model's pipeline on a dataset
+// Pipeline steps fit on complete row sets, so the dataset is read into memory for this pass
+func (m *Model) fitPipelineStream(ds Dataset) error {
+	if m.Pipeline == nil || len(m.Pipeline.Steps) == 0 {
+		return nil
+	}
+	if err := ds.Reset(); err != nil {
+		return fmt.Errorf("failed to reset dataset: %w", err)
+	}
+
+	var inputs, outputs []map[string]interface{}
+	for {
+		input, output, err := ds.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read row %d: %w", len(inputs), err)
+		}
+		inputs = append(inputs, m.applyFeatureSpecs(input, len(inputs)))
+		outputs = append(outputs, output)
+	}
+	return m.Pipeline.fit(inputs, outputs)
+}
+
+// scanPreprocessedStats recomputes the normalization statistics from preprocessed rows
+func (m *Model) scanPreprocessedStats(ds Dataset) error {
+	if err := ds.Reset(); err != nil {
+		return fmt.Errorf("failed to reset dataset: %w", err)
+	}
+
+	for rows := 0; ; rows++ {
+		input, _, err := ds.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read row %d: %w", rows, err)
+		}
+		m.updateStats([]map[string]interface{}{m.preprocessAt(input, rows)})
+	}
+}
+
+// readBatch reads up to size rows from a dataset; an empty batch means the dataset is exhausted
+func readBatch(ds Dataset, size int) ([]map[string]interface{}, []map[string]interface{}, error) {
+	inputs := make([]map[string]interface{}, 0, size)
+	outputs := make([]map[string]interface{}, 0, size)
+
+	for len(inputs) < size {
+		input, output, err := ds.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		inputs = append(inputs, input)
+		outputs = append(outputs, output)
+	}
+
+	return inputs, outputs, nil
+}
+
+// lineSource reads newline separated records and rewinds seekable sources
+type lineSource struct {
+	source io.Reader
+	reader *bufio.Reader
+	line   int
+}
+
+// newLineSource creates a line reader over a source
+func newLineSource(source io.Reader) *lineSource {
+	return &lineSource{
+		source: source,
+		reader: bufio.NewReader(source),
+	}
+}
+
+// next returns the next non-blank line without its line ending, or io.EOF
+func (s *lineSource) next() ([]byte, error) {
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return nil, err
+		}
+		s.line++
+
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// reset rewinds the source, which must implement io.Seeker once lines have been read
+func (s *lineSource) reset() error {
+	if s.line == 0 {
+		// Nothing consumed yet
+		return nil
+	}
+
+	seeker, ok := s.source.(io.Seeker)
+	if !ok {
+		return fmt.Errorf("source cannot be rewound: %w", ErrInvalidInput)
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind source: %w", err)
+	}
+
+	s.reader.Reset(s.source)
+	s.line = 0
+	return nil
+}
@@ -0,0 +1,174 @@
+package goml
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// countingDataset wraps a dataset and records how often it is rewound
+type countingDataset struct {
+	Dataset
+	resets int
+}
+
+func (d *countingDataset) Reset() error {
+	d.resets++
+	return d.Dataset.Reset()
+}
+
+// failingDataset returns an error after a number of rows
+type failingDataset struct {
+	rows int
+	pos  int
+}
+
+func (d *failingDataset) Next() (map[string]interface{}, map[string]interface{}, error) {
+	if d.pos >= d.rows {
+		return nil, nil, errors.New("disk read failed")
+	}
+	d.pos++
+	return map[string]interface{}{"x": float64(d.pos)}, map[string]interface{}{"y": float64(d.pos)}, nil
+}
+
+func (d *failingDataset) Reset() error {
+	d.pos = 0
+	return nil
+}
+
+// TestTrainStreamLinear tests streaming training of a linear model
+func TestTrainStreamLinear(t *testing.T) {
+	inputs := []map[string]interface{}{
+		{"x1": 1.0, "x2": 2.0}, {"x1": 2.0, "x2": 3.0}, {"x1": 3.0, "x2": 4.0}, {"x1": 4.0, "x2": 5.0},
+	}
+	outputs := []map[string]interface{}{
+		{"y": 5.0}, {"y": 8.0}, {"y": 11.0}, {"y": 14.0},
+	}
+
+	ds := &countingDataset{Dataset: NewSliceDataset(inputs, outputs)}
+
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 300, BatchSize: 2})
+
+	if err := engine.TrainStream(ds); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+
+	// One statistics pass plus one pass per epoch
+	if ds.resets != 301 {
+		t.Errorf("Expected 301 passes over the dataset, got %d", ds.resets)
+	}
+
+	prediction, err := engine.Predict(map[string]interface{}{"x1": 5.0, "x2": 6.0})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if y := prediction["y"].(float64); y < 15 || y > 19 {
+		t.Errorf("Prediction outside expected range: %v", y)
+	}
+
+	model, _, _ := engine.snapshot()
+	if stats := model.Stats["x1"]; stats == nil || stats.Count != 4 {
+		t.Errorf("Expected statistics from the first pass, got %+v", stats)
+	}
+}
+
+// TestTrainStreamMixedTargetTypes tests that target types come from the whole dataset, not from each batch
+func TestTrainStreamMixedTargetTypes(t *testing.T) {
+	var inputs, outputs []map[string]interface{}
+	for i := 0; i < 16; i++ {
+		x := float64(i%8 + 1)
+		// Every batch of four starts with a score of 0 or 1
+		score := x + 2
+		if i%4 == 0 {
+			score = float64(i / 4 % 2)
+		}
+		inputs = append(inputs, map[string]interface{}{"x": x})
+		outputs = append(outputs, map[string]interface{}{"score": score, "flag": x > 4})
+	}
+
+	engine := New()
+	engine.WithModel(NewMixedModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 20, BatchSize: 4})
+	if err := engine.TrainStream(NewSliceDataset(inputs, outputs)); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+
+	model, weights, _ := engine.snapshot()
+	if model.Targets["score"] != "numeric" || model.Targets["flag"] != "boolean" {
+		t.Errorf("Expected a numeric score and a boolean flag, got %v", model.Targets)
+	}
+	prediction, err := engine.Predict(map[string]interface{}{"x": 8.0})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if _, ok := prediction["score"].(float64); !ok {
+		t.Errorf("Expected a numeric score prediction, got %v", prediction)
+	}
+	if _, ok := prediction["flag"].(bool); !ok {
+		t.Errorf("Expected a boolean flag prediction, got %v", prediction)
+	}
+	if _, exists := weights.Get("x->flag"); !exists {
+		t.Errorf("Expected weights for the boolean target")
+	}
+
+	// A loose tolerance stops training after a few epochs, not after a few batches
+	ds := &countingDataset{Dataset: NewSliceDataset(inputs, outputs)}
+	stopping := New()
+	stopping.WithModel(NewMixedModel().JSON())
+	stopping.WithConfig(&Config{LearningRate: 0.01, Epochs: 20, BatchSize: 4, Tolerance: 1e6})
+	if err := stopping.TrainStream(ds); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+	if ds.resets != 3 {
+		t.Errorf("Expected the statistics pass and two epochs, got %d passes", ds.resets)
+	}
+}
+
+// TestTrainStreamRegistersAllCategories tests that categories seen only in later batches are learned
+func TestTrainStreamRegistersAllCategories(t *testing.T) {
+	inputs := []map[string]interface{}{
+		{"x": 1.0}, {"x": 1.5}, {"x": 5.0}, {"x": 5.5}, {"x": 9.0}, {"x": 9.5},
+	}
+	outputs := []map[string]interface{}{
+		{"size": "small"}, {"size": "small"}, {"size": "medium"}, {"size": "medium"}, {"size": "large"}, {"size": "large"},
+	}
+
+	engine := New()
+	engine.WithModel(NewCategoricalModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.05, Epochs: 20, BatchSize: 2})
+
+	if err := engine.TrainStream(NewSliceDataset(inputs, outputs)); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+
+	model, weights, _ := engine.snapshot()
+	if len(model.Categories["size"]) != 3 {
+		t.Fatalf("Expected 3 categories, got %v", model.Categories["size"])
+	}
+	for _, category := range []string{"small", "medium", "large"} {
+		if _, exists := weights.Get("x->size:" + category); !exists {
+			t.Errorf("Missing weights for category %s", category)
+		}
+	}
+}
+
+// TestTrainStreamErrors tests error handling for empty and failing datasets
+func TestTrainStreamErrors(t *testing.T) {
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+
+	if err := engine.TrainStream(NewSliceDataset(nil, nil)); err == nil {
+		t.Error("Expected error for an empty dataset")
+	}
+
+	err := engine.TrainStream(&failingDataset{rows: 3})
+	if err == nil || errors.Is(err, io.EOF) {
+		t.Errorf("Expected the dataset read error, got %v", err)
+	}
+
+	if err := New().TrainStream(NewSliceDataset(nil, nil)); err == nil {
+		t.Error("Expected error for a missing model")
+	}
+}
@@ -0,0 +1,295 @@
+package goml
+
+import (
+	"fmt"
+)
+
+// EncodeMethod selects how a categorical feature is turned into a number
+type EncodeMethod string
+
+const (
+	EncodeNone      EncodeMethod = ""          // Keep the category; only bucket rare categories
+	EncodeTarget    EncodeMethod = "target"    // Smoothed mean of the target for the category
+	EncodeFrequency EncodeMethod = "frequency" // Share of the training rows with the category
+	EncodeCount     EncodeMethod = "count"     // Number of training rows with the category
+)
+
+// RareCategory replaces categories seen in fewer than Encoder.MinCount training rows
+const RareCategory = "__other__"
+
+// Encoder encodes a high-cardinality categorical feature, such as a merchant ID, as a single
+// number instead of one feature per value
+// Target encoding replaces a category with the mean target of its training rows, shrunk towards
+// the overall mean by Smoothing. To keep the model from learning its own targets, each training
+// row is encoded out-of-fold, with the statistics of the other folds; Predict uses all rows.
+// Categories not seen during training get the overall mean, or 0 for frequency and count encoding.
+// Non-string values are encoded by their string form
+type Encoder struct {
+	Method    EncodeMethod `json:"method,omitempty"`
+	Target    string       `json:"target,omitempty"`    // Target to encode; may be omitted when there is only one
+	Positive  string       `json:"positive,omitempty"`  // For string targets, the category whose rate is encoded
+	Smoothing float64      `json:"smoothing,omitempty"` // Weight of the overall mean, in rows; 0 uses the plain category mean
+	Folds     int          `json:"folds,omitempty"`     // Folds for out-of-fold target encoding of training rows (default 5; 1 disables)
+	MinCount  int          `json:"min_count,omitempty"` // Categories in fewer training rows are bucketed into RareCategory
+
+	// Learned by Train
+	Values  map[string]float64 `json:"values,omitempty"`  // Category -> encoded value (training row count when only bucketing)
+	Default float64            `json:"default,omitempty"` // Value of categories not seen during training
+
+	folds []map[string]float64 // Out-of-fold values per fold, for the training rows only
+}
+
+// WithEncoder configures how one or more categorical features are encoded
+// The mappings are learned when the model is trained and saved with the model
+func (m *Model) WithEncoder(encoder Encoder, features ...string) *Model {
+	for _, feature := range features {
+		spec := m.featureSpec(feature)
+		encoderCopy := encoder
+		spec.Encode = &encoderCopy
+	}
+	return m
+}
+
+// validate checks the encoder configuration
+func (e *Encoder) validate(feature string) error {
+	switch e.Method {
+	case EncodeNone, EncodeTarget, EncodeFrequency, EncodeCount:
+	default:
+		return fmt.Errorf("feature %q: unknown encoding %q: %w", feature, e.Method, ErrInvalidInput)
+	}
+	if e.Smoothing < 0 || e.Folds < 0 || e.MinCount < 0 {
+		return fmt.Errorf("feature %q: smoothing, folds and min count must not be negative: %w", feature, ErrInvalidInput)
+	}
+	return nil
+}
+
+// foldCount returns the number of folds used for out-of-fold target encoding
+func (e *Encoder) foldCount() int {
+	if e.Method != EncodeTarget {
+		return 1
+	}
+	if e.Folds == 0 {
+		return 5
+	}
+	return e.Folds
+}
+
+// category returns the category of a value after rare bucketing, or false if it is missing
+func (e *Encoder) category(val interface{}) (string, bool) {
+	if isMissing(val) {
+		return "", false
+	}
+	category, ok := val.(string)
+	if !ok {
+		category = fmt.Sprintf("%v", val)
+	}
+	if e.MinCount > 0 && e.Values != nil {
+		if _, known := e.Values[category]; !known {
+			category = RareCategory
+		}
+	}
+	return category, true
+}
+
+// apply replaces the feature's value with its encoding
+// index is the position of a training row, used for out-of-fold encoding, or -1 at prediction time
+func (e *Encoder) apply(feature string, row map[string]interface{}, index int) {
+	val, exists := row[feature]
+	if !exists {
+		return
+	}
+	category, ok := e.category(val)
+	if !ok {
+		delete(row, feature)
+		return
+	}
+
+	if e.Method == EncodeNone {
+		row[feature] = category
+		return
+	}
+
+	values := e.Values
+	if index >= 0 && len(e.folds) > 1 {
+		values = e.folds[index%len(e.folds)]
+	}
+	encoded, known := values[category]
+	if !known {
+		encoded = e.Default
+	}
+	row[feature] = encoded
+}
+
+// encodeObservation collects the category counts and target sums an encoder learns from
+type encodeObservation struct {
+	rows       int
+	counts     map[string]int
+	sums       map[string]float64
+	targetRows map[string]int
+	foldSums   map[string][]float64
+	foldRows   map[string][]int
+	ambiguous  bool
+}
+
+// observe records the category of one training row and its target
+func (o *encodeObservation) observe(e *Encoder, val interface{}, output map[string]interface{}) {
+	fold := o.rows % e.foldCount()
+	o.rows++
+
+	if isMissing(val) {
+		return
+	}
+	category, ok := val.(string)
+	if !ok {
+		category = fmt.Sprintf("%v", val)
+	}
+
+	if o.counts == nil {
+		o.counts = make(map[string]int)
+		o.sums = make(map[string]float64)
+		o.targetRows = make(map[string]int)
+		o.foldSums = make(map[string][]float64)
+		o.foldRows = make(map[string][]int)
+	}
+	o.counts[category]++
+
+	if e.Method != EncodeTarget {
+		return
+	}
+
+	// The target can be left out when the rows have a single one
+	if e.Target == "" {
+		if len(output) != 1 {
+			o.ambiguous = true
+			return
+		}
+		for target := range output {
+			e.Target = target
+		}
+	}
+
+	y, ok := targetRate(output[e.Target], e.Positive)
+	if !ok {
+		return
+	}
+	if o.foldSums[category] == nil {
+		o.foldSums[category] = make([]float64, e.foldCount())
+		o.foldRows[category] = make([]int, e.foldCount())
+	}
+	o.sums[category] += y
+	o.targetRows[category]++
+	o.foldSums[category][fold] += y
+	o.foldRows[category][fold]++
+}
+
+// targetRate converts a target value to the number whose mean is encoded
+func targetRate(val interface{}, positive string) (float64, bool) {
+	if category, ok := val.(string); ok {
+		if positive == "" {
+			return 0, false
+		}
+		if category == positive {
+			return 1, true
+		}
+		return 0, true
+	}
+	return numericValue(val)
+}
+
+// finish stores the learned mappings in the encoder
+func (o *encodeObservation) finish(e *Encoder, feature string) error {
+	if o.ambiguous {
+		return fmt.Errorf("feature %q: target encoding needs a Target when rows have several: %w", feature, ErrInvalidInput)
+	}
+
+	// Rare categories are merged before anything is computed from them
+	counts := make(map[string]int)
+	sums := make(map[string]float64)
+	targetRows := make(map[string]int)
+	foldSums := make(map[string][]float64)
+	foldRows := make(map[string][]int)
+	for _, category := range sortedCategories(o.counts) {
+		bucket := category
+		if o.counts[category] < e.MinCount {
+			bucket = RareCategory
+		}
+		counts[bucket] += o.counts[category]
+		sums[bucket] += o.sums[category]
+		targetRows[bucket] += o.targetRows[category]
+		if o.foldSums[category] != nil {
+			if foldSums[bucket] == nil {
+				foldSums[bucket] = make([]float64, e.foldCount())
+				foldRows[bucket] = make([]int, e.foldCount())
+			}
+			for k := range o.foldSums[category] {
+				foldSums[bucket][k] += o.foldSums[category][k]
+				foldRows[bucket][k] += o.foldRows[category][k]
+			}
+		}
+	}
+
+	e.Values = make(map[string]float64, len(counts))
+	e.Default = 0
+	e.folds = nil
+
+	switch e.Method {
+	case EncodeNone, EncodeCount:
+		for category, count := range counts {
+			e.Values[category] = float64(count)
+		}
+	case EncodeFrequency:
+		for category, count := range counts {
+			e.Values[category] = float64(count) / float64(o.rows)
+		}
+	case EncodeTarget:
+		total, totalRows := 0.0, 0
+		for category := range sums {
+			total += sums[category]
+			totalRows += targetRows[category]
+		}
+		e.Default = safeMean(total, totalRows)
+		for category := range counts {
+			e.Values[category] = e.smoothedMean(sums[category], targetRows[category], e.Default)
+		}
+
+		// Each fold is encoded with the statistics of the other folds
+		if e.foldCount() > 1 {
+			e.folds = make([]map[string]float64, e.foldCount())
+			for k := range e.folds {
+				foldTotal, foldTotalRows := total, totalRows
+				for category := range foldSums {
+					foldTotal -= foldSums[category][k]
+					foldTotalRows -= foldRows[category][k]
+				}
+				mean := safeMean(foldTotal, foldTotalRows)
+
+				e.folds[k] = make(map[string]float64, len(counts))
+				for category := range counts {
+					sum, rows := sums[category], targetRows[category]
+					if foldSums[category] != nil {
+						sum -= foldSums[category][k]
+						rows -= foldRows[category][k]
+					}
+					e.folds[k][category] = e.smoothedMean(sum, rows, mean)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// smoothedMean shrinks the mean of a category towards the overall mean
+func (e *Encoder) smoothedMean(sum float64, rows int, mean float64) float64 {
+	if float64(rows)+e.Smoothing == 0 {
+		return mean
+	}
+	return (sum + e.Smoothing*mean) / (float64(rows) + e.Smoothing)
+}
+
+// safeMean returns sum/count, or 0 if there are no rows
+func safeMean(sum float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
@@ -0,0 +1,151 @@
+package goml
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+// encodeRows returns transactions of three merchants and whether they were fraudulent
+func encodeRows() ([]map[string]interface{}, []map[string]interface{}) {
+	merchants := []string{"a", "a", "a", "b", "b", "c"}
+	fraud := []float64{1, 0, 1, 0, 0, 1}
+
+	inputs := make([]map[string]interface{}, len(merchants))
+	outputs := make([]map[string]interface{}, len(merchants))
+	for i := range merchants {
+		inputs[i] = map[string]interface{}{"merchant": merchants[i]}
+		outputs[i] = map[string]interface{}{"fraud": fraud[i]}
+	}
+	return inputs, outputs
+}
+
+// TestTargetEncoding tests smoothed target means and the overall mean for unseen categories
+func TestTargetEncoding(t *testing.T) {
+	model := NewLinearModel().WithEncoder(Encoder{Method: EncodeTarget, Smoothing: 2, Folds: 1}, "merchant")
+	inputs, outputs := encodeRows()
+	if err := model.fitPreprocessing(inputs, outputs); err != nil {
+		t.Fatalf("Fitting failed: %v", err)
+	}
+
+	encoder := model.Features["merchant"].Encode
+	if encoder.Target != "fraud" {
+		t.Errorf("Expected the only target to be used, got %q", encoder.Target)
+	}
+	expected := map[string]float64{"a": 3.0 / 5, "b": 1.0 / 4, "c": 2.0 / 3}
+	for category, want := range expected {
+		if got := encoder.Values[category]; math.Abs(got-want) > 1e-9 {
+			t.Errorf("Expected %s to encode as %v, got %v", category, want, got)
+		}
+	}
+
+	row := model.preprocess(map[string]interface{}{"merchant": "unseen"})
+	if row["merchant"] != 0.5 {
+		t.Errorf("Expected unseen merchants to get the overall mean 0.5, got %v", row["merchant"])
+	}
+}
+
+// TestTargetEncodingOutOfFold tests that training rows are encoded without their own fold
+func TestTargetEncodingOutOfFold(t *testing.T) {
+	model := NewLinearModel().WithEncoder(Encoder{Method: EncodeTarget, Folds: 2}, "merchant")
+	inputs, outputs := encodeRows()
+	if err := model.fitPreprocessing(inputs, outputs); err != nil {
+		t.Fatalf("Fitting failed: %v", err)
+	}
+
+	// Rows alternate between the folds; merchant c only appears in fold 1, so its row gets
+	// the mean of fold 0
+	expected := []float64{0, 1, 0, 0, 0, 2.0 / 3}
+	for i, row := range model.preprocessRows(inputs, 0) {
+		if got := row["merchant"].(float64); math.Abs(got-expected[i]) > 1e-9 {
+			t.Errorf("Row %d: expected out-of-fold encoding %v, got %v", i, expected[i], got)
+		}
+	}
+
+	// Predictions use every training row
+	if row := model.preprocess(map[string]interface{}{"merchant": "a"}); math.Abs(row["merchant"].(float64)-2.0/3) > 1e-9 {
+		t.Errorf("Expected merchant a to encode as 2/3 at prediction time, got %v", row["merchant"])
+	}
+}
+
+// TestFrequencyEncodingAndRareBuckets tests frequency encoding and bucketing of rare categories
+func TestFrequencyEncodingAndRareBuckets(t *testing.T) {
+	model := NewLinearModel().
+		WithEncoder(Encoder{Method: EncodeFrequency, MinCount: 2}, "merchant").
+		WithEncoder(Encoder{MinCount: 2}, "city")
+	inputs := []map[string]interface{}{
+		{"merchant": "a", "city": "riga"}, {"merchant": "a", "city": "riga"}, {"merchant": "a", "city": "tartu"},
+		{"merchant": "b", "city": "riga"}, {"merchant": "c", "city": "vilnius"},
+	}
+	if err := model.fitPreprocessing(inputs, nil); err != nil {
+		t.Fatalf("Fitting failed: %v", err)
+	}
+
+	values := model.Features["merchant"].Encode.Values
+	if values["a"] != 0.6 || values[RareCategory] != 0.4 || len(values) != 2 {
+		t.Errorf("Expected a at 0.6 and the rare bucket at 0.4, got %v", values)
+	}
+
+	for _, tc := range []struct {
+		input    map[string]interface{}
+		merchant interface{}
+		city     interface{}
+	}{
+		{map[string]interface{}{"merchant": "a", "city": "riga"}, 0.6, "riga"},
+		{map[string]interface{}{"merchant": "b", "city": "tartu"}, 0.4, RareCategory},
+		{map[string]interface{}{"merchant": "new", "city": "tallinn"}, 0.4, RareCategory},
+	} {
+		row := model.preprocess(tc.input)
+		if row["merchant"] != tc.merchant || row["city"] != tc.city {
+			t.Errorf("Expected %v to encode as %v/%v, got %v/%v", tc.input, tc.merchant, tc.city, row["merchant"], row["city"])
+		}
+	}
+}
+
+// TestEncoderEngine tests that the learned mappings are saved with the model and applied by Predict
+func TestEncoderEngine(t *testing.T) {
+	engine := New()
+	engine.WithModel(NewLinearModel().WithEncoder(Encoder{Method: EncodeTarget, Smoothing: 1}, "merchant").JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 10, BatchSize: 3, StrictSchema: true})
+
+	inputs, outputs := encodeRows()
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	modelJSON, _ := engine.GetModel()
+	var saved Model
+	if err := json.Unmarshal([]byte(*modelJSON), &saved); err != nil {
+		t.Fatalf("Failed to load model: %v", err)
+	}
+	if encoder := saved.Features["merchant"].Encode; len(encoder.Values) != 3 || encoder.Default != 0.5 {
+		t.Errorf("Expected the mapping to be saved, got %+v", encoder)
+	}
+
+	_, weights, _ := engine.snapshot()
+	if _, exists := weights.Get("merchant->fraud"); !exists {
+		t.Error("Expected a single weight for the encoded merchant")
+	}
+
+	// Unseen merchants are expected for high-cardinality features, even with a strict schema
+	if _, err := engine.Predict(map[string]interface{}{"merchant": "unseen"}); err != nil {
+		t.Errorf("Prediction of an unseen merchant failed: %v", err)
+	}
+}
+
+// TestEncoderInvalidConfiguration tests rejection of unknown methods and ambiguous targets
+func TestEncoderInvalidConfiguration(t *testing.T) {
+	engine := New()
+	engine.WithModel(NewLinearModel().WithEncoder(Encoder{Method: "onehot"}, "merchant").JSON())
+	err := engine.Train([]map[string]interface{}{{"merchant": "a"}}, []map[string]interface{}{{"y": 1.0}})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for an unknown method, got %v", err)
+	}
+
+	engine.WithModel(NewLinearModel().WithEncoder(Encoder{Method: EncodeTarget}, "merchant").JSON())
+	err = engine.Train([]map[string]interface{}{{"merchant": "a"}}, []map[string]interface{}{{"y": 1.0, "z": 2.0}})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput without a target for several targets, got %v", err)
+	}
+}
@@ -3,13 +3,23 @@ package goml
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 )
 
 // Engine encapsulates the entire ML system: model, weights, config, etc.
+// It is safe for concurrent use: predictions run in parallel with each other and with
+// training, while model and weight replacements are published atomically
 type Engine struct {
 	model   *Model
 	weights *Weights
 	config  *Config
+
+	// mu guards the model, weights and config pointers
+	// Published models and weights are never mutated, so readers only hold it to take a snapshot
+	mu sync.RWMutex
+
+	// trainMu serializes training runs
+	trainMu sync.Mutex
 }
 
 // New creates a new engine with default configuration
@@ -54,7 +64,10 @@ func (e *Engine) WithModel(modelJSON string) (*Model, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal model: %w", err)
 	}
+
+	e.mu.Lock()
 	e.model = &model
+	e.mu.Unlock()
 	return &model, nil
 }
 
@@ -65,23 +78,91 @@ func (e *Engine) WithWeights(weightsJSON string) (*Weights, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal weights: %w", err)
 	}
+
+	e.mu.Lock()
 	e.weights = &weights
+	e.mu.Unlock()
 	return &weights, nil
 }
 
 // WithConfig sets the training configuration
 func (e *Engine) WithConfig(config *Config) *Engine {
+	e.mu.Lock()
 	e.config = config
+	e.mu.Unlock()
 	return e
 }
 
+// Swap atomically replaces the model and weights, e.g. to hot reload a retrained model
+// Predictions already in flight finish with the previous pair; later ones see the new pair
+// The engine takes ownership of both values, so callers must not modify them afterwards
+func (e *Engine) Swap(model *Model, weights *Weights) error {
+	if model == nil {
+		return fmt.Errorf("model not initialized")
+	}
+
+	if weights == nil {
+		return fmt.Errorf("weights not initialized")
+	}
+
+	e.mu.Lock()
+	e.model = model
+	e.weights = weights
+	e.mu.Unlock()
+	return nil
+}
+
+// publish swaps in a trained model and weights unless the published pair was replaced since
+// the snapshot they were trained from, e.g. by WithModel or Swap. The newer pair is then kept
+// and the training result is discarded with ErrConcurrentUpdate
+func (e *Engine) publish(fromModel *Model, fromWeights *Weights, model *Model, weights *Weights) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.model != fromModel || e.weights != fromWeights {
+		return fmt.Errorf("training result discarded: %w", ErrConcurrentUpdate)
+	}
+	e.model = model
+	e.weights = weights
+	return nil
+}
+
+// snapshot returns the current model, weights and config under the read lock
+func (e *Engine) snapshot() (*Model, *Weights, *Config) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.model, e.weights, e.config
+}
+
 // Train trains the model with given input and output parameters
+// Training runs on private copies of the model and weights, which are swapped in when it
+// completes, so concurrent predictions keep using the previous model until then. If the model
+// or weights are replaced with WithModel, WithWeights or Swap while training runs, the
+// replacement wins: the training result is discarded and ErrConcurrentUpdate is returned
 func (e *Engine) Train(inputs []map[string]interface{}, outputs []map[string]interface{}) error {
-	if e.model == nil {
+	return e.fit(inputs, outputs, (*Model).Train)
+}
+
+// PartialFit updates the model with new data without starting over, for online learning
+// It runs Config.PartialFitEpochs passes over the new rows, keeps the existing weights and
+// categories, learns categories it has not seen before and updates the normalization statistics. Like Train, it
+// returns ErrConcurrentUpdate when the model or weights are replaced while it runs
+func (e *Engine) PartialFit(inputs []map[string]interface{}, outputs []map[string]interface{}) error {
+	return e.fit(inputs, outputs, (*Model).PartialFit)
+}
+
+// fit validates the data, runs the given training method on copies of the model and
+// weights, and publishes the result
+func (e *Engine) fit(inputs []map[string]interface{}, outputs []map[string]interface{}, train func(m *Model, inputs []map[string]interface{}, outputs []map[string]interface{}, weights *Weights, config *Config) error) error {
+	e.trainMu.Lock()
+	defer e.trainMu.Unlock()
+
+	model, weights, config := e.snapshot()
+	if model == nil {
 		return fmt.Errorf("model not initialized")
 	}
 
-	if len(inputs) != len(outputs) {
+	// Unsupervised models may be trained without outputs
+	if len(inputs) != len(outputs) && !(outputs == nil && model.Type == "pca") {
 		return fmt.Errorf("number of input samples (%d) must match number of output samples (%d)", len(inputs), len(outputs))
 	}
 
@@ -90,46 +171,79 @@ func (e *Engine) Train(inputs []map[string]interface{}, outputs []map[string]int
 	}
 
 	// Initialize weights if needed
-	if e.weights == nil {
-		e.weights = &Weights{
+	if weights == nil {
+		weights = &Weights{
 			Values: make(map[string]interface{}),
 		}
+
+		e.mu.Lock()
+		if e.weights == nil {
+			e.weights = weights
+		}
+		e.mu.Unlock()
+	}
+
+	// Train on copies so published models and weights are never mutated
+	trainModel, err := model.clone()
+	if err != nil {
+		return err
 	}
+	trainWeights := weights.clone()
 
 	// Delegate training to the model implementation
-	return e.model.Train(inputs, outputs, e.weights, e.config)
+	if err := train(trainModel, inputs, outputs, trainWeights, config); err != nil {
+		return err
+	}
+
+	return e.publish(model, weights, trainModel, trainWeights)
 }
 
 // Predict performs inference on the trained model
+// Inputs are validated against the model's schema first; see Config.StrictSchema
 func (e *Engine) Predict(input map[string]interface{}) (map[string]interface{}, error) {
-	if e.model == nil {
+	model, weights, config := e.snapshot()
+	return predictWith(model, weights, config, input)
+}
+
+// predictWith performs inference with a snapshot of the engine's model, weights and config
+func predictWith(model *Model, weights *Weights, config *Config, input map[string]interface{}) (map[string]interface{}, error) {
+	if model == nil {
 		return nil, fmt.Errorf("model not initialized")
 	}
 
-	if e.weights == nil {
+	if weights == nil {
 		return nil, fmt.Errorf("weights not initialized, model not trained")
 	}
 
+	// Reject inputs the model would silently skip
+	if model.Schema != nil {
+		if err := model.Schema.ValidateInput(input, config.strictSchema()); err != nil {
+			return nil, err
+		}
+	}
+
 	// Delegate prediction to the model implementation
-	return e.model.Predict(input, e.weights)
+	return model.predict(input, weights, config)
 }
 
 // GetModel serializes the current model to JSON
 func (e *Engine) GetModel() (*string, error) {
-	if e.model == nil {
+	model, _, _ := e.snapshot()
+	if model == nil {
 		return nil, fmt.Errorf("model not initialized")
 	}
 
-	modelJSON := e.model.JSON()
+	modelJSON := model.JSON()
 	return &modelJSON, nil
 }
 
 // GetWeights serializes the current weights to JSON
 func (e *Engine) GetWeights() (*string, error) {
-	if e.weights == nil {
+	_, weights, _ := e.snapshot()
+	if weights == nil {
 		return nil, fmt.Errorf("weights not initialized")
 	}
 
-	weightsJSON := e.weights.JSON()
+	weightsJSON := weights.JSON()
 	return &weightsJSON, nil
 }
@@ -0,0 +1,215 @@
+package goml
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// Run these tests with -race to check the engine's synchronization
+
+// newBiasOnlyWeights creates linear weights that always predict the given value for target y
+func newBiasOnlyWeights(value float64) *Weights {
+	return &Weights{
+		Values: map[string]interface{}{
+			"x->y":    0.0,
+			"bias->y": value,
+		},
+	}
+}
+
+// TestConcurrentTrainPredictReload tests interleaved training, prediction and reloading
+func TestConcurrentTrainPredictReload(t *testing.T) {
+	inputs := []map[string]interface{}{
+		{"x": 1.0}, {"x": 2.0}, {"x": 3.0}, {"x": 4.0},
+	}
+	outputs := []map[string]interface{}{
+		{"y": 2.0}, {"y": 4.0}, {"y": 6.0}, {"y": 8.0},
+	}
+
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 20, BatchSize: 2, Parallelism: 2})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Initial training failed: %v", err)
+	}
+
+	modelJSON, _ := engine.GetModel()
+	weightsJSON, _ := engine.GetWeights()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+
+	// Predictors
+	for p := 0; p < 4; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, err := engine.Predict(map[string]interface{}{"x": 2.5}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	// Trainer
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 3; i++ {
+			// Reloads during training win over the training result
+			if err := engine.Train(inputs, outputs); err != nil && !errors.Is(err, ErrConcurrentUpdate) {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	// Reloader
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			if _, err := engine.WithModel(*modelJSON); err != nil {
+				errs <- err
+				return
+			}
+			if _, err := engine.WithWeights(*weightsJSON); err != nil {
+				errs <- err
+				return
+			}
+			engine.GetModel()
+			engine.GetWeights()
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Concurrent operation failed: %v", err)
+	}
+}
+
+// TestSwapIsAtomic tests that predictions always see a consistent model and weights pair
+func TestSwapIsAtomic(t *testing.T) {
+	engine := New()
+	if err := engine.Swap(NewLinearModel(), newBiasOnlyWeights(1.0)); err != nil {
+		t.Fatalf("Swap failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			value := 1.0
+			if i%2 == 0 {
+				value = 2.0
+			}
+			engine.Swap(NewLinearModel(), newBiasOnlyWeights(value))
+		}
+		close(done)
+	}()
+
+	for p := 0; p < 4; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				prediction, err := engine.Predict(map[string]interface{}{"x": 3.0})
+				if err != nil {
+					t.Errorf("Prediction failed: %v", err)
+					return
+				}
+				if y := prediction["y"].(float64); y != 1.0 && y != 2.0 {
+					t.Errorf("Prediction from an inconsistent model: %v", y)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// TestTrainDiscardedAfterSwap tests that a model swapped in while training runs is not overwritten
+func TestTrainDiscardedAfterSwap(t *testing.T) {
+	engine := New()
+	replacement, replacementWeights := NewLinearModel(), newBiasOnlyWeights(7.0)
+
+	// The pipeline step runs during training, so the swap lands between snapshot and publish
+	var once sync.Once
+	RegisterFunc("test_swap", func(row map[string]interface{}) map[string]interface{} {
+		once.Do(func() { engine.Swap(replacement, replacementWeights) })
+		return row
+	})
+	engine.WithModel(NewLinearModel().WithPipeline(NewPipeline(&FuncTransform{Name: "test_swap"})).JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 5, BatchSize: 2})
+
+	inputs := []map[string]interface{}{{"x": 1.0}, {"x": 2.0}}
+	outputs := []map[string]interface{}{{"y": 2.0}, {"y": 4.0}}
+	if err := engine.Train(inputs, outputs); !errors.Is(err, ErrConcurrentUpdate) {
+		t.Fatalf("Expected ErrConcurrentUpdate, got %v", err)
+	}
+	if model, weights, _ := engine.snapshot(); model != replacement || weights != replacementWeights {
+		t.Fatalf("Expected the swapped model to be kept")
+	}
+
+	// Training on the new model publishes normally
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training after the swap failed: %v", err)
+	}
+	if model, _, _ := engine.snapshot(); model == replacement {
+		t.Errorf("Expected the trained model to be published")
+	}
+}
+
+// TestSwapRejectsNil tests that Swap refuses to install a missing model or weights
+func TestSwapRejectsNil(t *testing.T) {
+	engine := New()
+
+	if err := engine.Swap(nil, newBiasOnlyWeights(1.0)); err == nil {
+		t.Error("Expected error when swapping in a nil model")
+	}
+
+	if err := engine.Swap(NewLinearModel(), nil); err == nil {
+		t.Error("Expected error when swapping in nil weights")
+	}
+}
+
+// TestTrainLeavesPublishedWeightsUntouched tests that training works on a copy of the weights
+func TestTrainLeavesPublishedWeightsUntouched(t *testing.T) {
+	engine := New()
+	engine.Swap(NewLinearModel(), newBiasOnlyWeights(1.0))
+
+	_, published, _ := engine.snapshot()
+	before := published.JSON()
+
+	err := engine.Train(
+		[]map[string]interface{}{{"x": 1.0}, {"x": 2.0}},
+		[]map[string]interface{}{{"y": 3.0}, {"y": 5.0}},
+	)
+	if err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	if after := published.JSON(); after != before {
+		t.Errorf("Training mutated the previously published weights: before=%s after=%s", before, after)
+	}
+
+	_, current, _ := engine.snapshot()
+	if current == published {
+		t.Error("Expected training to publish new weights")
+	}
+}
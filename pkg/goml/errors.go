@@ -8,4 +8,5 @@ var (
 	ErrInvalidInput         = errors.New("invalid input data")
 	ErrInvalidOutput        = errors.New("invalid output data")
 	ErrModelNotTrained      = errors.New("model not trained")
+	ErrConcurrentUpdate     = errors.New("model replaced during training")
 )
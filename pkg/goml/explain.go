@@ -0,0 +1,500 @@
+package goml
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// ExplainMethod is how the contributions of an explanation were computed
+type ExplainMethod string
+
+const (
+	ExplainExact  ExplainMethod = "exact"  // Weight times the distance of the value from the training mean
+	ExplainKernel ExplainMethod = "kernel" // Shapley values of the model's predictions, exact for few features and sampled with KernelSHAP for many
+)
+
+// ExplainScale is the unit of the base value and contributions
+type ExplainScale string
+
+const (
+	ScaleValue       ExplainScale = "value"       // The predicted value of a numeric target
+	ScaleLogOdds     ExplainScale = "log_odds"    // The log odds of a boolean target; the prediction is its sigmoid
+	ScaleProbability ExplainScale = "probability" // The probability of the predicted category
+)
+
+// Contribution is the additive contribution of one feature to a prediction
+type Contribution struct {
+	Feature      string      `json:"feature"`
+	Value        interface{} `json:"value"` // Value of the feature in the explained input; nil when missing
+	Contribution float64     `json:"contribution"`
+}
+
+// Explanation splits the prediction of one target into a base value and one contribution per
+// feature, so that Value equals BaseValue plus the sum of the contributions
+type Explanation struct {
+	Target        string         `json:"target"`
+	Category      string         `json:"category,omitempty"` // Explained category of a categorical target, the predicted one
+	Method        ExplainMethod  `json:"method"`
+	Scale         ExplainScale   `json:"scale"`
+	BaseValue     float64        `json:"base_value"` // Value for an average input
+	Value         float64        `json:"value"`
+	Prediction    interface{}    `json:"prediction"`
+	Contributions []Contribution `json:"contributions"` // Largest absolute contribution first
+}
+
+// exactShapleyFeatures is the most features whose Shapley values are computed from every
+// coalition; explanations of more features sample kernelSamples coalitions
+const (
+	exactShapleyFeatures = 10
+	kernelSamples        = 2048
+)
+
+// Explain returns, per target, each feature's additive contribution to the prediction of an input
+// Linear models and the numeric and boolean targets of mixed models are explained exactly: a
+// feature contributes its weight times the distance of its value from the training mean, in
+// the features the model sees after preprocessing. Logistic contributions are in log odds,
+// before any calibration. Other models, such as categorical targets, are explained with
+// Shapley values of the raw input features: features are replaced by their training mean, or
+// left out when the model has no mean for them, and the contributions of the probability of
+// the predicted category are computed over coalitions of features. Sampling is seeded with
+// Config.Seed
+func (e *Engine) Explain(input map[string]interface{}) (map[string]*Explanation, error) {
+	model, weights, config := e.snapshot()
+	prediction, err := predictWith(model, weights, config, input)
+	if err != nil {
+		return nil, err
+	}
+
+	explanations := make(map[string]*Explanation)
+	var kernelTargets []string
+	for _, target := range predictedTargets(prediction) {
+		if explanation := model.exactExplanation(target, input, weights); explanation != nil {
+			explanation.Prediction = prediction[target]
+			explanations[target] = explanation
+		} else {
+			kernelTargets = append(kernelTargets, target)
+		}
+	}
+
+	if len(kernelTargets) > 0 {
+		seed := int64(0)
+		if config != nil {
+			seed = config.Seed
+		}
+		kernel, err := model.kernelExplanations(kernelTargets, input, prediction, weights, seed)
+		if err != nil {
+			return nil, err
+		}
+		for target, explanation := range kernel {
+			explanations[target] = explanation
+		}
+	}
+
+	for _, explanation := range explanations {
+		contributions := explanation.Contributions
+		sort.SliceStable(contributions, func(i, j int) bool {
+			return math.Abs(contributions[i].Contribution) > math.Abs(contributions[j].Contribution)
+		})
+	}
+	return explanations, nil
+}
+
+// predictionOptions are the suffixes of the keys a prediction adds next to a target
+var predictionOptions = []string{"_probs", "_prob", "_top", "_abstain"}
+
+// predictedTargets returns the targets of a prediction in lexical order, without the
+// probabilities and other options stored next to them
+func predictedTargets(prediction map[string]interface{}) []string {
+	var targets []string
+	for key := range prediction {
+		if !isPredictionOption(prediction, key) {
+			targets = append(targets, key)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// isPredictionOption reports whether a key of a prediction belongs to another target
+func isPredictionOption(prediction map[string]interface{}, key string) bool {
+	for _, suffix := range predictionOptions {
+		if target, isOption := strings.CutSuffix(key, suffix); isOption {
+			if _, exists := prediction[target]; exists {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exactExplanation explains a target of a linear or logistic model, or nil for other targets
+func (m *Model) exactExplanation(target string, input map[string]interface{}, weights *Weights) *Explanation {
+	scale := ScaleValue
+	switch {
+	case m.Type == "linear", m.Type == "mixed" && m.Targets[target] == "numeric":
+	case m.Type == "logistic", m.Type == "mixed" && m.Targets[target] == "boolean":
+		scale = ScaleLogOdds
+	default:
+		return nil
+	}
+
+	row := m.preprocess(input)
+	explanation := &Explanation{Target: target, Method: ExplainExact, Scale: scale}
+
+	weights.mu.RLock()
+	for key, raw := range weights.Values {
+		parts := splitWeightKey(key)
+		weight, ok := numericValue(raw)
+		if parts[1] != target || !ok {
+			continue
+		}
+		if parts[0] == "bias" {
+			explanation.BaseValue += weight
+			continue
+		}
+
+		// Features are valued as the models value them at prediction time
+		mean := 0.0
+		if stats := m.Stats[parts[0]]; stats != nil {
+			mean = stats.Mean()
+		}
+		x, _ := weightedValue(parts[0], row[parts[0]])
+		explanation.BaseValue += weight * mean
+		explanation.Contributions = append(explanation.Contributions, Contribution{
+			Feature:      parts[0],
+			Value:        row[parts[0]],
+			Contribution: weight * (x - mean),
+		})
+	}
+	weights.mu.RUnlock()
+
+	sort.Slice(explanation.Contributions, func(i, j int) bool {
+		return explanation.Contributions[i].Feature < explanation.Contributions[j].Feature
+	})
+	explanation.Value = explanation.BaseValue
+	for _, contribution := range explanation.Contributions {
+		explanation.Value += contribution.Contribution
+	}
+	return explanation
+}
+
+// weightedValue converts a feature value the way the linear, logistic and categorical models
+// do at prediction time; other values and missing features count as 0
+func weightedValue(feature string, val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		if v == feature {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// kernelExplanations explains targets with Shapley values over the raw input features
+func (m *Model) kernelExplanations(targets []string, input map[string]interface{}, prediction map[string]interface{}, weights *Weights, seed int64) (map[string]*Explanation, error) {
+	features := sortedKeys(input)
+
+	// Explained categories are fixed by the prediction of the complete input
+	categories := make(map[string]string, len(targets))
+	for _, target := range targets {
+		if probs, ok := prediction[target+"_probs"].(map[string]float64); ok {
+			categories[target] = mostProbable(probs)
+		}
+	}
+
+	// value evaluates the explained outputs with the features outside the coalition at
+	// their baseline
+	baseline := make(map[string]interface{})
+	for _, feature := range features {
+		if stats := m.Stats[feature]; stats != nil && stats.Count > 0 && IsSupportedNumericType(input[feature]) {
+			baseline[feature] = stats.Mean()
+		}
+	}
+	value := func(coalition []bool) ([]float64, error) {
+		row := make(map[string]interface{}, len(features))
+		for j, feature := range features {
+			if coalition[j] {
+				row[feature] = input[feature]
+			} else if val, exists := baseline[feature]; exists {
+				row[feature] = val
+			}
+		}
+		result, err := m.Predict(row, weights)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]float64, len(targets))
+		for t, target := range targets {
+			values[t] = explainedValue(result, target, categories[target])
+		}
+		return values, nil
+	}
+
+	var contributions [][]float64
+	var base, full []float64
+	var err error
+	if len(features) <= exactShapleyFeatures {
+		contributions, base, full, err = exactShapley(len(features), len(targets), value)
+	} else {
+		contributions, base, full, err = kernelShapley(len(features), len(targets), value, rand.New(rand.NewSource(seed)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	explanations := make(map[string]*Explanation, len(targets))
+	for t, target := range targets {
+		explanation := &Explanation{
+			Target:     target,
+			Category:   categories[target],
+			Method:     ExplainKernel,
+			Scale:      ScaleValue,
+			BaseValue:  base[t],
+			Value:      full[t],
+			Prediction: prediction[target],
+		}
+		if categories[target] != "" {
+			explanation.Scale = ScaleProbability
+		}
+		for j, feature := range features {
+			explanation.Contributions = append(explanation.Contributions, Contribution{
+				Feature:      feature,
+				Value:        input[feature],
+				Contribution: contributions[j][t],
+			})
+		}
+		explanations[target] = explanation
+	}
+	return explanations, nil
+}
+
+// mostProbable returns the category with the highest probability, the first in lexical order on ties
+func mostProbable(probs map[string]float64) string {
+	best := ""
+	for category, p := range probs {
+		if best == "" || p > probs[best] || (p == probs[best] && category < best) {
+			best = category
+		}
+	}
+	return best
+}
+
+// explainedValue returns the explained number of a target in a prediction: the probability of
+// the category for categorical targets, or the value converted to a number
+func explainedValue(prediction map[string]interface{}, target string, category string) float64 {
+	if category != "" {
+		probs, _ := prediction[target+"_probs"].(map[string]float64)
+		return probs[category]
+	}
+	x, _ := numericValue(prediction[target])
+	return x
+}
+
+// exactShapley computes Shapley values from every coalition of n features
+// It returns the contributions per feature and output, and the outputs without and with all features
+func exactShapley(n int, outputs int, value func([]bool) ([]float64, error)) ([][]float64, []float64, []float64, error) {
+	values := make([][]float64, 1<<n)
+	for mask := range values {
+		coalition := make([]bool, n)
+		for j := range coalition {
+			coalition[j] = mask&(1<<j) != 0
+		}
+		v, err := value(coalition)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		values[mask] = v
+	}
+
+	// weights[s] is the Shapley weight of a coalition of s other features
+	weights := make([]float64, n)
+	for s := range weights {
+		weights[s] = math.Exp(lgamma(s+1) + lgamma(n-s) - lgamma(n+1))
+	}
+
+	contributions := make([][]float64, n)
+	for j := range contributions {
+		contributions[j] = make([]float64, outputs)
+		for mask := range values {
+			if mask&(1<<j) != 0 {
+				continue
+			}
+			weight := weights[popcount(mask)]
+			for t := 0; t < outputs; t++ {
+				contributions[j][t] += weight * (values[mask|1<<j][t] - values[mask][t])
+			}
+		}
+	}
+	return contributions, values[0], values[len(values)-1], nil
+}
+
+// kernelShapley estimates Shapley values of n features with KernelSHAP (Lundberg and Lee, 2017)
+// Coalitions are sampled in complementary pairs with the Shapley kernel distribution over
+// their sizes, and the contributions are the weighted least squares fit of the outputs that
+// adds up to the difference between all and no features
+func kernelShapley(n int, outputs int, value func([]bool) ([]float64, error), rng *rand.Rand) ([][]float64, []float64, []float64, error) {
+	empty, err := value(make([]bool, n))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	all := make([]bool, n)
+	for j := range all {
+		all[j] = true
+	}
+	full, err := value(all)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Sizes are drawn with probability proportional to (n-1) / (s (n-s))
+	sizeWeights := make([]float64, n)
+	total := 0.0
+	for s := 1; s < n; s++ {
+		sizeWeights[s] = float64(n-1) / float64(s*(n-s))
+		total += sizeWeights[s]
+	}
+
+	// The last feature's contribution is eliminated with the constraint, leaving n-1 unknowns
+	m := n - 1
+	gram := make([][]float64, m)
+	for a := range gram {
+		gram[a] = make([]float64, m)
+	}
+	moments := make([][]float64, outputs)
+	for t := range moments {
+		moments[t] = make([]float64, m)
+	}
+
+	for sample := 0; sample < kernelSamples/2; sample++ {
+		r := rng.Float64() * total
+		size := 1
+		for ; size < n-1 && r > sizeWeights[size]; size++ {
+			r -= sizeWeights[size]
+		}
+		coalition := make([]bool, n)
+		for _, j := range rng.Perm(n)[:size] {
+			coalition[j] = true
+		}
+
+		for pair := 0; pair < 2; pair++ {
+			if pair == 1 {
+				for j := range coalition {
+					coalition[j] = !coalition[j]
+				}
+			}
+			v, err := value(coalition)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			last := indicator(coalition[n-1])
+			x := make([]float64, m)
+			for j := range x {
+				x[j] = indicator(coalition[j]) - last
+			}
+			for a := range x {
+				for b := range x {
+					gram[a][b] += x[a] * x[b]
+				}
+			}
+			for t := range moments {
+				y := v[t] - empty[t] - last*(full[t]-empty[t])
+				for a := range x {
+					moments[t][a] += x[a] * y
+				}
+			}
+		}
+	}
+
+	// A tiny ridge keeps features that never change the coalition value solvable
+	for a := range gram {
+		gram[a][a] += 1e-9
+	}
+
+	contributions := make([][]float64, n)
+	for j := range contributions {
+		contributions[j] = make([]float64, outputs)
+	}
+	for t := 0; t < outputs; t++ {
+		solution := solveLinear(gram, moments[t])
+		remaining := full[t] - empty[t]
+		for j, phi := range solution {
+			contributions[j][t] = phi
+			remaining -= phi
+		}
+		contributions[n-1][t] = remaining
+	}
+	return contributions, empty, full, nil
+}
+
+// solveLinear solves the linear system a x = b by Gaussian elimination with partial pivoting
+func solveLinear(a [][]float64, b []float64) []float64 {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = append(append([]float64(nil), a[i]...), b[i])
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		if m[col][col] == 0 {
+			continue
+		}
+		for row := col + 1; row < n; row++ {
+			factor := m[row][col] / m[col][col]
+			for k := col; k <= n; k++ {
+				m[row][k] -= factor * m[col][k]
+			}
+		}
+	}
+
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		if m[row][row] == 0 {
+			continue
+		}
+		sum := m[row][n]
+		for k := row + 1; k < n; k++ {
+			sum -= m[row][k] * x[k]
+		}
+		x[row] = sum / m[row][row]
+	}
+	return x
+}
+
+// indicator returns 1 for true and 0 for false
+func indicator(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// popcount returns the number of set bits
+func popcount(mask int) int {
+	count := 0
+	for ; mask > 0; mask &= mask - 1 {
+		count++
+	}
+	return count
+}
+
+// lgamma returns the log of the gamma function of a positive integer, log((n-1)!)
+func lgamma(n int) float64 {
+	value, _ := math.Lgamma(float64(n))
+	return value
+}
@@ -0,0 +1,212 @@
+package goml
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// contributionSum returns the sum of the contributions of an explanation
+func contributionSum(explanation *Explanation) float64 {
+	sum := 0.0
+	for _, contribution := range explanation.Contributions {
+		sum += contribution.Contribution
+	}
+	return sum
+}
+
+// TestExplainLinear tests exact explanations of linear and logistic models
+func TestExplainLinear(t *testing.T) {
+	var inputs, outputs []map[string]interface{}
+	for i := 0; i < 20; i++ {
+		a, b := float64(i%5+1), float64(i%4+1)
+		inputs = append(inputs, map[string]interface{}{"a": a, "b": b})
+		outputs = append(outputs, map[string]interface{}{"y": 3*a + 2*b})
+	}
+
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 200, BatchSize: 5})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	input := map[string]interface{}{"a": 5.0, "b": 1.0}
+	explanations, err := engine.Explain(input)
+	if err != nil {
+		t.Fatalf("Explanation failed: %v", err)
+	}
+	explanation := explanations["y"]
+	prediction, _ := engine.Predict(input)
+	if explanation.Method != ExplainExact || explanation.Scale != ScaleValue {
+		t.Errorf("Expected an exact explanation of the value, got %s in %s", explanation.Method, explanation.Scale)
+	}
+	if math.Abs(explanation.Value-prediction["y"].(float64)) > 1e-9 || math.Abs(explanation.BaseValue+contributionSum(explanation)-explanation.Value) > 1e-9 {
+		t.Errorf("Expected the contributions to add up to the prediction %v, got %+v", prediction["y"], explanation)
+	}
+
+	model, weights, _ := engine.snapshot()
+	weightA, _ := weights.GetFloat("a->y")
+	if explanation.Contributions[0].Feature != "a" || math.Abs(explanation.Contributions[0].Contribution-weightA*(5-model.Stats["a"].Mean())) > 1e-9 {
+		t.Errorf("Expected a to contribute its weight times its distance from the mean, got %+v", explanation.Contributions)
+	}
+	average, _ := engine.Predict(map[string]interface{}{"a": model.Stats["a"].Mean(), "b": model.Stats["b"].Mean()})
+	if math.Abs(explanation.BaseValue-average["y"].(float64)) > 1e-9 {
+		t.Errorf("Expected the base value to be the prediction for the mean input %v, got %v", average["y"], explanation.BaseValue)
+	}
+
+	logistic := New()
+	logistic.WithModel(NewLogisticModel().JSON())
+	logistic.WithConfig(&Config{LearningRate: 0.1, Epochs: 100, BatchSize: 5})
+	for i := range outputs {
+		outputs[i] = map[string]interface{}{"high": 0.0}
+		if inputs[i]["a"].(float64) > 3 {
+			outputs[i]["high"] = 1.0
+		}
+	}
+	if err := logistic.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+	explanations, err = logistic.Explain(input)
+	if err != nil {
+		t.Fatalf("Explanation failed: %v", err)
+	}
+	prediction, _ = logistic.Predict(input)
+	if explanation := explanations["high"]; explanation.Scale != ScaleLogOdds || math.Abs(sigmoid(explanation.Value)-prediction["high"].(float64)) > 1e-9 {
+		t.Errorf("Expected log odds whose sigmoid is the prediction %v, got %+v", prediction["high"], explanation)
+	}
+}
+
+// TestExplainCategorical tests Shapley explanations of the predicted category
+func TestExplainCategorical(t *testing.T) {
+	var inputs, outputs []map[string]interface{}
+	for i := 0; i < 40; i++ {
+		amount := float64(i%10 + 1)
+		label := "ok"
+		if amount > 7 {
+			label = "fraud"
+		}
+		inputs = append(inputs, map[string]interface{}{"amount": amount, "hour": float64(i%3 + 1)})
+		outputs = append(outputs, map[string]interface{}{"label": label})
+	}
+
+	engine := New()
+	engine.WithModel(NewCategoricalModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 200, BatchSize: 10})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	input := map[string]interface{}{"amount": 10.0, "hour": 2.0}
+	explanations, err := engine.Explain(input)
+	if err != nil {
+		t.Fatalf("Explanation failed: %v", err)
+	}
+	prediction, _ := engine.Predict(input)
+	explanation := explanations["label"]
+	if len(explanations) != 1 || explanation.Method != ExplainKernel || explanation.Scale != ScaleProbability {
+		t.Fatalf("Expected one probability explanation for the label, got %+v", explanations)
+	}
+	if explanation.Category != prediction["label"] {
+		t.Errorf("Expected the predicted category %v to be explained, got %q", prediction["label"], explanation.Category)
+	}
+	probability := prediction["label_probs"].(map[string]float64)[explanation.Category]
+	if math.Abs(explanation.Value-probability) > 1e-9 || math.Abs(explanation.BaseValue+contributionSum(explanation)-probability) > 1e-9 {
+		t.Errorf("Expected the contributions to add up to the probability %v, got %+v", probability, explanation)
+	}
+	if explanation.Contributions[0].Feature != "amount" || explanation.Contributions[0].Contribution <= 0 {
+		t.Errorf("Expected the large amount to drive the prediction, got %+v", explanation.Contributions)
+	}
+
+	if _, err := json.Marshal(explanations); err != nil {
+		t.Errorf("Expected explanations to serialize to JSON, got %v", err)
+	}
+}
+
+// TestExplainProbabilities tests that label probabilities and other prediction options are not explained as targets
+func TestExplainProbabilities(t *testing.T) {
+	inputs, outputs := calibrationRows(1, 200)
+	for i := range outputs {
+		outputs[i] = map[string]interface{}{"flag": outputs[i]["flag"]}
+	}
+
+	engine := New()
+	engine.WithModel(NewLogisticModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 50, BatchSize: 20, Probabilities: true})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	input := map[string]interface{}{"x": 4.0}
+	explanations, err := engine.Explain(input)
+	if err != nil {
+		t.Fatalf("Explanation failed: %v", err)
+	}
+	prediction, _ := engine.Predict(input)
+	explanation := explanations["flag"]
+	if len(explanations) != 1 || explanation == nil || explanation.Method != ExplainExact {
+		t.Fatalf("Expected one exact explanation for flag, got %+v", explanations)
+	}
+	if explanation.Prediction != prediction["flag"] {
+		t.Errorf("Expected the predicted label %v, got %v", prediction["flag"], explanation.Prediction)
+	}
+
+	// Ranked categories and abstentions are not targets either
+	categorical := New()
+	categorical.WithModel(NewCategoricalModel().JSON())
+	categorical.WithConfig(&Config{LearningRate: 0.1, Epochs: 50, BatchSize: 20, TopK: 2, Probabilities: true, MinConfidence: 0.3})
+	labels := make([]map[string]interface{}, len(inputs))
+	for i := range outputs {
+		labels[i] = map[string]interface{}{"label": []string{"no", "yes"}[int(outputs[i]["flag"].(float64))]}
+	}
+	if err := categorical.Train(inputs, labels); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+	explanations, err = categorical.Explain(input)
+	if err != nil {
+		t.Fatalf("Explanation failed: %v", err)
+	}
+	prediction, _ = categorical.Predict(input)
+	if _, exists := prediction["label_abstain"]; !exists || len(explanations) != 1 || explanations["label"] == nil {
+		t.Errorf("Expected one explanation for label, got %+v for %v", explanations, prediction)
+	}
+}
+
+// TestKernelShapley tests sampled Shapley values against the exact ones
+func TestKernelShapley(t *testing.T) {
+	// An additive part plus an interaction between the first two features
+	coefficients := []float64{1, -2, 0.5, 0, 3, -1, 0.25, 2, 0, 1.5, -0.5, 1}
+	value := func(coalition []bool) ([]float64, error) {
+		v := 0.0
+		for j, in := range coalition {
+			v += coefficients[j] * indicator(in)
+		}
+		v += 4 * indicator(coalition[0]) * indicator(coalition[1])
+		return []float64{v}, nil
+	}
+
+	exact, _, _, _ := exactShapley(len(coefficients), 1, value)
+	sampled, base, full, err := kernelShapley(len(coefficients), 1, value, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("Kernel Shapley failed: %v", err)
+	}
+
+	sum := 0.0
+	for j := range coefficients {
+		want := coefficients[j]
+		if j < 2 {
+			want += 2
+		}
+		if math.Abs(exact[j][0]-want) > 1e-9 {
+			t.Errorf("Expected exact Shapley value %v for feature %d, got %v", want, j, exact[j][0])
+		}
+		if math.Abs(sampled[j][0]-want) > 0.05 {
+			t.Errorf("Expected sampled Shapley value near %v for feature %d, got %v", want, j, sampled[j][0])
+		}
+		sum += sampled[j][0]
+	}
+	if math.Abs(base[0]+sum-full[0]) > 1e-9 {
+		t.Errorf("Expected sampled values to add up to %v, got %v", full[0]-base[0], sum)
+	}
+}
@@ -0,0 +1,207 @@
+package goml
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// ListMode selects how the elements of a list-valued feature become features
+type ListMode string
+
+const (
+	ListAuto      ListMode = ""          // Strings and booleans multi-hot, numbers aggregated
+	ListMultiHot  ListMode = "multi_hot" // Every element as a category, including numbers
+	ListAggregate ListMode = "aggregate" // Only the aggregates of the numeric elements
+)
+
+// Aggregate is a summary of the numeric elements of a list
+type Aggregate string
+
+const (
+	AggregateCount Aggregate = "count" // Number of elements, including non-numeric ones
+	AggregateSum   Aggregate = "sum"
+	AggregateMean  Aggregate = "mean"
+	AggregateMin   Aggregate = "min"
+	AggregateMax   Aggregate = "max"
+)
+
+// DefaultAggregates are the aggregates computed when ListFeatures.Aggregates is empty
+var DefaultAggregates = []Aggregate{AggregateCount, AggregateMean, AggregateMin, AggregateMax}
+
+// ListFeatures configures how a list-valued feature is flattened
+// Multi-hot elements become features named "<feature>=<value>" with value 1, and aggregates
+// features named "<feature>_<aggregate>". Lists of objects are aggregated per field, e.g.
+// "items.price_mean" and "items.category=books", with the element count in "items_count"
+type ListFeatures struct {
+	Mode       ListMode    `json:"mode,omitempty"`
+	Aggregates []Aggregate `json:"aggregates,omitempty"` // Default DefaultAggregates
+}
+
+// WithList configures how one or more list-valued features are flattened
+// Features inside nested objects are named by their dotted path, e.g. "device.tags"
+func (m *Model) WithList(list ListFeatures, features ...string) *Model {
+	for _, feature := range features {
+		spec := m.featureSpec(feature)
+		listCopy := list
+		listCopy.Aggregates = append([]Aggregate(nil), list.Aggregates...)
+		spec.List = &listCopy
+	}
+	return m
+}
+
+// validate checks the list configuration
+func (l *ListFeatures) validate(feature string) error {
+	switch l.Mode {
+	case ListAuto, ListMultiHot, ListAggregate:
+	default:
+		return fmt.Errorf("feature %q: unknown list mode %q: %w", feature, l.Mode, ErrInvalidInput)
+	}
+	for _, aggregate := range l.Aggregates {
+		switch aggregate {
+		case AggregateCount, AggregateSum, AggregateMean, AggregateMin, AggregateMax:
+		default:
+			return fmt.Errorf("feature %q: unknown aggregate %q: %w", feature, aggregate, ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// aggregates returns the configured aggregates, or the defaults
+func (l *ListFeatures) aggregates() []Aggregate {
+	if len(l.Aggregates) == 0 {
+		return DefaultAggregates
+	}
+	return l.Aggregates
+}
+
+// isNested reports whether a value is a map with string keys or a list
+func isNested(val interface{}) bool {
+	if val == nil {
+		return false
+	}
+	switch reflect.TypeOf(val).Kind() {
+	case reflect.Map:
+		return reflect.TypeOf(val).Key().Kind() == reflect.String
+	case reflect.Slice:
+		// Byte slices are binary data rather than lists
+		return reflect.TypeOf(val).Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return true
+	default:
+		return false
+	}
+}
+
+// hasNestedValues reports whether a row holds nested objects or lists
+func hasNestedValues(input map[string]interface{}) bool {
+	for _, val := range input {
+		if isNested(val) {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten replaces the nested objects and lists in a row with flat features
+func (m *Model) flatten(row map[string]interface{}) {
+	var nested []string
+	for key, val := range row {
+		if isNested(val) {
+			nested = append(nested, key)
+		}
+	}
+	for _, key := range nested {
+		val := row[key]
+		delete(row, key)
+		m.flattenValue(key, val, row)
+	}
+}
+
+// flattenValue adds a value to the row under a name, flattening objects and lists
+func (m *Model) flattenValue(name string, val interface{}, row map[string]interface{}) {
+	if !isNested(val) {
+		row[name] = val
+		return
+	}
+
+	rv := reflect.ValueOf(val)
+	if rv.Kind() == reflect.Map {
+		iter := rv.MapRange()
+		for iter.Next() {
+			m.flattenValue(name+"."+iter.Key().String(), iter.Value().Interface(), row)
+		}
+		return
+	}
+
+	elements := make([]interface{}, rv.Len())
+	for i := range elements {
+		elements[i] = rv.Index(i).Interface()
+	}
+	m.flattenList(name, elements, row)
+}
+
+// flattenList adds the multi-hot and aggregate features of a list to the row
+func (m *Model) flattenList(name string, elements []interface{}, row map[string]interface{}) {
+	list := &ListFeatures{}
+	if spec := m.Features[name]; spec != nil && spec.List != nil {
+		list = spec.List
+	}
+
+	// Objects in the list are flattened first, so their fields are summarized separately
+	fields := make(map[string][]interface{})
+	for _, element := range elements {
+		if !isNested(element) {
+			fields[name] = append(fields[name], element)
+			continue
+		}
+		flat := make(map[string]interface{})
+		m.flattenValue(name, element, flat)
+		for key, val := range flat {
+			fields[key] = append(fields[key], val)
+		}
+	}
+
+	aggregates := list.aggregates()
+	if list.Mode != ListMultiHot && slices.Contains(aggregates, AggregateCount) {
+		row[name+"_count"] = float64(len(elements))
+	}
+
+	for key, values := range fields {
+		var numbers []float64
+		for _, val := range values {
+			if isMissing(val) {
+				continue
+			}
+			x, numeric := numericValue(val)
+			numeric = numeric && IsSupportedNumericType(val)
+
+			switch {
+			case list.Mode == ListMultiHot || (list.Mode == ListAuto && !numeric):
+				row[fmt.Sprintf("%s=%v", key, val)] = 1.0
+			case numeric:
+				numbers = append(numbers, x)
+			}
+		}
+		if len(numbers) == 0 {
+			continue
+		}
+
+		stats := &FeatureStats{}
+		for _, x := range numbers {
+			stats.Add(x)
+		}
+		for _, aggregate := range aggregates {
+			switch aggregate {
+			case AggregateSum:
+				row[key+"_sum"] = stats.Sum
+			case AggregateMean:
+				row[key+"_mean"] = stats.Mean()
+			case AggregateMin:
+				row[key+"_min"] = stats.Min
+			case AggregateMax:
+				row[key+"_max"] = stats.Max
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package goml
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestFlattenNested tests dotted names for nested objects and the default list features
+func TestFlattenNested(t *testing.T) {
+	input := map[string]interface{}{
+		"amount": 5.0,
+		"device": map[string]interface{}{"os": "ios", "screen": map[string]interface{}{"width": 390}},
+		"tags":   []interface{}{"a", "b"},
+		"scores": []float64{1, 2, 6},
+		"items": []interface{}{
+			map[string]interface{}{"price": 10.0, "category": "books"},
+			map[string]interface{}{"price": 20.0, "category": "music"},
+		},
+		"empty": []interface{}{},
+	}
+
+	row := NewLinearModel().preprocess(input)
+	expected := map[string]interface{}{
+		"amount":               5.0,
+		"device.os":            "ios",
+		"device.screen.width":  390,
+		"tags_count":           2.0,
+		"tags=a":               1.0,
+		"tags=b":               1.0,
+		"scores_count":         3.0,
+		"scores_mean":          3.0,
+		"scores_min":           1.0,
+		"scores_max":           6.0,
+		"items_count":          2.0,
+		"items.price_mean":     15.0,
+		"items.price_min":      10.0,
+		"items.price_max":      20.0,
+		"items.category=books": 1.0,
+		"items.category=music": 1.0,
+		"empty_count":          0.0,
+	}
+	if !reflect.DeepEqual(row, expected) {
+		t.Errorf("Expected %v, got %v", expected, row)
+	}
+	if _, nested := input["device"].(map[string]interface{}); !nested || len(input) != 6 {
+		t.Errorf("Flattening modified the caller's input: %v", input)
+	}
+}
+
+// TestFlattenListModes tests the configured list modes and specs on dotted names
+func TestFlattenListModes(t *testing.T) {
+	model := NewLinearModel().
+		WithList(ListFeatures{Mode: ListMultiHot}, "codes").
+		WithList(ListFeatures{Mode: ListAggregate, Aggregates: []Aggregate{AggregateSum}}, "device.readings").
+		WithImputer(Imputer{Strategy: ImputeConstant, Value: "unknown"}, "device.os")
+
+	inputs := []map[string]interface{}{{
+		"codes":  []interface{}{7, 9},
+		"device": map[string]interface{}{"readings": []interface{}{1.5, "n/a", 2.5}},
+	}}
+	if err := model.fitPreprocessing(inputs, nil); err != nil {
+		t.Fatalf("Fitting failed: %v", err)
+	}
+
+	row := model.preprocess(inputs[0])
+	expected := map[string]interface{}{
+		"codes=7":             1.0,
+		"codes=9":             1.0,
+		"device.readings_sum": 4.0,
+		"device.os":           "unknown",
+	}
+	if !reflect.DeepEqual(row, expected) {
+		t.Errorf("Expected %v, got %v", expected, row)
+	}
+}
+
+// TestFlattenTrainStream tests training on nested JSON Lines records
+func TestFlattenTrainStream(t *testing.T) {
+	data := strings.Join([]string{
+		`{"device": {"os": "ios"}, "tags": ["promo"], "items": [{"price": 10}], "label": "buy"}`,
+		`{"device": {"os": "android"}, "tags": ["news", "promo"], "items": [{"price": 12}, {"price": 8}], "label": "buy"}`,
+		`{"device": {"os": "ios"}, "tags": ["news"], "items": [], "label": "skip"}`,
+		`{"device": {"os": "web"}, "tags": [], "items": [{"price": 1}], "label": "skip"}`,
+	}, "\n")
+
+	engine := New()
+	engine.WithModel(NewCategoricalModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 50, BatchSize: 2})
+	if err := engine.TrainStream(NewJSONLReader(strings.NewReader(data), nil, []string{"label"})); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+
+	model, weights, _ := engine.snapshot()
+	for _, key := range []string{"tags=promo->label:buy", "items_count->label:buy", "items.price_mean->label:buy"} {
+		if _, exists := weights.Get(key); !exists {
+			t.Errorf("Expected weight %s", key)
+		}
+	}
+	if stats := model.Stats["items_count"]; stats == nil || stats.Count != 4 {
+		t.Errorf("Expected statistics of the flattened features, got %+v", stats)
+	}
+
+	prediction, err := engine.Predict(map[string]interface{}{
+		"device": map[string]interface{}{"os": "ios"},
+		"tags":   []string{"promo"},
+		"items":  []interface{}{map[string]interface{}{"price": 11}},
+	})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if prediction["label"] != "buy" {
+		t.Errorf("Expected buy, got %v", prediction["label"])
+	}
+}
+
+// TestFlattenInvalidConfiguration tests rejection of unknown list modes and aggregates
+func TestFlattenInvalidConfiguration(t *testing.T) {
+	for _, list := range []ListFeatures{{Mode: "one_hot"}, {Aggregates: []Aggregate{"median"}}} {
+		engine := New()
+		engine.WithModel(NewLinearModel().WithList(list, "tags").JSON())
+
+		err := engine.Train([]map[string]interface{}{{"tags": []interface{}{1.0}}}, []map[string]interface{}{{"y": 1.0}})
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for %+v, got %v", list, err)
+		}
+	}
+}
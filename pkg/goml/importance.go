@@ -0,0 +1,340 @@
+package goml
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Metric scores predictions against the expected outputs of a target
+type Metric string
+
+const (
+	MetricAuto     Metric = ""         // Accuracy for string and boolean targets, mean squared error for numeric ones
+	MetricAccuracy Metric = "accuracy" // Share of correct predictions; probabilities of boolean targets count as true from 0.5
+	MetricMSE      Metric = "mse"      // Mean squared error
+	MetricMAE      Metric = "mae"      // Mean absolute error
+	MetricR2       Metric = "r2"       // Coefficient of determination
+)
+
+// permutationRepeats is the number of times each feature is shuffled
+const permutationRepeats = 5
+
+// TargetScore is the score of the unpermuted rows for one target
+type TargetScore struct {
+	Target string  `json:"target"`
+	Metric Metric  `json:"metric"`
+	Score  float64 `json:"score"`
+}
+
+// FeatureImportance is the permutation importance of one feature for one target
+type FeatureImportance struct {
+	Feature    string  `json:"feature"`
+	Target     string  `json:"target"`
+	Importance float64 `json:"importance"` // Mean loss of score when the feature is shuffled; near zero or negative for unused features
+	StdDev     float64 `json:"std_dev"`    // Standard deviation of the loss over the shuffles
+}
+
+// ImportanceReport is the result of permutation importance
+type ImportanceReport struct {
+	Targets  []TargetScore       `json:"targets"`
+	Features []FeatureImportance `json:"features"` // Ordered by target, then most important first
+}
+
+// Importance computes the permutation importance of every input feature for any model type
+// Each feature is shuffled across the rows several times, and its importance is how much the
+// metric gets worse on average, so it measures what the trained model relies on rather than
+// what the model type exposes. Shuffles are seeded with Config.Seed, so reports are reproducible
+func Importance(engine *Engine, inputs []map[string]interface{}, outputs []map[string]interface{}, metric Metric) (*ImportanceReport, error) {
+	if engine == nil {
+		return nil, fmt.Errorf("engine not initialized")
+	}
+	if len(inputs) != len(outputs) {
+		return nil, fmt.Errorf("number of input samples (%d) must match number of output samples (%d)", len(inputs), len(outputs))
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no data provided")
+	}
+
+	targets := sortedRowKeys(outputs)
+	metrics := make(map[string]Metric, len(targets))
+	for _, target := range targets {
+		resolved, err := metric.resolve(target, outputs)
+		if err != nil {
+			return nil, err
+		}
+		metrics[target] = resolved
+	}
+
+	predictions, err := engine.PredictBatch(inputs)
+	if err != nil {
+		return nil, err
+	}
+	report := &ImportanceReport{}
+	baseline := make(map[string]float64, len(targets))
+	for _, target := range targets {
+		baseline[target] = metrics[target].score(target, predictions, outputs)
+		report.Targets = append(report.Targets, TargetScore{Target: target, Metric: metrics[target], Score: baseline[target]})
+	}
+
+	seed := int64(0)
+	if _, _, config := engine.snapshot(); config != nil {
+		seed = config.Seed
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	for _, feature := range sortedRowKeys(inputs) {
+		losses := make(map[string]*FeatureStats, len(targets))
+		for _, target := range targets {
+			losses[target] = &FeatureStats{}
+		}
+
+		for repeat := 0; repeat < permutationRepeats; repeat++ {
+			predictions, err := engine.PredictBatch(permuteFeature(inputs, feature, rng))
+			if err != nil {
+				return nil, err
+			}
+			for _, target := range targets {
+				losses[target].Add(metrics[target].loss(baseline[target], metrics[target].score(target, predictions, outputs)))
+			}
+		}
+
+		for _, target := range targets {
+			report.Features = append(report.Features, FeatureImportance{
+				Feature:    feature,
+				Target:     target,
+				Importance: losses[target].Mean(),
+				StdDev:     losses[target].StdDev(),
+			})
+		}
+	}
+
+	sort.SliceStable(report.Features, func(i, j int) bool {
+		a, b := report.Features[i], report.Features[j]
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return a.Importance > b.Importance
+	})
+	return report, nil
+}
+
+// permuteFeature returns copies of the rows with the values of a feature shuffled between them
+// A missing value is shuffled like any other, so rows may gain or lose the feature
+func permuteFeature(inputs []map[string]interface{}, feature string, rng *rand.Rand) []map[string]interface{} {
+	order := rng.Perm(len(inputs))
+	rows := make([]map[string]interface{}, len(inputs))
+	for i, input := range inputs {
+		rows[i] = copyRow(input)
+		if val, exists := inputs[order[i]][feature]; exists {
+			rows[i][feature] = val
+		} else {
+			delete(rows[i], feature)
+		}
+	}
+	return rows
+}
+
+// resolve returns the metric used for a target, checking that it applies to the target's values
+func (m Metric) resolve(target string, outputs []map[string]interface{}) (Metric, error) {
+	categorical := false
+	for _, output := range outputs {
+		switch output[target].(type) {
+		case string, bool:
+			categorical = true
+		}
+	}
+
+	switch m {
+	case MetricAuto:
+		if categorical {
+			return MetricAccuracy, nil
+		}
+		return MetricMSE, nil
+	case MetricAccuracy:
+		return m, nil
+	case MetricMSE, MetricMAE, MetricR2:
+		for _, output := range outputs {
+			if _, isString := output[target].(string); isString {
+				return m, fmt.Errorf("metric %q needs a numeric target, %q is categorical: %w", m, target, ErrInvalidOutput)
+			}
+		}
+		return m, nil
+	default:
+		return m, fmt.Errorf("unknown metric %q: %w", m, ErrInvalidInput)
+	}
+}
+
+// higherIsBetter reports whether larger scores are better
+func (m Metric) higherIsBetter() bool {
+	return m == MetricAccuracy || m == MetricR2
+}
+
+// loss returns how much worse a score is than the baseline
+func (m Metric) loss(baseline float64, score float64) float64 {
+	if m.higherIsBetter() {
+		return baseline - score
+	}
+	return score - baseline
+}
+
+// score computes the metric of the predictions of a target over the rows that have it
+func (m Metric) score(target string, predictions []map[string]interface{}, outputs []map[string]interface{}) float64 {
+	var expected, predicted []interface{}
+	for i, output := range outputs {
+		if y, exists := output[target]; exists && !isMissing(y) {
+			expected = append(expected, y)
+			predicted = append(predicted, predictions[i][target])
+		}
+	}
+	if len(expected) == 0 {
+		return 0
+	}
+
+	if m == MetricAccuracy {
+		correct := 0
+		for i := range expected {
+			if predictedLabel(expected[i], predicted[i]) == fmt.Sprintf("%v", expected[i]) {
+				correct++
+			}
+		}
+		return float64(correct) / float64(len(expected))
+	}
+
+	targetStats := &FeatureStats{}
+	squared, absolute := 0.0, 0.0
+	for i := range expected {
+		y, _ := numericValue(expected[i])
+		prediction, _ := numericValue(predicted[i])
+		targetStats.Add(y)
+		squared += (prediction - y) * (prediction - y)
+		absolute += math.Abs(prediction - y)
+	}
+	n := float64(len(expected))
+
+	switch m {
+	case MetricMAE:
+		return absolute / n
+	case MetricR2:
+		if targetStats.M2 == 0 {
+			return 0
+		}
+		return 1 - squared/targetStats.M2
+	default:
+		return squared / n
+	}
+}
+
+// predictedLabel formats a prediction for comparison with the expected value
+// Predictions of boolean targets may be probabilities, which count as true from 0.5
+func predictedLabel(expected interface{}, prediction interface{}) string {
+	if _, isBool := expected.(bool); isBool {
+		if p, ok := numericValue(prediction); ok {
+			return fmt.Sprintf("%v", p >= 0.5)
+		}
+	}
+	return fmt.Sprintf("%v", prediction)
+}
+
+// Coefficient is one weight of a linear, logistic, categorical or mixed model
+type Coefficient struct {
+	Feature      string   `json:"feature"`
+	Target       string   `json:"target"`
+	Category     string   `json:"category,omitempty"`     // Category of a categorical target
+	Weight       float64  `json:"weight"`                 // Change of the score per unit of the feature
+	Standardized *float64 `json:"standardized,omitempty"` // Change of the score per standard deviation of the feature, when its statistics exist
+}
+
+// FeatureCoefficients groups the weights of one feature for every target and category
+type FeatureCoefficients struct {
+	Feature      string        `json:"feature"`
+	Magnitude    float64       `json:"magnitude"` // Largest absolute standardized weight, or absolute weight without statistics
+	Coefficients []Coefficient `json:"coefficients"`
+}
+
+// CoefficientReport lists the weights of a model grouped by feature
+type CoefficientReport struct {
+	Type       string                `json:"type"`
+	Features   []FeatureCoefficients `json:"features"`   // Largest magnitude first
+	Intercepts []Coefficient         `json:"intercepts"` // Bias weights, with Feature "bias"
+}
+
+// Coefficients reports the weights of a linear, logistic, categorical or mixed model
+// The weights of "feature->target" and "feature->target:category" keys are grouped by feature.
+// Weights apply to the preprocessed features, so a binned or one-hot encoded field appears as
+// its generated features. Standardized weights multiply the weight by the standard deviation
+// of the feature, which makes features with different units comparable
+func (e *Engine) Coefficients() (*CoefficientReport, error) {
+	model, weights, _ := e.snapshot()
+	if model == nil {
+		return nil, fmt.Errorf("model not initialized")
+	}
+	if weights == nil {
+		return nil, ErrModelNotTrained
+	}
+	switch model.Type {
+	case "linear", "logistic", "categorical", "mixed":
+	default:
+		return nil, fmt.Errorf("model type %q has no coefficients: %w", model.Type, ErrUnsupportedModelType)
+	}
+
+	report := &CoefficientReport{Type: model.Type}
+	groups := make(map[string]*FeatureCoefficients)
+
+	weights.mu.RLock()
+	for key := range weights.Values {
+		parts := splitWeightKey(key)
+		weight, ok := numericValue(weights.Values[key])
+		if parts[0] == "" || !ok {
+			continue
+		}
+		target, category, _ := strings.Cut(parts[1], ":")
+		coefficient := Coefficient{Feature: parts[0], Target: target, Category: category, Weight: weight}
+
+		if parts[0] == "bias" {
+			report.Intercepts = append(report.Intercepts, coefficient)
+			continue
+		}
+
+		magnitude := math.Abs(weight)
+		if stats := model.Stats[parts[0]]; stats != nil && stats.Count > 0 {
+			standardized := weight * stats.StdDev()
+			coefficient.Standardized = &standardized
+			magnitude = math.Abs(standardized)
+		}
+
+		group := groups[parts[0]]
+		if group == nil {
+			group = &FeatureCoefficients{Feature: parts[0]}
+			groups[parts[0]] = group
+		}
+		group.Coefficients = append(group.Coefficients, coefficient)
+		group.Magnitude = math.Max(group.Magnitude, magnitude)
+	}
+	weights.mu.RUnlock()
+
+	for _, group := range groups {
+		sortCoefficients(group.Coefficients)
+		report.Features = append(report.Features, *group)
+	}
+	sort.Slice(report.Features, func(i, j int) bool {
+		if report.Features[i].Magnitude != report.Features[j].Magnitude {
+			return report.Features[i].Magnitude > report.Features[j].Magnitude
+		}
+		return report.Features[i].Feature < report.Features[j].Feature
+	})
+	sortCoefficients(report.Intercepts)
+	return report, nil
+}
+
+// sortCoefficients orders coefficients by target and category
+func sortCoefficients(coefficients []Coefficient) {
+	sort.Slice(coefficients, func(i, j int) bool {
+		if coefficients[i].Target != coefficients[j].Target {
+			return coefficients[i].Target < coefficients[j].Target
+		}
+		return coefficients[i].Category < coefficients[j].Category
+	})
+}
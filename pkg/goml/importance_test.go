@@ -0,0 +1,148 @@
+package goml
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// importanceRows returns rows where y depends on "a" only, while "b" varies independently
+func importanceRows() ([]map[string]interface{}, []map[string]interface{}) {
+	var inputs, outputs []map[string]interface{}
+	for i := 0; i < 30; i++ {
+		a := float64(i%10 + 1)
+		b := float64((i*7)%11 + 1)
+		label := "small"
+		if a > 5 {
+			label = "large"
+		}
+		inputs = append(inputs, map[string]interface{}{"a": a, "b": b})
+		outputs = append(outputs, map[string]interface{}{"y": 3 * a, "label": label})
+	}
+	return inputs, outputs
+}
+
+// TestImportance tests that the feature a model relies on gets the largest importance
+func TestImportance(t *testing.T) {
+	inputs, outputs := importanceRows()
+	numeric := make([]map[string]interface{}, len(outputs))
+	for i := range outputs {
+		numeric[i] = map[string]interface{}{"y": outputs[i]["y"]}
+	}
+
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 500, BatchSize: 10})
+	if err := engine.Train(inputs, numeric); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	for _, metric := range []Metric{MetricAuto, MetricMAE, MetricR2} {
+		report, err := Importance(engine, inputs, numeric, metric)
+		if err != nil {
+			t.Fatalf("Importance with %q failed: %v", metric, err)
+		}
+		if len(report.Features) != 2 || report.Features[0].Feature != "a" {
+			t.Fatalf("Expected a to be the most important feature with %q, got %+v", metric, report.Features)
+		}
+		if a, b := report.Features[0].Importance, report.Features[1].Importance; a <= 10*math.Abs(b) {
+			t.Errorf("Expected a to matter far more than b with %q, got %v and %v", metric, a, b)
+		}
+		if metric == MetricAuto && report.Targets[0].Metric != MetricMSE {
+			t.Errorf("Expected mean squared error for a numeric target, got %q", report.Targets[0].Metric)
+		}
+	}
+
+	again, _ := Importance(engine, inputs, numeric, MetricMSE)
+	report, _ := Importance(engine, inputs, numeric, MetricMSE)
+	if again.Features[0] != report.Features[0] {
+		t.Errorf("Expected reproducible importances, got %+v and %+v", again.Features[0], report.Features[0])
+	}
+}
+
+// TestImportanceCategorical tests accuracy-based importance and metric validation
+func TestImportanceCategorical(t *testing.T) {
+	inputs, outputs := importanceRows()
+	labels := make([]map[string]interface{}, len(outputs))
+	for i := range outputs {
+		labels[i] = map[string]interface{}{"label": outputs[i]["label"]}
+	}
+
+	engine := New()
+	engine.WithModel(NewCategoricalModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 200, BatchSize: 10})
+	if err := engine.Train(inputs, labels); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	report, err := Importance(engine, inputs, labels, MetricAuto)
+	if err != nil {
+		t.Fatalf("Importance failed: %v", err)
+	}
+	if report.Targets[0].Metric != MetricAccuracy || report.Targets[0].Score < 0.7 {
+		t.Errorf("Expected an accurate baseline, got %+v", report.Targets)
+	}
+	if report.Features[0].Feature != "a" || report.Features[0].Importance <= 0 {
+		t.Errorf("Expected a to be the most important feature, got %+v", report.Features)
+	}
+
+	if _, err := Importance(engine, inputs, labels, MetricMSE); !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Expected ErrInvalidOutput for mean squared error of labels, got %v", err)
+	}
+	if _, err := Importance(engine, inputs, labels, "auc"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for an unknown metric, got %v", err)
+	}
+}
+
+// TestCoefficients tests grouping of weights and standardized coefficients
+func TestCoefficients(t *testing.T) {
+	inputs, outputs := importanceRows()
+
+	engine := New()
+	engine.WithModel(NewMixedModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 50, BatchSize: 10})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	report, err := engine.Coefficients()
+	if err != nil {
+		t.Fatalf("Coefficient report failed: %v", err)
+	}
+	model, weights, _ := engine.snapshot()
+	if len(report.Features) != 2 {
+		t.Fatalf("Expected coefficients of a and b, got %+v", report.Features)
+	}
+
+	for _, group := range report.Features {
+		hasCategory := false
+		for _, coefficient := range group.Coefficients {
+			key := group.Feature + "->" + coefficient.Target
+			if coefficient.Category != "" {
+				key += ":" + coefficient.Category
+				hasCategory = true
+			}
+			weight, _ := weights.GetFloat(key)
+			if coefficient.Weight != weight {
+				t.Errorf("Expected weight %v for %s, got %v", weight, key, coefficient.Weight)
+			}
+			standardized := weight * model.Stats[group.Feature].StdDev()
+			if coefficient.Standardized == nil || math.Abs(*coefficient.Standardized-standardized) > 1e-12 {
+				t.Errorf("Expected standardized weight %v for %s, got %v", standardized, key, coefficient.Standardized)
+			}
+		}
+		if !hasCategory {
+			t.Errorf("Expected coefficients for the label categories of %s", group.Feature)
+		}
+	}
+	if len(report.Intercepts) == 0 || report.Intercepts[0].Feature != "bias" {
+		t.Errorf("Expected the bias weights as intercepts, got %+v", report.Intercepts)
+	}
+
+	pca := New()
+	pca.WithModel(NewPCAModel(PCA{}).JSON())
+	pca.Train(inputs, nil)
+	if _, err := pca.Coefficients(); !errors.Is(err, ErrUnsupportedModelType) {
+		t.Errorf("Expected ErrUnsupportedModelType for a PCA model, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package goml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ImputeStrategy selects how missing values of a feature are filled
+type ImputeStrategy string
+
+const (
+	ImputeNone         ImputeStrategy = ""              // Leave missing values missing
+	ImputeMean         ImputeStrategy = "mean"          // Mean of the numeric training values
+	ImputeMedian       ImputeStrategy = "median"        // Median of the numeric training values
+	ImputeMostFrequent ImputeStrategy = "most_frequent" // Most common training value of any type
+	ImputeConstant     ImputeStrategy = "constant"      // A fixed value
+)
+
+// MissingIndicatorSuffix is appended to a feature name to name its missing value indicator
+const MissingIndicatorSuffix = "_is_missing"
+
+// Imputer fills missing values of a feature
+// A value is missing when its key is absent, it is nil, or it is a NaN float
+type Imputer struct {
+	Strategy  ImputeStrategy `json:"strategy,omitempty"`
+	Value     interface{}    `json:"value,omitempty"`     // Fill value: set for ImputeConstant, learned by Train otherwise
+	Indicator bool           `json:"indicator,omitempty"` // Add a <feature>_is_missing feature that is 1 when the value was missing
+}
+
+// WithImputer configures how missing values of one or more features are filled
+// The fill values are learned when the model is trained and saved with the model
+func (m *Model) WithImputer(imputer Imputer, features ...string) *Model {
+	for _, feature := range features {
+		spec := m.featureSpec(feature)
+		imputerCopy := imputer
+		spec.Impute = &imputerCopy
+	}
+	return m
+}
+
+// validate checks the imputer configuration
+func (i *Imputer) validate(feature string) error {
+	switch i.Strategy {
+	case ImputeNone, ImputeMean, ImputeMedian, ImputeMostFrequent:
+		return nil
+	case ImputeConstant:
+		if i.Value == nil {
+			return fmt.Errorf("feature %q: constant imputation requires a value: %w", feature, ErrInvalidInput)
+		}
+		return nil
+	default:
+		return fmt.Errorf("feature %q: unknown imputation strategy %q: %w", feature, i.Strategy, ErrInvalidInput)
+	}
+}
+
+// apply fills a missing value and sets the indicator in the row
+func (i *Imputer) apply(feature string, row map[string]interface{}) {
+	missing := isMissing(row[feature])
+
+	if i.Indicator {
+		if missing {
+			row[feature+MissingIndicatorSuffix] = 1.0
+		} else {
+			row[feature+MissingIndicatorSuffix] = 0.0
+		}
+	}
+
+	if !missing {
+		return
+	}
+
+	if i.Strategy == ImputeNone || i.Value == nil {
+		// Nothing to fill with: drop nil and NaN values so the models skip them consistently
+		delete(row, feature)
+		return
+	}
+	row[feature] = i.Value
+}
+
+// isMissing reports whether a feature value counts as missing
+func isMissing(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case float64:
+		return math.IsNaN(v)
+	case float32:
+		return math.IsNaN(float64(v))
+	default:
+		return false
+	}
+}
+
+// imputeObservation collects the training values an imputer learns from
+type imputeObservation struct {
+	sum     float64
+	count   int
+	numbers []float64              // Kept for the median only
+	counts  map[string]int         // Kept for the most frequent value only
+	values  map[string]interface{} // Original value per counts key
+}
+
+// observe records one training value
+func (o *imputeObservation) observe(strategy ImputeStrategy, val interface{}) {
+	if isMissing(val) {
+		return
+	}
+
+	switch strategy {
+	case ImputeMean, ImputeMedian:
+		if !IsSupportedNumericType(val) {
+			return
+		}
+		x, _ := ConvertToFloat64(val, "")
+		o.sum += x
+		o.count++
+		if strategy == ImputeMedian {
+			o.numbers = append(o.numbers, x)
+		}
+	case ImputeMostFrequent:
+		if o.counts == nil {
+			o.counts = make(map[string]int)
+			o.values = make(map[string]interface{})
+		}
+		key := fmt.Sprintf("%T:%v", val, val)
+		o.counts[key]++
+		o.values[key] = val
+	}
+}
+
+// fill returns the learned fill value, or nil if no training value could be used
+func (o *imputeObservation) fill(strategy ImputeStrategy) interface{} {
+	switch strategy {
+	case ImputeMean:
+		if o.count == 0 {
+			return nil
+		}
+		return o.sum / float64(o.count)
+	case ImputeMedian:
+		if len(o.numbers) == 0 {
+			return nil
+		}
+		sort.Float64s(o.numbers)
+		mid := len(o.numbers) / 2
+		if len(o.numbers)%2 == 0 {
+			return (o.numbers[mid-1] + o.numbers[mid]) / 2
+		}
+		return o.numbers[mid]
+	case ImputeMostFrequent:
+		// Ties go to the lexically smallest value so the result is reproducible
+		bestKey := ""
+		bestCount := 0
+		for key, count := range o.counts {
+			if count > bestCount || (count == bestCount && key < bestKey) {
+				bestKey = key
+				bestCount = count
+			}
+		}
+		if bestCount == 0 {
+			return nil
+		}
+		return o.values[bestKey]
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,153 @@
+package goml
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+// imputeRows returns training rows where some features are absent, nil or NaN
+func imputeRows() ([]map[string]interface{}, []map[string]interface{}) {
+	inputs := []map[string]interface{}{
+		{"rooms": 2, "city": "riga"},
+		{"size": 1.0, "rooms": nil, "city": "riga"},
+		{"size": 2.0, "rooms": 3, "city": "tallinn"},
+		{"size": math.NaN(), "rooms": 4, "city": nil},
+		{"size": 6.0, "rooms": 3, "city": "riga"},
+	}
+	outputs := []map[string]interface{}{
+		{"price": 4.0}, {"price": 2.0}, {"price": 4.0}, {"price": 6.0}, {"price": 12.0},
+	}
+	return inputs, outputs
+}
+
+// TestImputeStrategies tests that fill values are learned during training and saved with the model
+func TestImputeStrategies(t *testing.T) {
+	model := NewLinearModel().
+		WithImputer(Imputer{Strategy: ImputeMean, Indicator: true}, "size").
+		WithImputer(Imputer{Strategy: ImputeMedian}, "rooms").
+		WithImputer(Imputer{Strategy: ImputeMostFrequent}, "city")
+
+	engine := New()
+	engine.WithModel(model.JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 20, BatchSize: 5})
+
+	inputs, outputs := imputeRows()
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	modelJSON, _ := engine.GetModel()
+	var trained Model
+	if err := json.Unmarshal([]byte(*modelJSON), &trained); err != nil {
+		t.Fatalf("Failed to load model: %v", err)
+	}
+
+	if fill := trained.Features["size"].Impute.Value; fill != 3.0 {
+		t.Errorf("Expected mean 3 for size, got %v", fill)
+	}
+	if fill := trained.Features["rooms"].Impute.Value; fill != 3.0 {
+		t.Errorf("Expected median 3 for rooms, got %v", fill)
+	}
+	if fill := trained.Features["city"].Impute.Value; fill != "riga" {
+		t.Errorf("Expected most frequent city riga, got %v", fill)
+	}
+
+	// The indicator becomes a feature, and size is learned although the first row lacks it
+	_, weights, _ := engine.snapshot()
+	for _, key := range []string{"size_is_missing->price", "size->price", "rooms->price"} {
+		if _, exists := weights.Get(key); !exists {
+			t.Errorf("Expected weight %s", key)
+		}
+	}
+
+	// A missing value predicts like the fill value, apart from the indicator's contribution
+	indicator, _ := weights.GetFloat("size_is_missing->price")
+	missing, err := engine.Predict(map[string]interface{}{"rooms": 3, "city": "riga"})
+	if err != nil {
+		t.Fatalf("Prediction with a missing value failed: %v", err)
+	}
+	filled, err := engine.Predict(map[string]interface{}{"size": 3.0, "rooms": 3, "city": "riga"})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if diff := missing["price"].(float64) - filled["price"].(float64) - indicator; math.Abs(diff) > 1e-9 {
+		t.Errorf("Expected the missing size to be filled with the mean, predictions differ by %v", diff)
+	}
+}
+
+// TestImputeConstant tests constant imputation and that Predict does not modify the input
+func TestImputeConstant(t *testing.T) {
+	model := NewCategoricalModel().WithImputer(Imputer{Strategy: ImputeConstant, Value: 0.0}, "x")
+
+	engine := New()
+	engine.WithModel(model.JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 50, BatchSize: 4})
+
+	err := engine.Train(
+		[]map[string]interface{}{{"x": 0.0}, {"x": nil}, {"x": 5.0}, {"x": 6.0}},
+		[]map[string]interface{}{{"size": "small"}, {"size": "small"}, {"size": "large"}, {"size": "large"}},
+	)
+	if err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	model, _, _ = engine.snapshot()
+	if model.Features["x"].Impute.Value != 0.0 {
+		t.Errorf("Expected the constant to be kept, got %v", model.Features["x"].Impute.Value)
+	}
+	if model.Schema.Inputs["x"].Required {
+		t.Error("Expected imputed features to be optional in the schema")
+	}
+
+	input := map[string]interface{}{"x": nil}
+	prediction, err := engine.Predict(input)
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if prediction["size"] != "small" {
+		t.Errorf("Expected the constant 0 to predict small, got %v", prediction["size"])
+	}
+	if input["x"] != nil || len(input) != 1 {
+		t.Errorf("Predict modified the caller's input: %v", input)
+	}
+}
+
+// TestImputeTrainStream tests that streaming training learns the same fill values
+func TestImputeTrainStream(t *testing.T) {
+	model := NewLinearModel().WithImputer(Imputer{Strategy: ImputeMedian, Indicator: true}, "size")
+
+	engine := New()
+	engine.WithModel(model.JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 5, BatchSize: 2})
+
+	inputs, outputs := imputeRows()
+	if err := engine.TrainStream(NewSliceDataset(inputs, outputs)); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+
+	trained, _, _ := engine.snapshot()
+	if fill := trained.Features["size"].Impute.Value; fill != 2.0 {
+		t.Errorf("Expected median 2 for size, got %v", fill)
+	}
+	if stats := trained.Stats["size"]; stats == nil || stats.Count != 5 {
+		t.Errorf("Expected statistics of the imputed feature over every row, got %+v", stats)
+	}
+	if stats := trained.Stats["size_is_missing"]; stats == nil || stats.Sum != 2 {
+		t.Errorf("Expected statistics of the indicator, got %+v", stats)
+	}
+}
+
+// TestImputeInvalidConfiguration tests rejection of unknown strategies and constants without a value
+func TestImputeInvalidConfiguration(t *testing.T) {
+	for _, imputer := range []Imputer{{Strategy: "mode"}, {Strategy: ImputeConstant}} {
+		engine := New()
+		engine.WithModel(NewLinearModel().WithImputer(imputer, "x").JSON())
+
+		err := engine.Train([]map[string]interface{}{{"x": 1.0}}, []map[string]interface{}{{"y": 1.0}})
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for %+v, got %v", imputer, err)
+		}
+	}
+}
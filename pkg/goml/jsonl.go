@@ -0,0 +1,202 @@
+package goml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSONLReader reads JSON Lines records into Engine-ready input and output rows
+// Fields are selected with dotted paths such as "device.os", which also name the row keys
+// It implements Dataset; Reset requires the source to implement io.Seeker
+type JSONLReader struct {
+	InputPaths  []string // Paths read as inputs; empty selects every top-level field that is not an output
+	OutputPaths []string // Paths read as outputs
+
+	lines *lineSource
+}
+
+// NewJSONLReader creates a JSON Lines reader over a source
+func NewJSONLReader(source io.Reader, inputPaths []string, outputPaths []string) *JSONLReader {
+	return &JSONLReader{
+		InputPaths:  inputPaths,
+		OutputPaths: outputPaths,
+		lines:       newLineSource(source),
+	}
+}
+
+// Next returns the input and output maps of the next record, or io.EOF at the end
+// Paths missing from a record are left out of its maps
+func (r *JSONLReader) Next() (map[string]interface{}, map[string]interface{}, error) {
+	line, err := r.lines.next()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	record, err := decodeJSONRecord(line)
+	if err != nil {
+		return nil, nil, fmt.Errorf("line %d: %w", r.lines.line, err)
+	}
+
+	output := make(map[string]interface{})
+	for _, path := range r.OutputPaths {
+		if val, ok := lookupPath(record, path); ok {
+			output[path] = val
+		}
+	}
+
+	input := make(map[string]interface{})
+	if len(r.InputPaths) == 0 {
+		for key, val := range record {
+			if !containsString(r.OutputPaths, key) {
+				input[key] = val
+			}
+		}
+	} else {
+		for _, path := range r.InputPaths {
+			if val, ok := lookupPath(record, path); ok {
+				input[path] = val
+			}
+		}
+	}
+
+	return input, output, nil
+}
+
+// Reset rewinds the reader to the first record
+func (r *JSONLReader) Reset() error {
+	return r.lines.reset()
+}
+
+// ReadAll reads the remaining records into input and output slices
+func (r *JSONLReader) ReadAll() ([]map[string]interface{}, []map[string]interface{}, error) {
+	var inputs, outputs []map[string]interface{}
+	for {
+		input, output, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return inputs, outputs, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		inputs = append(inputs, input)
+		outputs = append(outputs, output)
+	}
+}
+
+// JSONLWriter writes records such as predictions as JSON Lines
+type JSONLWriter struct {
+	writer *bufio.Writer
+}
+
+// NewJSONLWriter creates a JSON Lines writer; call Flush when done
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{writer: bufio.NewWriter(w)}
+}
+
+// Write writes a record as one line
+// Dotted keys are expanded back into nested objects, so rows read with paths round-trip
+func (w *JSONLWriter) Write(record map[string]interface{}) error {
+	bytes, err := json.Marshal(nestPaths(record))
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	if _, err := w.writer.Write(bytes); err != nil {
+		return err
+	}
+	return w.writer.WriteByte('\n')
+}
+
+// Flush writes any buffered records to the underlying writer
+func (w *JSONLWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+// decodeJSONRecord decodes a JSON object, loading integral numbers as int and others as float64
+func decodeJSONRecord(line []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+
+	var record map[string]interface{}
+	if err := decoder.Decode(&record); err != nil {
+		return nil, fmt.Errorf("invalid JSON record: %w", err)
+	}
+	return convertJSONNumbers(record).(map[string]interface{}), nil
+}
+
+// convertJSONNumbers replaces json.Number values with int or float64
+func convertJSONNumbers(val interface{}) interface{} {
+	switch v := val.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil && int64(int(i)) == i {
+			return int(i)
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = convertJSONNumbers(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = convertJSONNumbers(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// lookupPath returns the value at a dotted path in a nested record
+// A key that itself contains dots is matched before descending into nested objects
+func lookupPath(record map[string]interface{}, path string) (interface{}, bool) {
+	if val, ok := record[path]; ok {
+		return val, val != nil
+	}
+
+	head, rest, found := strings.Cut(path, ".")
+	if !found {
+		return nil, false
+	}
+
+	nested, ok := record[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupPath(nested, rest)
+}
+
+// nestPaths expands dotted keys into nested objects
+func nestPaths(record map[string]interface{}) map[string]interface{} {
+	nested := make(map[string]interface{})
+	for key, val := range record {
+		parts := strings.Split(key, ".")
+		current := nested
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := current[part].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				current[part] = child
+			}
+			current = child
+		}
+		current[parts[len(parts)-1]] = val
+	}
+	return nested
+}
+
+// containsString reports whether a slice contains a string
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
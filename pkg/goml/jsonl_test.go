@@ -0,0 +1,136 @@
+package goml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestJSONLReaderPaths tests selecting nested input and output fields with dotted paths
+func TestJSONLReaderPaths(t *testing.T) {
+	data := `{"user": {"age": 31, "premium": true}, "device": {"os": "ios"}, "amount": 12.5, "label": "buy"}
+
+{"user": {"age": 44}, "device": {"os": "android"}, "amount": 3, "label": "skip"}
+`
+	reader := NewJSONLReader(strings.NewReader(data), []string{"user.age", "user.premium", "device.os"}, []string{"label"})
+	inputs, outputs, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+
+	if len(inputs) != 2 || len(outputs) != 2 {
+		t.Fatalf("Expected 2 rows, got %d inputs and %d outputs", len(inputs), len(outputs))
+	}
+	if inputs[0]["user.age"] != 31 || inputs[0]["user.premium"] != true || inputs[0]["device.os"] != "ios" {
+		t.Errorf("Unexpected first input: %v", inputs[0])
+	}
+	if _, exists := inputs[1]["user.premium"]; exists {
+		t.Errorf("Missing paths should be left out, got %v", inputs[1])
+	}
+	if _, exists := inputs[0]["amount"]; exists {
+		t.Errorf("Unselected fields should be left out, got %v", inputs[0])
+	}
+	if outputs[1]["label"] != "skip" {
+		t.Errorf("Unexpected second output: %v", outputs[1])
+	}
+}
+
+// TestJSONLReaderDefaultInputs tests that all non-output top-level fields are inputs by default
+func TestJSONLReaderDefaultInputs(t *testing.T) {
+	reader := NewJSONLReader(strings.NewReader(`{"x": 2, "z": 0.5, "y": 7}`), nil, []string{"y"})
+	input, output, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+
+	if len(input) != 2 || input["x"] != 2 || input["z"] != 0.5 {
+		t.Errorf("Unexpected input: %v", input)
+	}
+	if output["y"] != 7 {
+		t.Errorf("Unexpected output: %v", output)
+	}
+
+	if _, _, err := reader.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+// TestJSONLReaderErrors tests invalid lines and rewinding sources that cannot seek
+func TestJSONLReaderErrors(t *testing.T) {
+	reader := NewJSONLReader(strings.NewReader("{\"x\": 1}\nnot json\n"), nil, nil)
+	if _, _, err := reader.ReadAll(); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error for line 2, got %v", err)
+	}
+
+	unseekable := NewJSONLReader(bytes.NewBufferString("{\"x\": 1}\n"), nil, nil)
+	if err := unseekable.Reset(); err != nil {
+		t.Errorf("Reset before reading should succeed, got %v", err)
+	}
+	unseekable.Next()
+	if err := unseekable.Reset(); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput rewinding an unseekable source, got %v", err)
+	}
+}
+
+// TestJSONLWriterRoundTrip tests that written predictions read back under the same paths
+func TestJSONLWriterRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewJSONLWriter(&buf)
+	records := []map[string]interface{}{
+		{"id": 1, "prediction.label": "buy", "prediction.score": 0.75},
+		{"id": 2, "prediction.label": "skip", "prediction.score": 0.25},
+	}
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %s", lines, buf.String())
+	}
+	if !strings.Contains(buf.String(), `"prediction":{"label":"buy","score":0.75}`) {
+		t.Errorf("Expected dotted keys to be nested, got %s", buf.String())
+	}
+
+	reader := NewJSONLReader(bytes.NewReader(buf.Bytes()), []string{"id"}, []string{"prediction.label", "prediction.score"})
+	inputs, outputs, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if inputs[1]["id"] != 2 || outputs[1]["prediction.label"] != "skip" || outputs[1]["prediction.score"] != 0.25 {
+		t.Errorf("Round trip mismatch: %v %v", inputs[1], outputs[1])
+	}
+}
+
+// TestTrainStreamJSONL tests streaming training directly from a seekable JSON Lines source
+func TestTrainStreamJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewJSONLWriter(&buf)
+	for i := 1; i <= 8; i++ {
+		writer.Write(map[string]interface{}{"features.x": float64(i), "y": 2.0*float64(i) + 1})
+	}
+	writer.Flush()
+
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 300, BatchSize: 4})
+
+	reader := NewJSONLReader(bytes.NewReader(buf.Bytes()), []string{"features.x"}, []string{"y"})
+	if err := engine.TrainStream(reader); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+
+	prediction, err := engine.Predict(map[string]interface{}{"features.x": 4.0})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if y := prediction["y"].(float64); y < 7 || y > 11 {
+		t.Errorf("Prediction outside expected range: %v", y)
+	}
+}
@@ -0,0 +1,279 @@
+package goml
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LIBSVMReader reads sparse LIBSVM lines ("label index:value ...") into input and output rows
+// It implements Dataset; Reset requires the source to implement io.Seeker
+type LIBSVMReader struct {
+	FeatureNames map[int]string // Feature name per index; unmapped indices are named "f<index>"
+	Target       string         // Output key the label is stored under
+
+	lines    *lineSource
+	scanned  bool
+	features []string // Features of every line, collected by a first pass over seekable sources
+}
+
+// NewLIBSVMReader creates a LIBSVM reader over a source
+func NewLIBSVMReader(source io.Reader, featureNames map[int]string, target string) *LIBSVMReader {
+	return &LIBSVMReader{
+		FeatureNames: featureNames,
+		Target:       target,
+		lines:        newLineSource(source),
+	}
+}
+
+// Next returns the input and output maps of the next line, or io.EOF at the end
+// Features absent from a sparse line are filled with 0 so every row has the same keys: the
+// mapped features, and on seekable sources every feature of the file, which a first pass
+// collects before the first line is returned. Sources that cannot be rewound only fill the
+// mapped features; ReadAll fills every feature either way
+func (r *LIBSVMReader) Next() (map[string]interface{}, map[string]interface{}, error) {
+	if !r.scanned {
+		if err := r.scanFeatures(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for {
+		line, err := r.lines.next()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fields := libsvmFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		input, output, err := r.parseFields(fields)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", r.lines.line, err)
+		}
+		return input, output, nil
+	}
+}
+
+// libsvmFields splits a line into its label and index:value pairs, without trailing comments
+func libsvmFields(line []byte) []string {
+	if idx := bytes.IndexByte(line, '#'); idx >= 0 {
+		line = line[:idx]
+	}
+	return strings.Fields(string(line))
+}
+
+// scanFeatures collects the features of every line of a seekable source that has not been
+// read yet, so that a feature first seen on a later line is filled with 0 on earlier ones
+func (r *LIBSVMReader) scanFeatures() error {
+	r.scanned = true
+	if _, seekable := r.lines.source.(io.Seeker); !seekable || r.lines.line > 0 {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	for {
+		line, err := r.lines.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		fields := libsvmFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		input, _, err := r.parseFields(fields)
+		if err != nil {
+			// Reported with its line number when the line is read
+			break
+		}
+		for name := range input {
+			names[name] = true
+		}
+	}
+
+	r.features = make([]string, 0, len(names))
+	for name := range names {
+		r.features = append(r.features, name)
+	}
+	sort.Strings(r.features)
+	return r.lines.reset()
+}
+
+// parseFields converts the label and index:value pairs of one line
+func (r *LIBSVMReader) parseFields(fields []string) (map[string]interface{}, map[string]interface{}, error) {
+	label, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid label %q: %w", fields[0], ErrInvalidInput)
+	}
+
+	output := make(map[string]interface{})
+	if label == math.Trunc(label) {
+		output[r.Target] = int(label)
+	} else {
+		output[r.Target] = label
+	}
+
+	input := make(map[string]interface{})
+	for _, name := range r.FeatureNames {
+		input[name] = 0.0
+	}
+	for _, name := range r.features {
+		input[name] = 0.0
+	}
+
+	for _, field := range fields[1:] {
+		key, raw, found := strings.Cut(field, ":")
+		if !found {
+			return nil, nil, fmt.Errorf("invalid feature %q: %w", field, ErrInvalidInput)
+		}
+
+		// Query ids are used by ranking tasks and carry no feature value
+		if key == "qid" {
+			continue
+		}
+
+		index, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid feature index %q: %w", key, ErrInvalidInput)
+		}
+		val, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid value for feature %d: %w", index, ErrInvalidInput)
+		}
+
+		input[r.featureName(index)] = val
+	}
+
+	return input, output, nil
+}
+
+// featureName returns the name mapped to an index
+func (r *LIBSVMReader) featureName(index int) string {
+	if name, ok := r.FeatureNames[index]; ok {
+		return name
+	}
+	return fmt.Sprintf("f%d", index)
+}
+
+// Reset rewinds the reader to the first line
+func (r *LIBSVMReader) Reset() error {
+	return r.lines.reset()
+}
+
+// ReadAll reads the remaining lines into input and output slices
+// Every row has every feature of the lines read, absent ones filled with 0
+func (r *LIBSVMReader) ReadAll() ([]map[string]interface{}, []map[string]interface{}, error) {
+	var inputs, outputs []map[string]interface{}
+	for {
+		input, output, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			fillAbsentFeatures(inputs)
+			return inputs, outputs, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		inputs = append(inputs, input)
+		outputs = append(outputs, output)
+	}
+}
+
+// fillAbsentFeatures sets the features some rows lack to 0, for sources that cannot be
+// scanned before they are read
+func fillAbsentFeatures(inputs []map[string]interface{}) {
+	names := make(map[string]bool)
+	for _, input := range inputs {
+		for name := range input {
+			names[name] = true
+		}
+	}
+	for _, input := range inputs {
+		for name := range names {
+			if _, exists := input[name]; !exists {
+				input[name] = 0.0
+			}
+		}
+	}
+}
+
+// LIBSVMWriter writes rows and predictions as sparse LIBSVM lines
+type LIBSVMWriter struct {
+	FeatureIndex map[string]int // Index per feature name; features without an index are skipped
+	Target       string         // Output key written as the label
+
+	writer *bufio.Writer
+}
+
+// NewLIBSVMWriter creates a LIBSVM writer; call Flush when done
+func NewLIBSVMWriter(w io.Writer, featureIndex map[string]int, target string) *LIBSVMWriter {
+	return &LIBSVMWriter{
+		FeatureIndex: featureIndex,
+		Target:       target,
+		writer:       bufio.NewWriter(w),
+	}
+}
+
+// Write writes one line from an input row and an output such as a prediction
+// Features are written in index order and zero values are left out
+func (w *LIBSVMWriter) Write(input map[string]interface{}, output map[string]interface{}) error {
+	label, ok := output[w.Target]
+	if !ok {
+		return fmt.Errorf("output has no value for target %q: %w", w.Target, ErrInvalidOutput)
+	}
+
+	// Boolean labels are written as 1/0
+	if !IsSupportedNumericType(label) && !IsSupportedBooleanType(label) {
+		return fmt.Errorf("label for target %q must be numeric or boolean: %w", w.Target, ErrInvalidOutput)
+	}
+	labelValue, _ := ConvertToFloat64(label, "")
+
+	type entry struct {
+		index int
+		value float64
+	}
+	var entries []entry
+	for name, val := range input {
+		index, ok := w.FeatureIndex[name]
+		if !ok {
+			continue
+		}
+
+		if !IsSupportedNumericType(val) && !IsSupportedBooleanType(val) {
+			return fmt.Errorf("feature %q must be numeric or boolean: %w", name, ErrInvalidInput)
+		}
+		value, _ := ConvertToFloat64(val, "")
+
+		// Sparse format: zeros are implicit
+		if value != 0 {
+			entries = append(entries, entry{index, value})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].index < entries[j].index })
+
+	var line strings.Builder
+	line.WriteString(strconv.FormatFloat(labelValue, 'g', -1, 64))
+	for _, e := range entries {
+		fmt.Fprintf(&line, " %d:%s", e.index, strconv.FormatFloat(e.value, 'g', -1, 64))
+	}
+	line.WriteByte('\n')
+
+	_, err := w.writer.WriteString(line.String())
+	return err
+}
+
+// Flush writes any buffered lines to the underlying writer
+func (w *LIBSVMWriter) Flush() error {
+	return w.writer.Flush()
+}
@@ -0,0 +1,120 @@
+package goml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestLIBSVMReader tests reading sparse lines with an index-to-name mapping
+func TestLIBSVMReader(t *testing.T) {
+	data := `# sparse export
+1 1:0.5 3:2 qid:7
+0 2:1.5 9:4 # trailing comment
+
+-1.5 1:1
+`
+	names := map[int]string{1: "size", 2: "rooms", 3: "age"}
+	reader := NewLIBSVMReader(strings.NewReader(data), names, "label")
+	inputs, outputs, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+
+	if len(inputs) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(inputs))
+	}
+	if inputs[0]["size"] != 0.5 || inputs[0]["age"] != 2.0 || inputs[0]["rooms"] != 0.0 {
+		t.Errorf("Unexpected first input: %v", inputs[0])
+	}
+	if inputs[1]["f9"] != 4.0 || inputs[1]["size"] != 0.0 {
+		t.Errorf("Expected unmapped index f9 and zero-filled features, got %v", inputs[1])
+	}
+	if inputs[0]["f9"] != 0.0 || inputs[2]["f9"] != 0.0 {
+		t.Errorf("Expected the unmapped index f9 to be zero-filled on every line, got %v", inputs)
+	}
+	if outputs[0]["label"] != 1 || outputs[1]["label"] != 0 || outputs[2]["label"] != -1.5 {
+		t.Errorf("Unexpected labels: %v", outputs)
+	}
+}
+
+// TestLIBSVMReaderSchema tests that features first seen on later lines pass schema validation
+func TestLIBSVMReaderSchema(t *testing.T) {
+	data := "1 1:1\n2 1:2\n3 1:3 2:1\n4 1:4 2:2\n"
+
+	// A stream that cannot be rewound is filled by ReadAll
+	inputs, outputs, err := NewLIBSVMReader(struct{ io.Reader }{strings.NewReader(data)}, nil, "y").ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if inputs[0]["f2"] != 0.0 {
+		t.Errorf("Expected f2 to be zero-filled, got %v", inputs[0])
+	}
+
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 5, BatchSize: 2, StrictSchema: true})
+	if err := engine.TrainStream(NewLIBSVMReader(strings.NewReader(data), nil, "y")); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+	model, _, _ := engine.snapshot()
+	if field := model.Schema.Inputs["f2"]; field == nil || !field.Required {
+		t.Errorf("Expected f2 on every streamed row, got %+v", field)
+	}
+	for i, input := range inputs {
+		if _, err := engine.Predict(input); err != nil {
+			t.Errorf("Row %d failed validation: %v", i, err)
+		}
+	}
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Errorf("Training failed: %v", err)
+	}
+}
+
+// TestLIBSVMReaderErrors tests malformed lines
+func TestLIBSVMReaderErrors(t *testing.T) {
+	for _, line := range []string{"abc 1:1", "1 1", "1 x:1", "1 1:abc"} {
+		_, _, err := NewLIBSVMReader(strings.NewReader(line), nil, "y").Next()
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for %q, got %v", line, err)
+		}
+	}
+}
+
+// TestLIBSVMWriterRoundTrip tests writing predictions and reading them back
+func TestLIBSVMWriterRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	index := map[string]int{"size": 1, "rooms": 2, "urban": 3}
+	writer := NewLIBSVMWriter(&buf, index, "price")
+
+	if err := writer.Write(map[string]interface{}{"rooms": 3, "size": 1.5, "urban": true, "note": "x"}, map[string]interface{}{"price": 250.5}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := writer.Write(map[string]interface{}{"size": 0.0, "rooms": 1}, map[string]interface{}{"price": false}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	writer.Flush()
+
+	expected := "250.5 1:1.5 2:3 3:1\n0 2:1\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	names := map[int]string{1: "size", 2: "rooms", 3: "urban"}
+	inputs, outputs, err := NewLIBSVMReader(bytes.NewReader(buf.Bytes()), names, "price").ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if inputs[0]["rooms"] != 3.0 || inputs[1]["size"] != 0.0 || outputs[0]["price"] != 250.5 {
+		t.Errorf("Round trip mismatch: %v %v", inputs, outputs)
+	}
+
+	if err := writer.Write(map[string]interface{}{}, map[string]interface{}{"price": "high"}); !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Expected ErrInvalidOutput for a string label, got %v", err)
+	}
+	if err := writer.Write(map[string]interface{}{}, map[string]interface{}{}); !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Expected ErrInvalidOutput for a missing label, got %v", err)
+	}
+}
@@ -6,27 +6,21 @@ import (
 )
 
 // trainLinearModel implements linear regression training
-func trainLinearModel(inputs []map[string]interface{}, outputs []map[string]interface{}, weights *Weights, config *Config) error {
-	// Get feature names from the first input
+func trainLinearModel(inputs []map[string]interface{}, outputs []map[string]interface{}, weights *Weights, config *Config, model *Model) error {
+	// Get feature names from the inputs
 	if len(inputs) == 0 {
 		return ErrInvalidInput
 	}
 
-	// Extract feature names from first input
-	features := make([]string, 0, len(inputs[0]))
-	for key := range inputs[0] {
-		features = append(features, key)
-	}
+	// Extract feature names from all inputs, since rows may omit missing features
+	features := sortedRowKeys(inputs)
 
-	// Extract target variable names from first output
+	// Extract target variable names from all outputs
 	if len(outputs) == 0 {
 		return ErrInvalidOutput
 	}
 
-	targets := make([]string, 0, len(outputs[0]))
-	for key := range outputs[0] {
-		targets = append(targets, key)
-	}
+	targets := sortedRowKeys(outputs)
 
 	// Initialize weights if they don't exist
 	for _, feature := range features {
@@ -51,27 +45,16 @@ func trainLinearModel(inputs []map[string]interface{}, outputs []map[string]inte
 	fmt.Printf("Training with targets: %v\n", targets)
 
 	// Normalization helpers - calculate means for normalization
-	featureMeans := make(map[string]float64)
 	targetMeans := make(map[string]float64)
 
-	// Calculate feature means for numeric features
+	// Take feature means for numeric features from the model's running statistics
+	if model.Stats == nil {
+		model.updateStats(inputs)
+	}
+	featureMeans := model.featureMeans(features)
 	for _, feature := range features {
-		sum := 0.0
-		count := 0
-		for i := range inputs {
-			if val, ok := inputs[i][feature]; ok {
-				// Only include numeric types in mean calculation
-				if IsSupportedNumericType(val) {
-					// Safe to convert since we checked type
-					numVal, _ := ConvertToFloat64(val, "")
-					sum += numVal
-					count++
-				}
-			}
-		}
-		if count > 0 {
-			featureMeans[feature] = sum / float64(count)
-			fmt.Printf("Feature %s mean: %f\n", feature, featureMeans[feature])
+		if mean, ok := featureMeans[feature]; ok {
+			fmt.Printf("Feature %s mean: %f\n", feature, mean)
 		}
 	}
 
@@ -96,210 +79,31 @@ func trainLinearModel(inputs []map[string]interface{}, outputs []map[string]inte
 		}
 	}
 
+	workers := config.workers()
+	rng := newSeededRand(config.Seed, 0)
+
 	// Gradient descent for the specified number of epochs
 	for epoch := 0; epoch < config.Epochs; epoch++ {
 		// Calculate MSE for convergence check
 		prevMSE := calculateMSE(inputs, outputs, weights, features, targets)
 
+		// Visit samples in a seeded random order when shuffling is enabled
+		epochInputs, epochOutputs := shuffleSamples(inputs, outputs, rng)
+
 		// Update weights using batched gradient descent
-		for batchStart := 0; batchStart < len(inputs); batchStart += config.BatchSize {
+		for batchStart := 0; batchStart < len(epochInputs); batchStart += config.BatchSize {
 			batchEnd := batchStart + config.BatchSize
-			if batchEnd > len(inputs) {
-				batchEnd = len(inputs)
+			if batchEnd > len(epochInputs) {
+				batchEnd = len(epochInputs)
 			}
 
-			// Process each target variable
-			for _, target := range targets {
-				// Process each feature
-				for _, feature := range features {
-					weightKey := fmt.Sprintf("%s->%s", feature, target)
-					gradient := 0.0
-
-					// Calculate gradient for this batch
-					for i := batchStart; i < batchEnd; i++ {
-						// Get input feature value
-						featureValRaw, ok := inputs[i][feature]
-						if !ok {
-							continue
-						}
-
-						// Convert feature value to float64 and normalize
-						var featureVal float64
-						switch v := featureValRaw.(type) {
-						case float64:
-							// Normalize by dividing by mean if it's non-zero
-							if mean, ok := featureMeans[feature]; ok && mean != 0 {
-								featureVal = v / mean
-							} else {
-								featureVal = v
-							}
-						case int:
-							// Normalize by dividing by mean if it's non-zero
-							if mean, ok := featureMeans[feature]; ok && mean != 0 {
-								featureVal = float64(v) / mean
-							} else {
-								featureVal = float64(v)
-							}
-						case string:
-							// For string features, use one-hot encoding (1.0 if matches)
-							if v == feature {
-								featureVal = 1.0
-							} else {
-								featureVal = 0.0
-							}
-						default:
-							continue
-						}
-
-						// Calculate the prediction for this sample
-						predicted := 0.0
-						for _, f := range features {
-							fKey := fmt.Sprintf("%s->%s", f, target)
-							w, exists := weights.GetFloat(fKey)
-							if !exists {
-								continue
-							}
-
-							fVal, ok := inputs[i][f]
-							if !ok {
-								continue
-							}
-
-							// Convert feature value
-							var fValFloat float64
-							switch v := fVal.(type) {
-							case float64:
-								fValFloat = v
-							case int:
-								fValFloat = float64(v)
-							case bool:
-								if v {
-									fValFloat = 1.0
-								} else {
-									fValFloat = 0.0
-								}
-							case string:
-								if v == f {
-									fValFloat = 1.0
-								} else {
-									fValFloat = 0.0
-								}
-							default:
-								continue
-							}
-
-							predicted += w * fValFloat
-						}
-
-						// Add bias term
-						biasKey := fmt.Sprintf("bias->%s", target)
-						if bias, exists := weights.GetFloat(biasKey); exists {
-							predicted += bias
-						}
-
-						// Get actual target value
-						actualRaw, ok := outputs[i][target]
-						if !ok {
-							continue
-						}
-
-						// Convert target value to float64
-						var actual float64
-						switch v := actualRaw.(type) {
-						case float64:
-							actual = v
-						case int:
-							actual = float64(v)
-						default:
-							continue
-						}
-
-						// Update gradient: (predicted - actual) * featureValue
-						error := predicted - actual
-						gradient += error * featureVal
-					}
-
-					// Average the gradient over the batch
-					gradient /= float64(batchEnd - batchStart)
-
-					// Update weight with learning rate and regularization
-					currentWeight, _ := weights.GetFloat(weightKey)
-					regularizationTerm := config.Regularize * currentWeight
-					newWeight := currentWeight - config.LearningRate*(gradient+regularizationTerm)
-					weights.Set(weightKey, newWeight)
-				}
-
-				// Update bias term (no regularization for bias)
-				biasKey := fmt.Sprintf("bias->%s", target)
-				biasGradient := 0.0
-
-				// Calculate bias gradient
-				for i := batchStart; i < batchEnd; i++ {
-					// Calculate prediction for this sample
-					predicted := 0.0
-					for _, f := range features {
-						weightKey := fmt.Sprintf("%s->%s", f, target)
-						w, exists := weights.GetFloat(weightKey)
-						if !exists {
-							continue
-						}
-
-						fVal, ok := inputs[i][f]
-						if !ok {
-							continue
-						}
-
-						// Convert feature value
-						var fValFloat float64
-						switch v := fVal.(type) {
-						case float64:
-							fValFloat = v
-						case int:
-							fValFloat = float64(v)
-						case string:
-							if v == f {
-								fValFloat = 1.0
-							} else {
-								fValFloat = 0.0
-							}
-						default:
-							continue
-						}
-
-						predicted += w * fValFloat
-					}
-
-					// Add bias
-					bias, _ := weights.GetFloat(biasKey)
-					predicted += bias
-
-					// Get actual target value
-					actualRaw, ok := outputs[i][target]
-					if !ok {
-						continue
-					}
-
-					// Convert target to float64
-					var actual float64
-					switch v := actualRaw.(type) {
-					case float64:
-						actual = v
-					case int:
-						actual = float64(v)
-					default:
-						continue
-					}
-
-					// Update bias gradient with error (predicted - actual)
-					biasGradient += predicted - actual
-				}
+			batchInputs := epochInputs[batchStart:batchEnd]
+			batchOutputs := epochOutputs[batchStart:batchEnd]
 
-				// Average the gradient and update bias
-				biasGradient /= float64(batchEnd - batchStart)
-				currentBias, _ := weights.GetFloat(biasKey)
-				newBias := currentBias - config.LearningRate*biasGradient
-				weights.Set(biasKey, newBias)
-			}
+			// Each target has its own weights, so targets are updated concurrently
+			parallelFor(len(targets), workers, func(t int) {
+				updateLinearTarget(batchInputs, batchOutputs, targets[t], features, featureMeans, weights, config, splitWorkers(workers, len(targets)))
+			})
 		}
 
 		// Check for convergence
@@ -316,13 +120,216 @@ func trainLinearModel(inputs []map[string]interface{}, outputs []map[string]inte
 
 	// Print final weights
 	fmt.Println("Final weights:")
-	for key, val := range weights.Values {
-		fmt.Printf("%s: %v\n", key, val)
+	for _, target := range targets {
+		for _, feature := range features {
+			weightKey := fmt.Sprintf("%s->%s", feature, target)
+			val, _ := weights.Get(weightKey)
+			fmt.Printf("%s: %v\n", weightKey, val)
+		}
+		biasKey := fmt.Sprintf("bias->%s", target)
+		val, _ := weights.Get(biasKey)
+		fmt.Printf("%s: %v\n", biasKey, val)
 	}
 
 	return nil
 }
 
+// updateLinearTarget performs one mini-batch gradient step for a single target
+// Per-sample gradient terms are computed by a pool of up to workers goroutines, started once
+// for the batch, and summed in sample order
+func updateLinearTarget(inputs []map[string]interface{}, outputs []map[string]interface{}, target string, features []string, featureMeans map[string]float64, weights *Weights, config *Config, workers int) {
+	pool := newSumPool(len(inputs), workers)
+	defer pool.close()
+
+	// Process each feature
+	for _, feature := range features {
+		weightKey := fmt.Sprintf("%s->%s", feature, target)
+
+		// Calculate gradient for this batch
+		gradient := pool.sum(func(i int) float64 {
+			// Get input feature value
+			featureValRaw, ok := inputs[i][feature]
+			if !ok {
+				return 0.0
+			}
+
+			// Convert feature value to float64 and normalize
+			var featureVal float64
+			switch v := featureValRaw.(type) {
+			case float64:
+				// Normalize by dividing by mean if it's non-zero
+				if mean, ok := featureMeans[feature]; ok && mean != 0 {
+					featureVal = v / mean
+				} else {
+					featureVal = v
+				}
+			case int:
+				// Normalize by dividing by mean if it's non-zero
+				if mean, ok := featureMeans[feature]; ok && mean != 0 {
+					featureVal = float64(v) / mean
+				} else {
+					featureVal = float64(v)
+				}
+			case string:
+				// For string features, use one-hot encoding (1.0 if matches)
+				if v == feature {
+					featureVal = 1.0
+				} else {
+					featureVal = 0.0
+				}
+			default:
+				return 0.0
+			}
+
+			// Calculate the prediction for this sample
+			predicted := 0.0
+			for _, f := range features {
+				fKey := fmt.Sprintf("%s->%s", f, target)
+				w, exists := weights.GetFloat(fKey)
+				if !exists {
+					continue
+				}
+
+				fVal, ok := inputs[i][f]
+				if !ok {
+					continue
+				}
+
+				// Convert feature value
+				var fValFloat float64
+				switch v := fVal.(type) {
+				case float64:
+					fValFloat = v
+				case int:
+					fValFloat = float64(v)
+				case bool:
+					if v {
+						fValFloat = 1.0
+					} else {
+						fValFloat = 0.0
+					}
+				case string:
+					if v == f {
+						fValFloat = 1.0
+					} else {
+						fValFloat = 0.0
+					}
+				default:
+					continue
+				}
+
+				predicted += w * fValFloat
+			}
+
+			// Add bias term
+			biasKey := fmt.Sprintf("bias->%s", target)
+			if bias, exists := weights.GetFloat(biasKey); exists {
+				predicted += bias
+			}
+
+			// Get actual target value
+			actualRaw, ok := outputs[i][target]
+			if !ok {
+				return 0.0
+			}
+
+			// Convert target value to float64
+			var actual float64
+			switch v := actualRaw.(type) {
+			case float64:
+				actual = v
+			case int:
+				actual = float64(v)
+			default:
+				return 0.0
+			}
+
+			// Gradient term: (predicted - actual) * featureValue
+			error := predicted - actual
+			return error * featureVal
+		})
+
+		// Average the gradient over the batch
+		gradient /= float64(len(inputs))
+
+		// Update weight with learning rate and regularization
+		currentWeight, _ := weights.GetFloat(weightKey)
+		regularizationTerm := config.Regularize * currentWeight
+		newWeight := currentWeight - config.LearningRate*(gradient+regularizationTerm)
+		weights.Set(weightKey, newWeight)
+	}
+
+	// Update bias term (no regularization for bias)
+	biasKey := fmt.Sprintf("bias->%s", target)
+
+	// Calculate bias gradient
+	biasGradient := pool.sum(func(i int) float64 {
+		// Calculate prediction for this sample
+		predicted := 0.0
+		for _, f := range features {
+			weightKey := fmt.Sprintf("%s->%s", f, target)
+			w, exists := weights.GetFloat(weightKey)
+			if !exists {
+				continue
+			}
+
+			fVal, ok := inputs[i][f]
+			if !ok {
+				continue
+			}
+
+			// Convert feature value
+			var fValFloat float64
+			switch v := fVal.(type) {
+			case float64:
+				fValFloat = v
+			case int:
+				fValFloat = float64(v)
+			case string:
+				if v == f {
+					fValFloat = 1.0
+				} else {
+					fValFloat = 0.0
+				}
+			default:
+				continue
+			}
+
+			predicted += w * fValFloat
+		}
+
+		// Add bias
+		bias, _ := weights.GetFloat(biasKey)
+		predicted += bias
+
+		// Get actual target value
+		actualRaw, ok := outputs[i][target]
+		if !ok {
+			return 0.0
+		}
+
+		// Convert target to float64
+		var actual float64
+		switch v := actualRaw.(type) {
+		case float64:
+			actual = v
+		case int:
+			actual = float64(v)
+		default:
+			return 0.0
+		}
+
+		// Bias gradient term is the error (predicted - actual)
+		return predicted - actual
+	})
+
+	// Average the gradient and update bias
+	biasGradient /= float64(len(inputs))
+	currentBias, _ := weights.GetFloat(biasKey)
+	newBias := currentBias - config.LearningRate*biasGradient
+	weights.Set(biasKey, newBias)
+}
+
 // predictLinearModel implements linear regression prediction
 func predictLinearModel(input map[string]interface{}, weights *Weights) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
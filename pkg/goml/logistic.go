@@ -12,26 +12,20 @@ func sigmoid(z float64) float64 {
 
 // trainLogisticModel implements logistic regression training
 func trainLogisticModel(inputs []map[string]interface{}, outputs []map[string]interface{}, weights *Weights, config *Config) error {
-	// Get feature names from the first input
+	// Get feature names from the inputs
 	if len(inputs) == 0 {
 		return ErrInvalidInput
 	}
 
-	// Extract feature names from first input
-	features := make([]string, 0, len(inputs[0]))
-	for key := range inputs[0] {
-		features = append(features, key)
-	}
+	// Extract feature names from all inputs, since rows may omit missing features
+	features := sortedRowKeys(inputs)
 
-	// Extract target variable names from first output
+	// Extract target variable names from all outputs
 	if len(outputs) == 0 {
 		return ErrInvalidOutput
 	}
 
-	targets := make([]string, 0, len(outputs[0]))
-	for key := range outputs[0] {
-		targets = append(targets, key)
-	}
+	targets := sortedRowKeys(outputs)
 
 	// Initialize weights if they don't exist
 	for _, feature := range features {
@@ -51,210 +45,231 @@ func trainLogisticModel(inputs []map[string]interface{}, outputs []map[string]in
 		}
 	}
 
+	workers := config.workers()
+	rng := newSeededRand(config.Seed, 0)
+
 	// Gradient descent for the specified number of epochs
 	for epoch := 0; epoch < config.Epochs; epoch++ {
 		// Calculate log loss for convergence check
 		prevLoss := calculateLogLoss(inputs, outputs, weights, features, targets)
 
+		// Visit samples in a seeded random order when shuffling is enabled
+		epochInputs, epochOutputs := shuffleSamples(inputs, outputs, rng)
+
 		// Update weights using batched gradient descent
-		for batchStart := 0; batchStart < len(inputs); batchStart += config.BatchSize {
+		for batchStart := 0; batchStart < len(epochInputs); batchStart += config.BatchSize {
 			batchEnd := batchStart + config.BatchSize
-			if batchEnd > len(inputs) {
-				batchEnd = len(inputs)
+			if batchEnd > len(epochInputs) {
+				batchEnd = len(epochInputs)
 			}
 
-			// Process each target variable
-			for _, target := range targets {
-				// Process each feature
-				for _, feature := range features {
-					weightKey := fmt.Sprintf("%s->%s", feature, target)
-					gradient := 0.0
-
-					// Calculate gradient for this batch
-					for i := batchStart; i < batchEnd; i++ {
-						// Get input feature value
-						featureValRaw, ok := inputs[i][feature]
-						if !ok {
-							continue
-						}
-
-						// Convert feature value to float64
-						var featureVal float64
-						switch v := featureValRaw.(type) {
-						case float64:
-							featureVal = v
-						case int:
-							featureVal = float64(v)
-						case string:
-							// For string features, use one-hot encoding (1.0 if matches)
-							if v == feature {
-								featureVal = 1.0
-							} else {
-								featureVal = 0.0
-							}
-						default:
-							continue
-						}
-
-						// Calculate the z value (linear combination)
-						z := 0.0
-						for _, f := range features {
-							fKey := fmt.Sprintf("%s->%s", f, target)
-							w, exists := weights.GetFloat(fKey)
-							if !exists {
-								continue
-							}
-
-							fVal, ok := inputs[i][f]
-							if !ok {
-								continue
-							}
-
-							// Convert feature value
-							var fValFloat float64
-							switch v := fVal.(type) {
-							case float64:
-								fValFloat = v
-							case int:
-								fValFloat = float64(v)
-							case string:
-								if v == f {
-									fValFloat = 1.0
-								} else {
-									fValFloat = 0.0
-								}
-							default:
-								continue
-							}
-
-							z += w * fValFloat
-						}
-
-						// Add bias term
-						biasKey := fmt.Sprintf("bias->%s", target)
-						if bias, exists := weights.GetFloat(biasKey); exists {
-							z += bias
-						}
-
-						// Apply sigmoid function
-						predicted := sigmoid(z)
-
-						// Get actual target value
-						actualRaw, ok := outputs[i][target]
-						if !ok {
-							continue
-						}
-
-						// Convert target value to float64
-						var actual float64
-						switch v := actualRaw.(type) {
-						case float64:
-							actual = v
-						case int:
-							actual = float64(v)
-						default:
-							continue
-						}
-
-						// Gradient for logistic regression: (predicted - actual) * featureValue
-						error := predicted - actual
-						gradient += error * featureVal
-					}
+			batchInputs := epochInputs[batchStart:batchEnd]
+			batchOutputs := epochOutputs[batchStart:batchEnd]
+
+			// Each target has its own weights, so targets are updated concurrently
+			parallelFor(len(targets), workers, func(t int) {
+				updateLogisticTarget(batchInputs, batchOutputs, targets[t], features, weights, config, splitWorkers(workers, len(targets)))
+			})
+		}
 
-					// Average the gradient over the batch
-					gradient /= float64(batchEnd - batchStart)
+		// Check for convergence
+		currentLoss := calculateLogLoss(inputs, outputs, weights, features, targets)
+		if math.Abs(prevLoss-currentLoss) < config.Tolerance {
+			break
+		}
+	}
 
-					// Update weight with learning rate and regularization
-					currentWeight, _ := weights.GetFloat(weightKey)
-					regularizationTerm := config.Regularize * currentWeight
-					newWeight := currentWeight - config.LearningRate*(gradient+regularizationTerm)
-					weights.Set(weightKey, newWeight)
+	return nil
+}
+
+// updateLogisticTarget performs one mini-batch gradient step for a single target
+// Per-sample gradient terms are computed by a pool of up to workers goroutines, started once
+// for the batch, and summed in sample order
+func updateLogisticTarget(inputs []map[string]interface{}, outputs []map[string]interface{}, target string, features []string, weights *Weights, config *Config, workers int) {
+	pool := newSumPool(len(inputs), workers)
+	defer pool.close()
+
+	// Process each feature
+	for _, feature := range features {
+		weightKey := fmt.Sprintf("%s->%s", feature, target)
+
+		// Calculate gradient for this batch
+		gradient := pool.sum(func(i int) float64 {
+			// Get input feature value
+			featureValRaw, ok := inputs[i][feature]
+			if !ok {
+				return 0.0
+			}
+
+			// Convert feature value to float64
+			var featureVal float64
+			switch v := featureValRaw.(type) {
+			case float64:
+				featureVal = v
+			case int:
+				featureVal = float64(v)
+			case string:
+				// For string features, use one-hot encoding (1.0 if matches)
+				if v == feature {
+					featureVal = 1.0
+				} else {
+					featureVal = 0.0
+				}
+			default:
+				return 0.0
+			}
+
+			// Calculate the z value (linear combination)
+			z := 0.0
+			for _, f := range features {
+				fKey := fmt.Sprintf("%s->%s", f, target)
+				w, exists := weights.GetFloat(fKey)
+				if !exists {
+					continue
 				}
 
-				// Update bias term (no regularization for bias)
-				biasKey := fmt.Sprintf("bias->%s", target)
-				biasGradient := 0.0
-
-				// Calculate bias gradient
-				for i := batchStart; i < batchEnd; i++ {
-					// Calculate z value for this sample
-					z := 0.0
-					for _, f := range features {
-						weightKey := fmt.Sprintf("%s->%s", f, target)
-						w, exists := weights.GetFloat(weightKey)
-						if !exists {
-							continue
-						}
-
-						fVal, ok := inputs[i][f]
-						if !ok {
-							continue
-						}
-
-						// Convert feature value
-						var fValFloat float64
-						switch v := fVal.(type) {
-						case float64:
-							fValFloat = v
-						case int:
-							fValFloat = float64(v)
-						case string:
-							if v == f {
-								fValFloat = 1.0
-							} else {
-								fValFloat = 0.0
-							}
-						default:
-							continue
-						}
-
-						z += w * fValFloat
+				fVal, ok := inputs[i][f]
+				if !ok {
+					continue
+				}
+
+				// Convert feature value
+				var fValFloat float64
+				switch v := fVal.(type) {
+				case float64:
+					fValFloat = v
+				case int:
+					fValFloat = float64(v)
+				case string:
+					if v == f {
+						fValFloat = 1.0
+					} else {
+						fValFloat = 0.0
 					}
+				default:
+					continue
+				}
 
-					// Add bias
-					bias, _ := weights.GetFloat(biasKey)
-					z += bias
+				z += w * fValFloat
+			}
 
-					// Apply sigmoid
-					predicted := sigmoid(z)
+			// Add bias term
+			biasKey := fmt.Sprintf("bias->%s", target)
+			if bias, exists := weights.GetFloat(biasKey); exists {
+				z += bias
+			}
 
-					// Get actual target value
-					actualRaw, ok := outputs[i][target]
-					if !ok {
-						continue
-					}
+			// Apply sigmoid function
+			predicted := sigmoid(z)
 
-					// Convert target to float64
-					var actual float64
-					switch v := actualRaw.(type) {
-					case float64:
-						actual = v
-					case int:
-						actual = float64(v)
-					default:
-						continue
-					}
+			// Get actual target value
+			actualRaw, ok := outputs[i][target]
+			if !ok {
+				return 0.0
+			}
 
-					// Update bias gradient with error (predicted - actual)
-					biasGradient += predicted - actual
-				}
+			// Convert target value to float64
+			var actual float64
+			switch v := actualRaw.(type) {
+			case float64:
+				actual = v
+			case int:
+				actual = float64(v)
+			case bool:
+				actual = indicator(v)
+			default:
+				return 0.0
+			}
+
+			// Gradient for logistic regression: (predicted - actual) * featureValue
+			error := predicted - actual
+			return error * featureVal
+		})
 
-				// Average the gradient and update bias
-				biasGradient /= float64(batchEnd - batchStart)
-				currentBias, _ := weights.GetFloat(biasKey)
-				newBias := currentBias - config.LearningRate*biasGradient
-				weights.Set(biasKey, newBias)
+		// Average the gradient over the batch
+		gradient /= float64(len(inputs))
+
+		// Update weight with learning rate and regularization
+		currentWeight, _ := weights.GetFloat(weightKey)
+		regularizationTerm := config.Regularize * currentWeight
+		newWeight := currentWeight - config.LearningRate*(gradient+regularizationTerm)
+		weights.Set(weightKey, newWeight)
+	}
+
+	// Update bias term (no regularization for bias)
+	biasKey := fmt.Sprintf("bias->%s", target)
+
+	// Calculate bias gradient
+	biasGradient := pool.sum(func(i int) float64 {
+		// Calculate z value for this sample
+		z := 0.0
+		for _, f := range features {
+			weightKey := fmt.Sprintf("%s->%s", f, target)
+			w, exists := weights.GetFloat(weightKey)
+			if !exists {
+				continue
+			}
+
+			fVal, ok := inputs[i][f]
+			if !ok {
+				continue
+			}
+
+			// Convert feature value
+			var fValFloat float64
+			switch v := fVal.(type) {
+			case float64:
+				fValFloat = v
+			case int:
+				fValFloat = float64(v)
+			case string:
+				if v == f {
+					fValFloat = 1.0
+				} else {
+					fValFloat = 0.0
+				}
+			default:
+				continue
 			}
+
+			z += w * fValFloat
 		}
 
-		// Check for convergence
-		currentLoss := calculateLogLoss(inputs, outputs, weights, features, targets)
-		if math.Abs(prevLoss-currentLoss) < config.Tolerance {
-			break
+		// Add bias
+		bias, _ := weights.GetFloat(biasKey)
+		z += bias
+
+		// Apply sigmoid
+		predicted := sigmoid(z)
+
+		// Get actual target value
+		actualRaw, ok := outputs[i][target]
+		if !ok {
+			return 0.0
 		}
-	}
 
-	return nil
+		// Convert target to float64
+		var actual float64
+		switch v := actualRaw.(type) {
+		case float64:
+			actual = v
+		case int:
+			actual = float64(v)
+		case bool:
+			actual = indicator(v)
+		default:
+			return 0.0
+		}
+
+		// Bias gradient term is the error (predicted - actual)
+		return predicted - actual
+	})
+
+	// Average the gradient and update bias
+	biasGradient /= float64(len(inputs))
+	currentBias, _ := weights.GetFloat(biasKey)
+	newBias := currentBias - config.LearningRate*biasGradient
+	weights.Set(biasKey, newBias)
 }
 
 // predictLogisticModel implements logistic regression prediction
@@ -385,6 +400,8 @@ func calculateLogLoss(inputs []map[string]interface{}, outputs []map[string]inte
 				actual = v
 			case int:
 				actual = float64(v)
+			case bool:
+				actual = indicator(v)
 			default:
 				continue
 			}
@@ -14,7 +14,7 @@ func NewMixedModel() *Model {
 		},
 		Categories:        make(map[string]map[string]int),
 		FeatureCategories: make(map[string]map[string]int),
-		Features:          make(map[string]interface{}),
+		Features:          make(map[string]*FeatureSpec),
 		Targets:           make(map[string]interface{}),
 	}
 }
@@ -36,56 +36,34 @@ func trainMixedModel(inputs []map[string]interface{}, outputs []map[string]inter
 		return ErrInvalidOutput
 	}
 
-	// Determine which fields are which type
-	for key, val := range outputs[0] {
-		isNumeric := false
-		isBoolean := false
-		isCategorical := false
+	// Initialize the target type map if the model was loaded without one
+	if model.Targets == nil {
+		model.Targets = make(map[string]interface{})
+	}
 
-		// Check value type
-		switch v := val.(type) {
-		case int, int64, int32, float64, float32:
-			// Check if it might be binary (0/1)
-			switch num := v.(type) {
-			case int:
-				if num == 0 || num == 1 {
-					isBoolean = true
-				} else {
-					isNumeric = true
-				}
-			case float64:
-				if num == 0.0 || num == 1.0 {
-					isBoolean = true
-				} else {
-					isNumeric = true
-				}
-			default:
-				isNumeric = true
-			}
-			if isNumeric {
-				model.Targets[key] = "numeric"
-			} else {
-				model.Targets[key] = "boolean"
-			}
-		case bool:
-			isBoolean = true
-			model.Targets[key] = "boolean"
-		case string:
-			isCategorical = true
-			model.Targets[key] = "categorical"
-		default:
-			// Skip unknown types
-			continue
+	// Determine which fields are which type from every row, widening the types already known
+	kinds := make(targetKinds)
+	for key, kind := range model.Targets {
+		if k, ok := kind.(string); ok {
+			kinds[key] = k
 		}
+	}
+	for _, output := range outputs {
+		kinds.observe(output)
+	}
+
+	for key, kind := range kinds {
+		model.Targets[key] = kind
 
 		// Copy values to appropriate output maps
 		for i, output := range outputs {
 			if v, ok := output[key]; ok {
-				if isNumeric {
+				switch kind {
+				case "numeric":
 					numericOutputs[i][key] = v
-				} else if isBoolean {
+				case "boolean":
 					booleanOutputs[i][key] = v
-				} else if isCategorical {
+				case "categorical":
 					categoricalOutputs[i][key] = v
 				}
 			}
@@ -122,42 +100,101 @@ func trainMixedModel(inputs []map[string]interface{}, outputs []map[string]inter
 	fmt.Printf("Categorical targets found: %d\n", categoricalTargetCount)
 	fmt.Printf("Boolean targets found: %d\n", booleanTargetCount)
 
+	// Collect the sub-models that have targets to train
+	type subModel struct {
+		name  string
+		train func(config *Config) error
+	}
+	var subModels []subModel
+
 	// Train for numeric outputs if they exist
 	if numericTargetCount > 0 {
-		fmt.Println("Training numeric model...")
-		err := trainLinearModel(inputs, numericOutputs, weights, config)
-		if err != nil {
-			return fmt.Errorf("error training numeric targets: %w", err)
-		}
+		subModels = append(subModels, subModel{"numeric", func(config *Config) error {
+			fmt.Println("Training numeric model...")
+			return trainLinearModel(inputs, numericOutputs, weights, config, model)
+		}})
 	}
 
 	// Train for categorical outputs if they exist
 	if categoricalTargetCount > 0 {
-		fmt.Println("Training categorical model...")
-		err := trainCategoricalModel(inputs, categoricalOutputs, weights, config, model)
-		if err != nil {
-			return fmt.Errorf("error training categorical targets: %w", err)
-		}
+		subModels = append(subModels, subModel{"categorical", func(config *Config) error {
+			fmt.Println("Training categorical model...")
+			return trainCategoricalModel(inputs, categoricalOutputs, weights, config, model)
+		}})
 	}
 
 	// Train for boolean outputs if they exist
 	if booleanTargetCount > 0 {
-		fmt.Println("Training boolean model...")
-		err := trainLogisticModel(inputs, booleanOutputs, weights, config)
+		subModels = append(subModels, subModel{"boolean", func(config *Config) error {
+			fmt.Println("Training boolean model...")
+			return trainLogisticModel(inputs, booleanOutputs, weights, config)
+		}})
+	}
+
+	// Sub-models write disjoint weight keys, so they are trained concurrently
+	// and share the worker budget between them
+	workers := config.workers()
+	subConfig := *config
+	subConfig.Parallelism = splitWorkers(workers, len(subModels))
+
+	errs := make([]error, len(subModels))
+	parallelFor(len(subModels), workers, func(i int) {
+		errs[i] = subModels[i].train(&subConfig)
+	})
+
+	for i, err := range errs {
 		if err != nil {
-			return fmt.Errorf("error training boolean targets: %w", err)
+			return fmt.Errorf("error training %s targets: %w", subModels[i].name, err)
 		}
 	}
 
 	return nil
 }
 
+// targetKinds maps the targets of a mixed model to their type: "numeric", "boolean" or "categorical"
+type targetKinds map[string]string
+
+// kindRank orders target types so that a type only widens as more values are seen
+var kindRank = map[string]int{"boolean": 1, "numeric": 2, "categorical": 3}
+
+// observe widens the target types with the values of an output row
+// Strings are categorical, numbers other than 0 and 1 numeric, and bools, 0 and 1 boolean;
+// values of other types are skipped
+func (k targetKinds) observe(output map[string]interface{}) {
+	for key, val := range output {
+		var kind string
+		switch v := val.(type) {
+		case bool:
+			kind = "boolean"
+		case int:
+			kind = "numeric"
+			if v == 0 || v == 1 {
+				kind = "boolean"
+			}
+		case float64:
+			kind = "numeric"
+			if v == 0 || v == 1 {
+				kind = "boolean"
+			}
+		case int64, int32, float32:
+			kind = "numeric"
+		case string:
+			kind = "categorical"
+		default:
+			continue
+		}
+		if kindRank[kind] > kindRank[k[key]] {
+			k[key] = kind
+		}
+	}
+}
+
 // predictMixedModel performs prediction with a mixed model
-func predictMixedModel(input map[string]interface{}, weights *Weights, model *Model) (map[string]interface{}, error) {
+func predictMixedModel(input map[string]interface{}, weights *Weights, model *Model, config *Config) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
 
 	// We'll predict with all model types and combine the results based on target type
-	
+
 	// First, do linear predictions for numeric outputs
 	linearPred, err := predictLinearModel(input, weights)
 	if err != nil {
@@ -181,12 +218,13 @@ func predictMixedModel(input map[string]interface{}, weights *Weights, model *Mo
 	for k, v := range catPred {
 		if targetType, ok := model.Targets[k]; ok && targetType == "categorical" {
 			result[k] = v
-			
+
 			// Also add probability distributions if available
 			probKey := k + "_probs"
 			if probs, ok := catPred[probKey]; ok {
 				result[probKey] = probs
 			}
+			model.categoryOptions(result, k, config)
 		}
 	}
 
@@ -199,13 +237,9 @@ func predictMixedModel(input map[string]interface{}, weights *Weights, model *Mo
 	// Add boolean predictions to result based on target type
 	for k, v := range logPred {
 		if targetType, ok := model.Targets[k]; ok && targetType == "boolean" {
-			// Convert probability to boolean if needed
+			// Convert probability to boolean using the target's decision threshold
 			if prob, ok := v.(float64); ok {
-				if prob >= 0.5 {
-					result[k] = true
-				} else {
-					result[k] = false
-				}
+				model.decide(result, k, model.calibratedProbability(k, prob), config)
 			} else {
 				result[k] = v
 			}
@@ -213,4 +247,4 @@ func predictMixedModel(input map[string]interface{}, weights *Weights, model *Mo
 	}
 
 	return result, nil
-}
\ No newline at end of file
+}
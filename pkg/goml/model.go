@@ -2,47 +2,184 @@ package goml
 
 import (
 	"encoding/json"
+	"fmt"
 )
 
 // Model represents the model structure (linear, logistic, etc.)
 type Model struct {
 	Type              string                    `json:"type"`
 	Parameters        map[string]interface{}    `json:"parameters"`
-	Features          map[string]interface{}    `json:"features,omitempty"`          // Feature metadata (e.g., type, mean, min, max)
-	Targets           map[string]interface{}    `json:"targets,omitempty"`           // Target metadata (e.g., type)
-	Categories        map[string]map[string]int `json:"categories,omitempty"`        // Maps output names to category->index mappings
+	Features          map[string]*FeatureSpec   `json:"features,omitempty"`           // Per-feature preprocessing and the state learned for it
+	Targets           map[string]interface{}    `json:"targets,omitempty"`            // Target metadata (e.g., type)
+	Categories        map[string]map[string]int `json:"categories,omitempty"`         // Maps output names to category->index mappings
 	FeatureCategories map[string]map[string]int `json:"feature_categories,omitempty"` // Maps categorical feature names to value->index mappings
+	Stats             map[string]*FeatureStats  `json:"stats,omitempty"`              // Running statistics of numeric features used for normalization
+	Schema            *Schema                   `json:"schema,omitempty"`             // Expected inputs and outputs, inferred at training time unless declared
+	Pipeline          *Pipeline                 `json:"pipeline,omitempty"`           // Preprocessing steps run after the per-feature preprocessing
+	Exclude           []string                  `json:"exclude,omitempty"`            // Input features dropped before preprocessing, e.g. by feature selection
+	Decomposition     *PCA                      `json:"decomposition,omitempty"`      // Principal components learned by a PCA model
+	Calibration       map[string]*Calibrator    `json:"calibration,omitempty"`        // Probability calibrators per boolean target or "target:category"
+	Thresholds        map[string]float64        `json:"thresholds,omitempty"`         // Decision thresholds of boolean targets (default 0.5)
 }
 
 // Train defines how the model is trained on data
 func (m *Model) Train(inputs []map[string]interface{}, outputs []map[string]interface{}, weights *Weights, config *Config) error {
+	// Check the data against a declared schema, or infer a new one from it
+	if m.Schema != nil && !m.Schema.Inferred {
+		if err := m.validateTrainingData(inputs, outputs, config); err != nil {
+			return err
+		}
+	} else {
+		m.Schema = InferSchema(inputs, outputs)
+		m.relaxSchema()
+	}
+
+	// Learn the feature preprocessing and apply it to the training rows
+	if err := m.fitPreprocessing(inputs, outputs); err != nil {
+		return err
+	}
+	inputs = m.preprocessRows(inputs, 0)
+
+	// Recompute normalization statistics from the full training set
+	m.Stats = nil
+	m.updateStats(inputs)
+
+	// Calibrators describe the previous weights
+	m.Calibration = nil
+
+	return m.train(inputs, outputs, weights, config)
+}
+
+// PartialFit continues training on new data for Config.PartialFitEpochs epochs
+// Existing weights and categories are kept, new categories are added, and the
+// normalization statistics are updated with the new rows
+func (m *Model) PartialFit(inputs []map[string]interface{}, outputs []map[string]interface{}, weights *Weights, config *Config) error {
+	// Principal components are fitted on the complete training set
+	if m.Type == "pca" {
+		return fmt.Errorf("partial fit of a pca model: %w", ErrUnsupportedModelType)
+	}
+
+	// New rows must match a declared schema, while an inferred one grows to include them
+	switch {
+	case m.Schema == nil:
+		m.Schema = InferSchema(inputs, outputs)
+	case m.Schema.Inferred:
+		m.Schema.extend(inputs, outputs)
+	default:
+		if err := m.validateTrainingData(inputs, outputs, config); err != nil {
+			return err
+		}
+	}
+	m.relaxSchema()
+
+	// Preprocessing keeps the state learned by earlier training so the features stay
+	// comparable; it is only learned here when the model has not been trained before
+	if m.Stats == nil {
+		if err := m.fitPreprocessing(inputs, outputs); err != nil {
+			return err
+		}
+	}
+	inputs = m.preprocessRows(inputs, 0)
+	m.updateStats(inputs)
+
+	// Calibrators describe the previous weights
+	m.Calibration = nil
+
+	partialConfig := *config
+	partialConfig.Epochs = config.PartialFitEpochs
+	if partialConfig.Epochs < 1 {
+		partialConfig.Epochs = 1
+	}
+
+	return m.train(inputs, outputs, weights, &partialConfig)
+}
+
+// validateTrainingData checks training rows against the model's declared schema
+func (m *Model) validateTrainingData(inputs []map[string]interface{}, outputs []map[string]interface{}, config *Config) error {
+	strict := config.strictSchema()
+	for i := range inputs {
+		if err := m.Schema.ValidateInput(inputs[i], strict); err != nil {
+			return fmt.Errorf("training row %d: %w", i, err)
+		}
+		if i < len(outputs) && m.Schema.Outputs != nil {
+			if err := m.Schema.ValidateOutput(outputs[i], strict); err != nil {
+				return fmt.Errorf("training row %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// train dispatches training to the implementation for the model type
+func (m *Model) train(inputs []map[string]interface{}, outputs []map[string]interface{}, weights *Weights, config *Config) error {
 	// Different implementations based on model type
 	switch m.Type {
 	case "linear":
-		return trainLinearModel(inputs, outputs, weights, config)
+		return trainLinearModel(inputs, outputs, weights, config, m)
 	case "logistic":
 		return trainLogisticModel(inputs, outputs, weights, config)
 	case "categorical":
 		return trainCategoricalModel(inputs, outputs, weights, config, m)
 	case "mixed":
 		return trainMixedModel(inputs, outputs, weights, config, m)
+	case "pca":
+		if m.Decomposition == nil {
+			m.Decomposition = &PCA{}
+		}
+		return m.Decomposition.Fit(inputs, outputs)
 	default:
 		return ErrUnsupportedModelType
 	}
 }
 
 // Predict performs inference using the trained model
+// The feature preprocessing learned during training is applied to the input first
 func (m *Model) Predict(input map[string]interface{}, weights *Weights) (map[string]interface{}, error) {
+	return m.predict(input, weights, nil)
+}
+
+// predict performs inference with the prediction options of a config, which may be nil
+// Logistic models predict probabilities, or labels with their probabilities when
+// Config.Probabilities is set
+func (m *Model) predict(input map[string]interface{}, weights *Weights, config *Config) (map[string]interface{}, error) {
+	input = m.preprocess(input)
+
 	// Different implementations based on model type
 	switch m.Type {
 	case "linear":
 		return predictLinearModel(input, weights)
 	case "logistic":
-		return predictLogisticModel(input, weights)
+		result, err := predictLogisticModel(input, weights)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range sortedKeys(result) {
+			if p, ok := result[target].(float64); ok {
+				p = m.calibratedProbability(target, p)
+				if config.probabilities() {
+					m.decide(result, target, p, config)
+				} else {
+					result[target] = p
+				}
+			}
+		}
+		return result, nil
 	case "categorical":
-		return predictCategoricalModel(input, weights, m)
+		result, err := predictCategoricalModel(input, weights, m)
+		if err != nil {
+			return nil, err
+		}
+		for target := range m.Categories {
+			m.categoryOptions(result, target, config)
+		}
+		return result, nil
 	case "mixed":
-		return predictMixedModel(input, weights, m)
+		return predictMixedModel(input, weights, m, config)
+	case "pca":
+		if m.Decomposition == nil {
+			return nil, ErrModelNotTrained
+		}
+		return m.Decomposition.predict(input), nil
 	default:
 		return nil, ErrUnsupportedModelType
 	}
@@ -57,6 +194,20 @@ func (m *Model) JSON() string {
 	return string(bytes)
 }
 
+// clone returns a deep copy of the model by round-tripping it through JSON
+func (m *Model) clone() (*Model, error) {
+	bytes, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy model: %w", err)
+	}
+
+	var clone Model
+	if err := json.Unmarshal(bytes, &clone); err != nil {
+		return nil, fmt.Errorf("failed to copy model: %w", err)
+	}
+	return &clone, nil
+}
+
 // NewLinearModel creates a new linear regression model
 func NewLinearModel() *Model {
 	return &Model{
@@ -95,7 +246,7 @@ func NewAutoModel(outputSample map[string]interface{}) *Model {
 	hasString := false
 	hasNumeric := false
 	hasBoolean := false
-	
+
 	// Analyze all output types
 	for _, val := range outputSample {
 		switch v := val.(type) {
@@ -112,24 +263,24 @@ func NewAutoModel(outputSample map[string]interface{}) *Model {
 			}
 		}
 	}
-	
+
 	// If we have mixed types (string and numeric/boolean), use mixed model
 	if (hasString && hasNumeric) || (hasString && hasBoolean) || (hasNumeric && hasBoolean) {
 		return NewMixedModel()
 	}
-	
+
 	// If all outputs are strings, use categorical
 	if hasString {
 		return NewCategoricalModel()
 	}
-	
+
 	// Check if all values are binary (0/1) or boolean
 	isLogistic := hasBoolean // Already logistic if we have boolean outputs
-	
+
 	// If we don't already know it's logistic, check numeric values
 	if !isLogistic && hasNumeric {
 		isLogistic = true // Assume it's logistic until proven otherwise
-		
+
 		for _, val := range outputSample {
 			switch v := val.(type) {
 			case int:
@@ -145,7 +296,7 @@ func NewAutoModel(outputSample map[string]interface{}) *Model {
 			}
 		}
 	}
-	
+
 	// Create and return the appropriate model
 	if isLogistic {
 		return NewLogisticModel()
@@ -153,4 +304,4 @@ func NewAutoModel(outputSample map[string]interface{}) *Model {
 		// Default to linear for all other cases
 		return NewLinearModel()
 	}
-}
\ No newline at end of file
+}
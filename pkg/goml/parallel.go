@@ -0,0 +1,164 @@
+package goml
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// workers returns how many goroutines training may use
+// A zero or negative Parallelism value means training runs serially
+func (c *Config) workers() int {
+	if c == nil || c.Parallelism < 1 {
+		return 1
+	}
+	return c.Parallelism
+}
+
+// parallelFor calls fn for every index in [0, n) using up to workers goroutines
+// Indices are split into contiguous shards; with one worker fn runs inline in order
+func parallelFor(n int, workers int, fn func(i int)) {
+	if n <= 0 {
+		return
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	shardSize := (n + workers - 1) / workers
+	for start := 0; start < n; start += shardSize {
+		end := start + shardSize
+		if end > n {
+			end = n
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				fn(i)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// minShardRows is the fewest rows a worker of a sumPool sums. Smaller batches are summed
+// serially, since handing a few rows to other goroutines costs more than summing them
+const minShardRows = 64
+
+// sumPool sums per-row terms of a mini-batch, such as the gradient of every weight
+// Its goroutines are started once per batch and each owns a contiguous shard of the rows, so
+// the many sums taken over one batch do not start goroutines of their own
+type sumPool struct {
+	terms []float64
+	tasks []chan func(i int) float64
+	wg    sync.WaitGroup
+}
+
+// newSumPool starts up to workers goroutines for sums over n rows
+// Close must be called when the batch is done
+func newSumPool(n int, workers int) *sumPool {
+	p := &sumPool{terms: make([]float64, n)}
+	if shards := n / minShardRows; workers > shards {
+		workers = shards
+	}
+	if workers <= 1 {
+		return p
+	}
+
+	shardSize := (n + workers - 1) / workers
+	for start := 0; start < n; start += shardSize {
+		end := start + shardSize
+		if end > n {
+			end = n
+		}
+
+		tasks := make(chan func(i int) float64)
+		p.tasks = append(p.tasks, tasks)
+		go func(start, end int) {
+			for fn := range tasks {
+				for i := start; i < end; i++ {
+					p.terms[i] = fn(i)
+				}
+				p.wg.Done()
+			}
+		}(start, end)
+	}
+	return p
+}
+
+// sum evaluates fn for every row and sums the results in row order
+// Summing in a fixed order keeps the result bit-identical regardless of the number of workers
+func (p *sumPool) sum(fn func(i int) float64) float64 {
+	sum := 0.0
+	if len(p.tasks) == 0 {
+		for i := range p.terms {
+			sum += fn(i)
+		}
+		return sum
+	}
+
+	p.wg.Add(len(p.tasks))
+	for _, tasks := range p.tasks {
+		tasks <- fn
+	}
+	p.wg.Wait()
+
+	for _, term := range p.terms {
+		sum += term
+	}
+	return sum
+}
+
+// close stops the goroutines of the pool
+func (p *sumPool) close() {
+	for _, tasks := range p.tasks {
+		close(tasks)
+	}
+}
+
+// splitWorkers divides a worker budget between units that already run concurrently
+func splitWorkers(workers int, units int) int {
+	if units <= 1 {
+		return workers
+	}
+	if perUnit := workers / units; perUnit > 1 {
+		return perUnit
+	}
+	return 1
+}
+
+// newSeededRand returns a deterministic random source for the given seed and stream
+// A zero seed disables shuffling and yields nil
+func newSeededRand(seed int64, stream int) *rand.Rand {
+	if seed == 0 {
+		return nil
+	}
+	return rand.New(rand.NewSource(seed + int64(stream)))
+}
+
+// shuffleSamples returns a shuffled view of the paired inputs and outputs
+// The original slices are left untouched; a nil source returns them unchanged
+func shuffleSamples(inputs []map[string]interface{}, outputs []map[string]interface{}, rng *rand.Rand) ([]map[string]interface{}, []map[string]interface{}) {
+	if rng == nil {
+		return inputs, outputs
+	}
+
+	shuffledInputs := make([]map[string]interface{}, len(inputs))
+	shuffledOutputs := make([]map[string]interface{}, len(outputs))
+	copy(shuffledInputs, inputs)
+	copy(shuffledOutputs, outputs)
+
+	rng.Shuffle(len(shuffledInputs), func(i, j int) {
+		shuffledInputs[i], shuffledInputs[j] = shuffledInputs[j], shuffledInputs[i]
+		shuffledOutputs[i], shuffledOutputs[j] = shuffledOutputs[j], shuffledOutputs[i]
+	})
+	return shuffledInputs, shuffledOutputs
+}
@@ -0,0 +1,184 @@
+package goml
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"testing"
+)
+
+// trainWithParallelism trains a fresh engine of the given model type and returns its weights JSON
+func trainWithParallelism(t *testing.T, model *Model, inputs, outputs []map[string]interface{}, parallelism int) string {
+	t.Helper()
+
+	engine := New()
+	engine.WithModel(model.JSON())
+	engine.WithConfig(&Config{
+		LearningRate: 0.01,
+		Epochs:       50,
+		BatchSize:    3,
+		Regularize:   0.001,
+		Tolerance:    0,
+		Parallelism:  parallelism,
+		Seed:         42,
+	})
+
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training with parallelism %d failed: %v", parallelism, err)
+	}
+
+	weightsJSON, err := engine.GetWeights()
+	if err != nil {
+		t.Fatalf("Error getting weights: %v", err)
+	}
+	return *weightsJSON
+}
+
+// TestParallelTrainingMatchesSerial tests that every model type produces identical weights with and without parallelism
+func TestParallelTrainingMatchesSerial(t *testing.T) {
+	inputs := []map[string]interface{}{
+		{"x1": 1.0, "x2": 2.0, "x3": 0.5},
+		{"x1": 2.0, "x2": 1.0, "x3": 1.5},
+		{"x1": 3.0, "x2": 4.0, "x3": 2.5},
+		{"x1": 4.0, "x2": 3.0, "x3": 3.5},
+		{"x1": 5.0, "x2": 6.0, "x3": 4.5},
+		{"x1": 6.0, "x2": 5.0, "x3": 5.5},
+		{"x1": 7.0, "x2": 8.0, "x3": 6.5},
+	}
+
+	tests := []struct {
+		name    string
+		model   func() *Model
+		outputs []map[string]interface{}
+	}{
+		{"linear", NewLinearModel, []map[string]interface{}{
+			{"y1": 3.0, "y2": 1.0}, {"y1": 5.0, "y2": 2.0}, {"y1": 7.0, "y2": 3.0}, {"y1": 9.0, "y2": 4.0},
+			{"y1": 11.0, "y2": 5.0}, {"y1": 13.0, "y2": 6.0}, {"y1": 15.0, "y2": 7.0},
+		}},
+		{"logistic", NewLogisticModel, []map[string]interface{}{
+			{"a": 0, "b": 1}, {"a": 0, "b": 1}, {"a": 0, "b": 0}, {"a": 1, "b": 0},
+			{"a": 1, "b": 1}, {"a": 1, "b": 0}, {"a": 1, "b": 1},
+		}},
+		{"categorical", NewCategoricalModel, []map[string]interface{}{
+			{"size": "small", "tier": "low"}, {"size": "small", "tier": "low"}, {"size": "medium", "tier": "low"},
+			{"size": "medium", "tier": "high"}, {"size": "large", "tier": "high"}, {"size": "large", "tier": "high"},
+			{"size": "large", "tier": "high"},
+		}},
+		{"mixed", NewMixedModel, []map[string]interface{}{
+			{"size": "small", "score": 3.5, "flag": 0}, {"size": "small", "score": 5.5, "flag": 0},
+			{"size": "medium", "score": 7.5, "flag": 0}, {"size": "medium", "score": 9.5, "flag": 1},
+			{"size": "large", "score": 11.5, "flag": 1}, {"size": "large", "score": 13.5, "flag": 1},
+			{"size": "large", "score": 15.5, "flag": 1},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serial := trainWithParallelism(t, tt.model(), inputs, tt.outputs, 1)
+			parallel := trainWithParallelism(t, tt.model(), inputs, tt.outputs, 4)
+
+			if serial != parallel {
+				t.Errorf("Parallel training produced different weights:\nserial=%s\nparallel=%s", serial, parallel)
+			}
+		})
+	}
+}
+
+// TestSeedMakesTrainingReproducible tests that shuffled training is repeatable with a fixed seed
+func TestSeedMakesTrainingReproducible(t *testing.T) {
+	inputs := []map[string]interface{}{
+		{"x": 1.0}, {"x": 2.0}, {"x": 3.0}, {"x": 4.0}, {"x": 5.0},
+	}
+	outputs := []map[string]interface{}{
+		{"y": 2.0}, {"y": 4.0}, {"y": 6.0}, {"y": 8.0}, {"y": 10.0},
+	}
+
+	first := trainWithParallelism(t, NewLinearModel(), inputs, outputs, 2)
+	second := trainWithParallelism(t, NewLinearModel(), inputs, outputs, 2)
+
+	if first != second {
+		t.Errorf("Training with the same seed produced different weights:\n%s\n%s", first, second)
+	}
+}
+
+// TestParallelForCoversAllIndices tests that parallelFor visits every index exactly once
+func TestParallelForCoversAllIndices(t *testing.T) {
+	for _, workers := range []int{1, 3, 8, 100} {
+		visited := make([]int, 37)
+		parallelFor(len(visited), workers, func(i int) {
+			visited[i]++
+		})
+
+		for i, count := range visited {
+			if count != 1 {
+				t.Errorf("workers=%d: index %d visited %d times", workers, i, count)
+			}
+		}
+	}
+}
+
+// TestSumPoolMatchesSerial tests that pooled sums are bit-identical to serial sums and that
+// small batches do not start goroutines
+func TestSumPoolMatchesSerial(t *testing.T) {
+	term := func(i int) float64 { return math.Pow(-1.1, float64(i%40)) / float64(i+1) }
+
+	serial := newSumPool(1000, 1)
+	want := serial.sum(term)
+	serial.close()
+
+	for _, workers := range []int{2, 3, 8, 100} {
+		pool := newSumPool(1000, workers)
+		if len(pool.tasks) < 2 || len(pool.tasks) > workers {
+			t.Errorf("workers=%d: expected between 2 and %d shards, got %d", workers, workers, len(pool.tasks))
+		}
+		for round := 0; round < 3; round++ {
+			if got := pool.sum(term); got != want {
+				t.Errorf("workers=%d: expected %v, got %v", workers, want, got)
+			}
+		}
+		pool.close()
+	}
+
+	if pool := newSumPool(minShardRows+1, 8); len(pool.tasks) != 0 {
+		t.Errorf("Expected a small batch to be summed serially, got %d shards", len(pool.tasks))
+	}
+}
+
+// BenchmarkUpdateLinearTarget compares serial and pooled gradient steps on one large batch
+func BenchmarkUpdateLinearTarget(b *testing.B) {
+	var inputs, outputs []map[string]interface{}
+	var features []string
+	for f := 0; f < 8; f++ {
+		features = append(features, fmt.Sprintf("x%d", f))
+	}
+	for i := 0; i < 2048; i++ {
+		input := make(map[string]interface{}, len(features))
+		y := 0.0
+		for f, feature := range features {
+			x := float64((i*(f+3))%17) / 17
+			input[feature] = x
+			y += float64(f) * x
+		}
+		inputs = append(inputs, input)
+		outputs = append(outputs, map[string]interface{}{"y": y})
+	}
+	config := &Config{LearningRate: 0.01}
+
+	benchmarks := []struct {
+		name    string
+		workers int
+	}{
+		{"serial", 1},
+		{"parallel", max(runtime.NumCPU(), 2)},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			weights := &Weights{Values: make(map[string]interface{})}
+			initWeights(weights, features, []string{"y"})
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				updateLinearTarget(inputs, outputs, "y", features, nil, weights, config, bm.workers)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package goml
+
+import (
+	"testing"
+)
+
+// TestPartialFitContinuesFromExistingWeights tests that PartialFit refines instead of reinitializing weights
+func TestPartialFitContinuesFromExistingWeights(t *testing.T) {
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 200, BatchSize: 4, PartialFitEpochs: 1})
+
+	inputs := []map[string]interface{}{{"x": 1.0}, {"x": 2.0}, {"x": 3.0}, {"x": 4.0}}
+	outputs := []map[string]interface{}{{"y": 2.0}, {"y": 4.0}, {"y": 6.0}, {"y": 8.0}}
+
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+	_, trained, _ := engine.snapshot()
+	trainedWeight, _ := trained.GetFloat("x->y")
+
+	err := engine.PartialFit(
+		[]map[string]interface{}{{"x": 5.0}},
+		[]map[string]interface{}{{"y": 10.0}},
+	)
+	if err != nil {
+		t.Fatalf("PartialFit failed: %v", err)
+	}
+	_, updated, _ := engine.snapshot()
+	updatedWeight, _ := updated.GetFloat("x->y")
+
+	// A single step on a consistent example should only nudge the learned weight
+	if updatedWeight == 0 || updatedWeight == trainedWeight {
+		t.Errorf("Expected PartialFit to adjust the trained weight %v, got %v", trainedWeight, updatedWeight)
+	}
+	if diff := updatedWeight - trainedWeight; diff > 0.5 || diff < -0.5 {
+		t.Errorf("PartialFit moved the weight too far: %v -> %v", trainedWeight, updatedWeight)
+	}
+}
+
+// TestPartialFitUpdatesStatistics tests that running normalization statistics include new rows
+func TestPartialFitUpdatesStatistics(t *testing.T) {
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+
+	engine.Train(
+		[]map[string]interface{}{{"x": 1.0}, {"x": 3.0}},
+		[]map[string]interface{}{{"y": 1.0}, {"y": 3.0}},
+	)
+	engine.PartialFit(
+		[]map[string]interface{}{{"x": 5.0}},
+		[]map[string]interface{}{{"y": 5.0}},
+	)
+
+	model, _, _ := engine.snapshot()
+	stats := model.Stats["x"]
+	if stats == nil {
+		t.Fatal("Expected statistics for feature x")
+	}
+	if stats.Count != 3 || stats.Mean() != 3.0 || stats.Min != 1.0 || stats.Max != 5.0 {
+		t.Errorf("Unexpected statistics after PartialFit: %+v (mean %v)", stats, stats.Mean())
+	}
+	if variance := stats.Variance(); variance < 2.66 || variance > 2.67 {
+		t.Errorf("Expected variance 8/3, got %v", variance)
+	}
+}
+
+// TestPartialFitAddsNewCategories tests that unseen categories are learned without renumbering existing ones
+func TestPartialFitAddsNewCategories(t *testing.T) {
+	for _, newModel := range []func() *Model{NewCategoricalModel, NewMixedModel} {
+		engine := New()
+		engine.WithModel(newModel().JSON())
+		engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 50, BatchSize: 2, PartialFitEpochs: 5})
+
+		err := engine.Train(
+			[]map[string]interface{}{{"x": 1.0}, {"x": 2.0}},
+			[]map[string]interface{}{{"label": "a", "score": 1.5}, {"label": "b", "score": 2.5}},
+		)
+		if err != nil {
+			t.Fatalf("Training failed: %v", err)
+		}
+
+		before, _, _ := engine.snapshot()
+		indexA := before.Categories["label"]["a"]
+		indexB := before.Categories["label"]["b"]
+
+		err = engine.PartialFit(
+			[]map[string]interface{}{{"x": 3.0}},
+			[]map[string]interface{}{{"label": "c", "score": 3.5}},
+		)
+		if err != nil {
+			t.Fatalf("PartialFit failed: %v", err)
+		}
+
+		model, weights, _ := engine.snapshot()
+		categories := model.Categories["label"]
+		if len(categories) != 3 {
+			t.Fatalf("%s: expected 3 categories, got %v", model.Type, categories)
+		}
+		if categories["a"] != indexA || categories["b"] != indexB || categories["c"] != 2 {
+			t.Errorf("%s: existing categories were renumbered: %v", model.Type, categories)
+		}
+		if _, exists := weights.Get("x->label:c"); !exists {
+			t.Errorf("%s: expected weights for the new category", model.Type)
+		}
+
+		prediction, err := engine.Predict(map[string]interface{}{"x": 3.0})
+		if err != nil {
+			t.Fatalf("Prediction failed: %v", err)
+		}
+		probs := prediction["label_probs"].(map[string]float64)
+		if _, ok := probs["c"]; !ok {
+			t.Errorf("%s: expected the new category in the probabilities, got %v", model.Type, probs)
+		}
+	}
+}
@@ -0,0 +1,486 @@
+package goml
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+func init() {
+	RegisterTransformer("pca", func() Transformer { return &PCA{} })
+}
+
+// PCASolver selects how the principal components are computed
+type PCASolver string
+
+const (
+	PCAExact      PCASolver = "exact"      // Eigendecomposition of the covariance matrix
+	PCARandomized PCASolver = "randomized" // Randomized truncated SVD, for data with many columns
+)
+
+// PCA reduces features to their principal components, named "pc1" to "pcN"
+// It is used as a pipeline step or, through NewPCAModel, as an unsupervised model whose
+// predictions are the components. Numeric and boolean features become one column each and
+// categorical features one column per category, named "<feature>=<value>". Missing values
+// count as the training mean, so they do not move a row along any component.
+// The randomized solver computes the leading components of the centered data with a randomized
+// truncated SVD, which avoids the covariance matrix of wide data and gives nearly the same
+// components as the exact solver
+type PCA struct {
+	Components  int       `json:"components,omitempty"`  // Number of components; default all columns
+	Solver      PCASolver `json:"solver,omitempty"`      // Default PCAExact
+	Features    []string  `json:"features,omitempty"`    // Features to decompose; empty uses every numeric, boolean and categorical feature
+	Keep        bool      `json:"keep,omitempty"`        // Keep the decomposed features next to the components
+	Oversamples int       `json:"oversamples,omitempty"` // Extra random directions of the randomized solver (default 10)
+	Iterations  int       `json:"iterations,omitempty"`  // Power iterations of the randomized solver (default 4)
+	Seed        int64     `json:"seed,omitempty"`        // Seed of the randomized solver
+
+	Inputs                 []string    `json:"inputs,omitempty"`                   // Features decomposed, learned by Fit
+	Columns                []string    `json:"columns,omitempty"`                  // Columns of the loadings, learned by Fit
+	Means                  []float64   `json:"means,omitempty"`                    // Training mean of every column
+	Loadings               [][]float64 `json:"loadings,omitempty"`                 // Unit vector of every component over the columns
+	ExplainedVariance      []float64   `json:"explained_variance,omitempty"`       // Variance of the training rows along every component
+	ExplainedVarianceRatio []float64   `json:"explained_variance_ratio,omitempty"` // Share of the total variance along every component
+}
+
+// NewPCAModel creates an unsupervised model that reduces inputs to their principal components
+// Train needs no outputs, and Predict returns the components of an input as "pc1" to "pcN"
+func NewPCAModel(pca PCA) *Model {
+	pca.Features = append([]string(nil), pca.Features...)
+	return &Model{
+		Type:          "pca",
+		Parameters:    map[string]interface{}{},
+		Decomposition: &pca,
+	}
+}
+
+// Fit learns the columns and principal components of the rows
+func (p *PCA) Fit(inputs []map[string]interface{}, outputs []map[string]interface{}) error {
+	switch p.Solver {
+	case "", PCAExact, PCARandomized:
+	default:
+		return fmt.Errorf("unknown PCA solver %q: %w", p.Solver, ErrInvalidInput)
+	}
+	if p.Components < 0 || p.Oversamples < 0 || p.Iterations < 0 {
+		return fmt.Errorf("PCA components, oversamples and iterations must not be negative: %w", ErrInvalidInput)
+	}
+
+	p.learnColumns(inputs)
+	p.Loadings, p.ExplainedVariance, p.ExplainedVarianceRatio = nil, nil, nil
+	if len(p.Columns) == 0 || len(inputs) == 0 {
+		return nil
+	}
+
+	// Center the training rows, filling missing values with the column mean
+	rows := make([][]float64, len(inputs))
+	p.Means = make([]float64, len(p.Columns))
+	present := make([][]bool, len(inputs))
+	counts := make([]int, len(p.Columns))
+	for i, input := range inputs {
+		rows[i] = make([]float64, len(p.Columns))
+		present[i] = make([]bool, len(p.Columns))
+		for j, column := range p.Columns {
+			if x, ok := p.columnValue(input, column); ok {
+				rows[i][j], present[i][j] = x, true
+				p.Means[j] += x
+				counts[j]++
+			}
+		}
+	}
+	for j := range p.Means {
+		if counts[j] > 0 {
+			p.Means[j] /= float64(counts[j])
+		}
+	}
+	totalVariance := 0.0
+	for i := range rows {
+		for j := range rows[i] {
+			if present[i][j] {
+				rows[i][j] -= p.Means[j]
+			} else {
+				rows[i][j] = 0
+			}
+			totalVariance += rows[i][j] * rows[i][j] / float64(len(rows))
+		}
+	}
+
+	k := p.Components
+	if k == 0 || k > len(p.Columns) {
+		k = len(p.Columns)
+	}
+
+	var variances []float64
+	var vectors [][]float64
+	if p.Solver == PCARandomized {
+		variances, vectors = p.randomizedComponents(rows, k)
+	} else {
+		variances, vectors = exactComponents(rows, len(p.Columns))
+	}
+	if len(vectors) > k {
+		variances, vectors = variances[:k], vectors[:k]
+	}
+
+	for c, vector := range vectors {
+		// The sign of a component is arbitrary; the largest loading is made positive so that
+		// refitting gives the same features
+		largest := 0
+		for j := range vector {
+			if math.Abs(vector[j]) > math.Abs(vector[largest]) {
+				largest = j
+			}
+		}
+		if vector[largest] < 0 {
+			for j := range vector {
+				vector[j] = -vector[j]
+			}
+		}
+
+		variance := math.Max(variances[c], 0)
+		ratio := 0.0
+		if totalVariance > 0 {
+			ratio = variance / totalVariance
+		}
+		p.Loadings = append(p.Loadings, vector)
+		p.ExplainedVariance = append(p.ExplainedVariance, variance)
+		p.ExplainedVarianceRatio = append(p.ExplainedVarianceRatio, ratio)
+	}
+	return nil
+}
+
+// learnColumns learns the decomposed features and their columns
+func (p *PCA) learnColumns(inputs []map[string]interface{}) {
+	numeric := make(map[string]bool)
+	categories := make(map[string]map[string]int)
+	for _, input := range inputs {
+		for feature, val := range input {
+			if len(p.Features) > 0 && !containsString(p.Features, feature) {
+				continue
+			}
+			switch {
+			case isMissing(val):
+			case IsSupportedNumericType(val) || IsSupportedBooleanType(val):
+				numeric[feature] = true
+			default:
+				if category, isString := val.(string); isString {
+					if categories[feature] == nil {
+						categories[feature] = make(map[string]int)
+					}
+					categories[feature][category] = 0
+				}
+			}
+		}
+	}
+
+	inputSet := make(map[string]int)
+	p.Columns = nil
+	for feature := range numeric {
+		inputSet[feature] = 0
+		p.Columns = append(p.Columns, feature)
+	}
+	for feature, values := range categories {
+		inputSet[feature] = 0
+		for _, category := range sortedCategories(values) {
+			p.Columns = append(p.Columns, feature+"="+category)
+		}
+	}
+	sort.Strings(p.Columns)
+	p.Inputs = sortedCategories(inputSet)
+}
+
+// columnValue returns the value of a column in a row
+// A categorical column "<feature>=<value>" is 1 when the feature has that value and 0 otherwise
+func (p *PCA) columnValue(row map[string]interface{}, column string) (float64, bool) {
+	if val, exists := row[column]; exists {
+		return numericValue(val)
+	}
+	feature, category, found := strings.Cut(column, "=")
+	if !found {
+		return 0, false
+	}
+	val, isString := row[feature].(string)
+	if !isString {
+		return 0, false
+	}
+	if val == category {
+		return 1, true
+	}
+	return 0, true
+}
+
+// project returns the components of a row
+func (p *PCA) project(row map[string]interface{}) []float64 {
+	centered := make([]float64, len(p.Columns))
+	for j, column := range p.Columns {
+		if x, ok := p.columnValue(row, column); ok {
+			centered[j] = x - p.Means[j]
+		}
+	}
+
+	components := make([]float64, len(p.Loadings))
+	for c, loading := range p.Loadings {
+		for j, weight := range loading {
+			components[c] += weight * centered[j]
+		}
+	}
+	return components
+}
+
+// Transform replaces the decomposed features of a row with its components
+func (p *PCA) Transform(input map[string]interface{}) map[string]interface{} {
+	components := p.project(input)
+	if !p.Keep {
+		for _, feature := range p.Inputs {
+			delete(input, feature)
+		}
+	}
+	for c, value := range components {
+		input[fmt.Sprintf("pc%d", c+1)] = value
+	}
+	return input
+}
+
+// predict returns the components of a row as the prediction of a PCA model
+func (p *PCA) predict(input map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(p.Loadings))
+	for c, value := range p.project(input) {
+		result[fmt.Sprintf("pc%d", c+1)] = value
+	}
+	return result
+}
+
+// exactComponents returns the variances and unit vectors of all principal components of
+// centered rows, largest first
+func exactComponents(rows [][]float64, columns int) ([]float64, [][]float64) {
+	covariance := make([][]float64, columns)
+	for a := range covariance {
+		covariance[a] = make([]float64, columns)
+	}
+	for _, row := range rows {
+		for a := 0; a < columns; a++ {
+			if row[a] == 0 {
+				continue
+			}
+			for b := a; b < columns; b++ {
+				covariance[a][b] += row[a] * row[b]
+			}
+		}
+	}
+	for a := 0; a < columns; a++ {
+		for b := a; b < columns; b++ {
+			covariance[a][b] /= float64(len(rows))
+			covariance[b][a] = covariance[a][b]
+		}
+	}
+	return symmetricEigen(covariance)
+}
+
+// randomizedComponents returns the variances and unit vectors of the leading k principal
+// components of centered rows, largest first
+// The rows are projected onto k plus Oversamples random directions, which power iterations
+// turn towards the leading components; the SVD of the much smaller projected matrix then
+// gives the components (Halko, Martinsson and Tropp, 2011)
+func (p *PCA) randomizedComponents(rows [][]float64, k int) ([]float64, [][]float64) {
+	n, columns := len(rows), len(rows[0])
+	oversamples, iterations := p.Oversamples, p.Iterations
+	if oversamples == 0 {
+		oversamples = 10
+	}
+	if iterations == 0 {
+		iterations = 4
+	}
+	size := min(k+oversamples, n, columns)
+
+	rng := rand.New(rand.NewSource(p.Seed))
+	directions := make([][]float64, size)
+	for d := range directions {
+		directions[d] = make([]float64, columns)
+		for j := range directions[d] {
+			directions[d][j] = rng.NormFloat64()
+		}
+	}
+
+	// basis holds orthonormal vectors over the rows spanning the leading components
+	basis := orthonormalize(projectRows(rows, directions))
+	for i := 0; i < iterations; i++ {
+		directions = orthonormalize(projectColumns(rows, basis))
+		basis = orthonormalize(projectRows(rows, directions))
+	}
+
+	// small is the basis applied to the rows; its row space holds the components
+	small := projectColumns(rows, basis)
+	gram := make([][]float64, len(small))
+	for a := range small {
+		gram[a] = make([]float64, len(small))
+		for b := range small {
+			gram[a][b] = dot(small[a], small[b])
+		}
+	}
+	squares, vectors := symmetricEigen(gram)
+
+	var variances []float64
+	var components [][]float64
+	for c, vector := range vectors {
+		if len(components) == k || squares[c] <= 1e-12 {
+			break
+		}
+		component := make([]float64, columns)
+		for a, weight := range vector {
+			for j := range component {
+				component[j] += weight * small[a][j]
+			}
+		}
+		norm := math.Sqrt(dot(component, component))
+		for j := range component {
+			component[j] /= norm
+		}
+		variances = append(variances, squares[c]/float64(n))
+		components = append(components, component)
+	}
+	return variances, components
+}
+
+// projectRows returns, for every direction over the columns, the projection of every row
+func projectRows(rows [][]float64, directions [][]float64) [][]float64 {
+	projected := make([][]float64, len(directions))
+	for d, direction := range directions {
+		projected[d] = make([]float64, len(rows))
+		for i, row := range rows {
+			projected[d][i] = dot(row, direction)
+		}
+	}
+	return projected
+}
+
+// projectColumns returns, for every vector over the rows, the weighted sum of the rows
+func projectColumns(rows [][]float64, vectors [][]float64) [][]float64 {
+	projected := make([][]float64, len(vectors))
+	for v, vector := range vectors {
+		projected[v] = make([]float64, len(rows[0]))
+		for i, row := range rows {
+			if vector[i] == 0 {
+				continue
+			}
+			for j, x := range row {
+				projected[v][j] += vector[i] * x
+			}
+		}
+	}
+	return projected
+}
+
+// orthonormalize makes vectors orthonormal in place with modified Gram-Schmidt; vectors that
+// depend on earlier ones become zero
+func orthonormalize(vectors [][]float64) [][]float64 {
+	for a := range vectors {
+		for b := 0; b < a; b++ {
+			projection := dot(vectors[a], vectors[b])
+			for j := range vectors[a] {
+				vectors[a][j] -= projection * vectors[b][j]
+			}
+		}
+		norm := math.Sqrt(dot(vectors[a], vectors[a]))
+		for j := range vectors[a] {
+			if norm > 1e-10 {
+				vectors[a][j] /= norm
+			} else {
+				vectors[a][j] = 0
+			}
+		}
+	}
+	return vectors
+}
+
+// dot returns the dot product of two vectors
+func dot(a []float64, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// symmetricEigen returns the eigenvalues and unit eigenvectors of a symmetric matrix, largest
+// eigenvalue first, using cyclic Jacobi rotations
+func symmetricEigen(matrix [][]float64) ([]float64, [][]float64) {
+	n := len(matrix)
+	a := make([][]float64, n)
+	v := make([][]float64, n)
+	for i := range a {
+		a[i] = append([]float64(nil), matrix[i]...)
+		v[i] = make([]float64, n)
+		v[i][i] = 1
+	}
+
+	for sweep := 0; sweep < 100; sweep++ {
+		offDiagonal, diagonal := 0.0, 0.0
+		for i := 0; i < n; i++ {
+			diagonal += a[i][i] * a[i][i]
+			for j := i + 1; j < n; j++ {
+				offDiagonal += a[i][j] * a[i][j]
+			}
+		}
+		if offDiagonal <= 1e-22*math.Max(diagonal, 1e-300) {
+			break
+		}
+
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+				// Rotate rows and columns p and q so that a[p][q] becomes zero
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p], a[k][q] = c*akp-s*akq, s*akp+c*akq
+				}
+				for k := 0; k < n; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k], a[q][k] = c*apk-s*aqk, s*apk+c*aqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p], v[k][q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return a[order[i]][order[i]] > a[order[j]][order[j]] })
+
+	values := make([]float64, n)
+	vectors := make([][]float64, n)
+	for i, idx := range order {
+		values[i] = a[idx][idx]
+		vectors[i] = make([]float64, n)
+		for k := 0; k < n; k++ {
+			vectors[i][k] = v[k][idx]
+		}
+	}
+	return values, vectors
+}
+
+// PCA returns a copy of the fitted decomposition of a PCA model
+func (e *Engine) PCA() (*PCA, error) {
+	model, _, _ := e.snapshot()
+	if model == nil {
+		return nil, fmt.Errorf("model not initialized")
+	}
+	if model.Type != "pca" || model.Decomposition == nil {
+		return nil, fmt.Errorf("model type %q has no decomposition: %w", model.Type, ErrUnsupportedModelType)
+	}
+	clone, err := model.clone()
+	if err != nil {
+		return nil, err
+	}
+	return clone.Decomposition, nil
+}
@@ -0,0 +1,263 @@
+package goml
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// assertClose fails when two slices differ by more than tolerance
+func assertClose(t *testing.T, name string, got []float64, want []float64, tolerance float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %s %v, got %v", name, want, got)
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > tolerance {
+			t.Errorf("Expected %s %v, got %v", name, want, got)
+			return
+		}
+	}
+}
+
+// TestPCAComponents tests the components, explained variance and categorical columns
+func TestPCAComponents(t *testing.T) {
+	inputs := []map[string]interface{}{
+		{"a": 2.0, "b": 0.0},
+		{"a": -2.0, "b": 0.0},
+		{"a": 0.0, "b": 1.0},
+		{"a": 0.0, "b": -1.0},
+	}
+	pca := &PCA{}
+	if err := pca.Fit(inputs, nil); err != nil {
+		t.Fatalf("Fitting failed: %v", err)
+	}
+	if !reflect.DeepEqual(pca.Columns, []string{"a", "b"}) {
+		t.Errorf("Expected columns a and b, got %v", pca.Columns)
+	}
+	assertClose(t, "explained variance", pca.ExplainedVariance, []float64{2, 0.5}, 1e-9)
+	assertClose(t, "explained variance ratio", pca.ExplainedVarianceRatio, []float64{0.8, 0.2}, 1e-9)
+	assertClose(t, "first loading", pca.Loadings[0], []float64{1, 0}, 1e-9)
+	assertClose(t, "second loading", pca.Loadings[1], []float64{0, 1}, 1e-9)
+
+	row := pca.Transform(map[string]interface{}{"a": 1.0, "other": "kept"})
+	expected := map[string]interface{}{"pc1": 1.0, "pc2": 0.0, "other": "kept"}
+	if !reflect.DeepEqual(row, expected) {
+		t.Errorf("Expected %v with a missing b counted as its mean, got %v", expected, row)
+	}
+
+	categorical := &PCA{Components: 1}
+	err := categorical.Fit([]map[string]interface{}{
+		{"size": 1.0, "color": "red"},
+		{"size": 3.0, "color": "blue"},
+		{"size": 1.0, "color": "red"},
+		{"size": 3.0, "color": "blue"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Fitting failed: %v", err)
+	}
+	if !reflect.DeepEqual(categorical.Columns, []string{"color=blue", "color=red", "size"}) {
+		t.Errorf("Expected one column per category, got %v", categorical.Columns)
+	}
+	assertClose(t, "explained variance ratio", categorical.ExplainedVarianceRatio, []float64{1}, 1e-9)
+	assertClose(t, "loading", categorical.Loadings[0], []float64{1 / math.Sqrt(6), -1 / math.Sqrt(6), 2 / math.Sqrt(6)}, 1e-9)
+}
+
+// TestPCARandomized tests that the randomized solver finds the components of the exact solver
+func TestPCARandomized(t *testing.T) {
+	// Three latent factors spread over many columns, plus a little noise
+	rng := rand.New(rand.NewSource(7))
+	mixing := make([][]float64, 3)
+	for f := range mixing {
+		mixing[f] = make([]float64, 30)
+		for j := range mixing[f] {
+			mixing[f][j] = rng.NormFloat64()
+		}
+	}
+	var inputs []map[string]interface{}
+	for i := 0; i < 60; i++ {
+		row := make(map[string]interface{})
+		factors := []float64{3 * rng.NormFloat64(), 2 * rng.NormFloat64(), rng.NormFloat64()}
+		for j := 0; j < 30; j++ {
+			x := 0.01 * rng.NormFloat64()
+			for f := range factors {
+				x += factors[f] * mixing[f][j]
+			}
+			row[string(rune('A'+j%26))+string(rune('a'+j/26))] = x
+		}
+		inputs = append(inputs, row)
+	}
+
+	exact := &PCA{Components: 3}
+	randomized := &PCA{Components: 3, Solver: PCARandomized, Seed: 1}
+	for _, pca := range []*PCA{exact, randomized} {
+		if err := pca.Fit(inputs, nil); err != nil {
+			t.Fatalf("Fitting failed: %v", err)
+		}
+	}
+
+	assertClose(t, "explained variance", randomized.ExplainedVariance, exact.ExplainedVariance, 1e-6*exact.ExplainedVariance[0])
+	for c := range exact.Loadings {
+		assertClose(t, "loading", randomized.Loadings[c], exact.Loadings[c], 1e-4)
+	}
+	if total := exact.ExplainedVarianceRatio[0] + exact.ExplainedVarianceRatio[1] + exact.ExplainedVarianceRatio[2]; total < 0.999 {
+		t.Errorf("Expected three components to explain the data, got ratios %v", exact.ExplainedVarianceRatio)
+	}
+}
+
+// TestPCAModel tests training a PCA model without outputs and reloading it from JSON
+func TestPCAModel(t *testing.T) {
+	inputs := []map[string]interface{}{
+		{"a": 1.0, "b": 2.0},
+		{"a": 2.0, "b": 4.1},
+		{"a": 3.0, "b": 5.9},
+		{"a": 4.0, "b": 8.0},
+	}
+
+	engine := New()
+	engine.WithModel(NewPCAModel(PCA{Components: 1}).JSON())
+	if err := engine.Train(inputs, nil); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	prediction, err := engine.Predict(map[string]interface{}{"a": 2.5, "b": 5.0})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if len(prediction) != 1 || math.Abs(prediction["pc1"].(float64)) > 0.1 {
+		t.Errorf("Expected the center of the data near pc1 0, got %v", prediction)
+	}
+
+	pca, err := engine.PCA()
+	if err != nil {
+		t.Fatalf("Getting the decomposition failed: %v", err)
+	}
+	if pca.ExplainedVarianceRatio[0] < 0.99 {
+		t.Errorf("Expected one component to explain nearly all variance, got %v", pca.ExplainedVarianceRatio)
+	}
+
+	modelJSON, _ := engine.GetModel()
+	weightsJSON, _ := engine.GetWeights()
+	reloaded := New()
+	reloaded.WithModel(*modelJSON)
+	reloaded.WithWeights(*weightsJSON)
+	again, err := reloaded.Predict(map[string]interface{}{"a": 2.5, "b": 5.0})
+	if err != nil || !reflect.DeepEqual(again, prediction) {
+		t.Errorf("Expected the reloaded model to predict %v, got %v (%v)", prediction, again, err)
+	}
+
+	if err := engine.PartialFit(inputs, make([]map[string]interface{}, len(inputs))); !errors.Is(err, ErrUnsupportedModelType) {
+		t.Errorf("Expected ErrUnsupportedModelType for partial fit, got %v", err)
+	}
+	engine.WithModel(NewPCAModel(PCA{Solver: "nmf"}).JSON())
+	if err := engine.Train(inputs, nil); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for an unknown solver, got %v", err)
+	}
+	linear := New()
+	linear.WithModel(NewLinearModel().JSON())
+	if _, err := linear.PCA(); !errors.Is(err, ErrUnsupportedModelType) {
+		t.Errorf("Expected ErrUnsupportedModelType for a linear model, got %v", err)
+	}
+}
+
+// TestPCAPipeline tests PCA as a pipeline step that is saved with the model
+func TestPCAPipeline(t *testing.T) {
+	var inputs, outputs []map[string]interface{}
+	for i := 0; i < 20; i++ {
+		x := float64(i)
+		label := "low"
+		if i >= 10 {
+			label = "high"
+		}
+		inputs = append(inputs, map[string]interface{}{"a": x, "b": 2*x + float64(i%3), "id": float64(i % 2)})
+		outputs = append(outputs, map[string]interface{}{"label": label})
+	}
+
+	engine := New()
+	engine.WithModel(NewCategoricalModel().WithPipeline(NewPipeline(&PCA{Components: 1, Features: []string{"a", "b"}})).JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 100, BatchSize: 5})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	_, weights, _ := engine.snapshot()
+	for _, key := range []string{"pc1->label:high", "id->label:high"} {
+		if _, exists := weights.Get(key); !exists {
+			t.Errorf("Expected weight %s", key)
+		}
+	}
+	if _, exists := weights.Get("a->label:high"); exists {
+		t.Errorf("Expected the decomposed features to be replaced by the components")
+	}
+
+	modelJSON, _ := engine.GetModel()
+	var saved Model
+	if err := json.Unmarshal([]byte(*modelJSON), &saved); err != nil {
+		t.Fatalf("Loading the model failed: %v", err)
+	}
+	pca, ok := saved.Pipeline.Steps[0].(*PCA)
+	if !ok || len(pca.Loadings) != 1 || !reflect.DeepEqual(pca.Columns, []string{"a", "b"}) {
+		t.Errorf("Expected the fitted PCA step to be saved, got %+v", saved.Pipeline.Steps[0])
+	}
+
+	prediction, err := engine.Predict(map[string]interface{}{"a": 18.0, "b": 37.0, "id": 0.0})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if prediction["label"] != "high" {
+		t.Errorf("Expected high, got %v", prediction["label"])
+	}
+}
+
+// TestPCASupervised tests linear and logistic models trained on centered principal components
+func TestPCASupervised(t *testing.T) {
+	var inputs, outputs []map[string]interface{}
+	for i := 0; i < 40; i++ {
+		a, b := float64(i%8), float64(i%5)
+		inputs = append(inputs, map[string]interface{}{"a": a, "b": b, "c": a + b})
+		outputs = append(outputs, map[string]interface{}{"y": a + 2*b, "flag": indicator(a+b > 5)})
+	}
+
+	for _, model := range []*Model{NewLinearModel(), NewLogisticModel()} {
+		engine := New()
+		engine.WithModel(model.WithPipeline(NewPipeline(&PCA{Components: 2})).JSON())
+		engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 100, BatchSize: 8})
+		if err := engine.Train(inputs, outputs); err != nil {
+			t.Fatalf("Training the %s model failed: %v", model.Type, err)
+		}
+
+		prediction, err := engine.Predict(map[string]interface{}{"a": 7.0, "b": 4.0, "c": 11.0})
+		if err != nil {
+			t.Fatalf("Prediction of the %s model failed: %v", model.Type, err)
+		}
+		for target, val := range prediction {
+			if f, ok := val.(float64); !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+				t.Errorf("Expected a finite %s prediction for %s, got %v", model.Type, target, val)
+			}
+		}
+	}
+}
+
+// TestPCATrainStream tests fitting a PCA model on a dataset without targets
+func TestPCATrainStream(t *testing.T) {
+	data := strings.Join([]string{`{"a": 1, "b": 2}`, `{"a": 2, "b": 4.1}`, `{"a": 3, "b": 6}`}, "\n")
+
+	engine := New()
+	engine.WithModel(NewPCAModel(PCA{Components: 1}).JSON())
+	if err := engine.TrainStream(NewJSONLReader(strings.NewReader(data), nil, nil)); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+
+	pca, err := engine.PCA()
+	if err != nil {
+		t.Fatalf("Getting the decomposition failed: %v", err)
+	}
+	assertClose(t, "means", pca.Means, []float64{2, 12.1 / 3}, 1e-9)
+	if len(pca.Loadings) != 1 || pca.ExplainedVarianceRatio[0] < 0.99 {
+		t.Errorf("Expected one dominant component, got %+v", pca)
+	}
+}
@@ -0,0 +1,353 @@
+package goml
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Transformer is a preprocessing step of a Pipeline
+// Fit learns the step's state from training rows, after the previous steps have been applied.
+// Transform receives a row it may modify and returns the transformed row; it is called
+// concurrently by predictions, so it must not change the step. Steps are saved with the model
+// JSON, so their configuration and learned state must be exported fields, and their type
+// must be registered with RegisterTransformer
+type Transformer interface {
+	Fit(inputs []map[string]interface{}, outputs []map[string]interface{}) error
+	Transform(input map[string]interface{}) map[string]interface{}
+}
+
+var (
+	transformersMu   sync.RWMutex
+	transformerTypes = make(map[string]func() Transformer)
+	transformerNames = make(map[reflect.Type]string)
+	transformFuncs   = make(map[string]func(map[string]interface{}) map[string]interface{})
+)
+
+// RegisterTransformer registers a Transformer type under a name so that pipelines containing
+// it can be saved and loaded. The factory returns a new, empty step of the type
+func RegisterTransformer(name string, factory func() Transformer) {
+	transformersMu.Lock()
+	defer transformersMu.Unlock()
+	transformerTypes[name] = factory
+	transformerNames[reflect.TypeOf(factory())] = name
+}
+
+// RegisterFunc registers a row function under a name for use in a FuncTransform
+// Functions cannot be serialized, so the name is saved and the function must be registered
+// again in any program that loads the model
+func RegisterFunc(name string, fn func(map[string]interface{}) map[string]interface{}) {
+	transformersMu.Lock()
+	defer transformersMu.Unlock()
+	transformFuncs[name] = fn
+}
+
+func init() {
+	RegisterTransformer("scaler", func() Transformer { return &Scaler{} })
+	RegisterTransformer("features", func() Transformer { return &FeatureTransform{} })
+	RegisterTransformer("func", func() Transformer { return &FuncTransform{} })
+}
+
+// Pipeline is a chain of preprocessing steps run before the model
+// It is stored in Model.Pipeline: Train fits the steps in order and trains the model on their
+// output, and Predict runs the fitted steps on every input. The per-feature preprocessing in
+// Model.Features runs before the pipeline
+type Pipeline struct {
+	Steps []Transformer
+}
+
+// NewPipeline creates a pipeline from steps, which are run in order
+func NewPipeline(steps ...Transformer) *Pipeline {
+	return &Pipeline{Steps: steps}
+}
+
+// pipelineStep is the JSON form of a step
+type pipelineStep struct {
+	Type   string          `json:"type"`
+	Params json.RawMessage `json:"params"`
+}
+
+// MarshalJSON saves every step with its registered type name
+func (p *Pipeline) MarshalJSON() ([]byte, error) {
+	steps := make([]pipelineStep, len(p.Steps))
+	for i, step := range p.Steps {
+		transformersMu.RLock()
+		name, registered := transformerNames[reflect.TypeOf(step)]
+		transformersMu.RUnlock()
+		if !registered {
+			return nil, fmt.Errorf("pipeline step %d: type %T is not registered", i, step)
+		}
+
+		params, err := json.Marshal(step)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline step %d: %w", i, err)
+		}
+		steps[i] = pipelineStep{Type: name, Params: params}
+	}
+	return json.Marshal(map[string]interface{}{"steps": steps})
+}
+
+// UnmarshalJSON loads steps of registered types
+func (p *Pipeline) UnmarshalJSON(data []byte) error {
+	var saved struct {
+		Steps []pipelineStep `json:"steps"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+
+	p.Steps = make([]Transformer, len(saved.Steps))
+	for i, s := range saved.Steps {
+		transformersMu.RLock()
+		factory, registered := transformerTypes[s.Type]
+		transformersMu.RUnlock()
+		if !registered {
+			return fmt.Errorf("pipeline step %d: unknown type %q", i, s.Type)
+		}
+
+		step := factory()
+		if len(s.Params) > 0 {
+			if err := json.Unmarshal(s.Params, step); err != nil {
+				return fmt.Errorf("pipeline step %d: %w", i, err)
+			}
+		}
+		p.Steps[i] = step
+	}
+	return nil
+}
+
+// fit fits the steps in order, each on the output of the previous ones
+func (p *Pipeline) fit(inputs []map[string]interface{}, outputs []map[string]interface{}) error {
+	rows := inputs
+	for i, step := range p.Steps {
+		if err := step.Fit(rows, outputs); err != nil {
+			return fmt.Errorf("pipeline step %d: %w", i, err)
+		}
+
+		// The last step's output is not needed here
+		if i == len(p.Steps)-1 {
+			break
+		}
+		transformed := make([]map[string]interface{}, len(rows))
+		for j, row := range rows {
+			transformed[j] = step.Transform(copyRow(row))
+		}
+		rows = transformed
+	}
+	return nil
+}
+
+// transform runs the fitted steps on a copy of the row
+func (p *Pipeline) transform(input map[string]interface{}) map[string]interface{} {
+	row := copyRow(input)
+	for _, step := range p.Steps {
+		row = step.Transform(row)
+	}
+	return row
+}
+
+// WithPipeline sets the preprocessing steps run before the model
+func (m *Model) WithPipeline(pipeline *Pipeline) *Model {
+	m.Pipeline = pipeline
+	return m
+}
+
+// WithPipeline sets the preprocessing steps of the engine's model
+// Train fits the steps and Predict runs them, so both see the same features. The fitted steps
+// are saved with the model by GetModel
+func (e *Engine) WithPipeline(pipeline *Pipeline) error {
+	e.trainMu.Lock()
+	defer e.trainMu.Unlock()
+
+	model, _, _ := e.snapshot()
+	if model == nil {
+		return fmt.Errorf("model not initialized")
+	}
+
+	// The published model is never mutated, so the pipeline goes on a copy
+	updated, err := model.clone()
+	if err != nil {
+		return err
+	}
+	updated.Pipeline = pipeline
+
+	e.mu.Lock()
+	e.model = updated
+	e.mu.Unlock()
+	return nil
+}
+
+// copyRow returns a shallow copy of a row
+func copyRow(input map[string]interface{}) map[string]interface{} {
+	row := make(map[string]interface{}, len(input))
+	for key, val := range input {
+		row[key] = val
+	}
+	return row
+}
+
+// ScaleMethod selects how a Scaler rescales numeric features
+type ScaleMethod string
+
+const (
+	ScaleStandard ScaleMethod = "standard" // Subtract the mean and divide by the standard deviation
+	ScaleMinMax   ScaleMethod = "minmax"   // Map the training range to [0, 1]
+)
+
+// Scaler rescales numeric features with statistics learned from the training rows
+// Non-numeric values and features not seen during training are left unchanged
+type Scaler struct {
+	Method   ScaleMethod              `json:"method"`
+	Features []string                 `json:"features,omitempty"` // Features to scale; empty scales every numeric feature
+	Stats    map[string]*FeatureStats `json:"stats,omitempty"`    // Learned by Fit
+}
+
+// Fit learns the statistics of the numeric features
+func (s *Scaler) Fit(inputs []map[string]interface{}, outputs []map[string]interface{}) error {
+	switch s.Method {
+	case ScaleStandard, ScaleMinMax:
+	default:
+		return fmt.Errorf("unknown scale method %q: %w", s.Method, ErrInvalidInput)
+	}
+
+	selected := make(map[string]bool, len(s.Features))
+	for _, feature := range s.Features {
+		selected[feature] = true
+	}
+
+	s.Stats = make(map[string]*FeatureStats)
+	for _, input := range inputs {
+		for feature, val := range input {
+			if !IsSupportedNumericType(val) || (len(selected) > 0 && !selected[feature]) {
+				continue
+			}
+			x, _ := ConvertToFloat64(val, "")
+			if isMissing(x) {
+				continue
+			}
+
+			stats, exists := s.Stats[feature]
+			if !exists {
+				stats = &FeatureStats{}
+				s.Stats[feature] = stats
+			}
+			stats.Add(x)
+		}
+	}
+	return nil
+}
+
+// Transform rescales the numeric features of a row
+func (s *Scaler) Transform(input map[string]interface{}) map[string]interface{} {
+	for feature, stats := range s.Stats {
+		val, exists := input[feature]
+		if !exists || !IsSupportedNumericType(val) {
+			continue
+		}
+		x, _ := ConvertToFloat64(val, "")
+
+		switch s.Method {
+		case ScaleStandard:
+			x -= stats.Mean()
+			if std := stats.StdDev(); std > 0 {
+				x /= std
+			}
+		case ScaleMinMax:
+			x -= stats.Min
+			if span := stats.Max - stats.Min; span > 0 {
+				x /= span
+			}
+		}
+		input[feature] = x
+	}
+	return input
+}
+
+// FeatureTransform applies per-feature preprocessing, such as imputation, date expansion or
+// text vectorization, as a pipeline step. It learns the same state as Model.Features
+type FeatureTransform struct {
+	Features map[string]*FeatureSpec `json:"features"`
+}
+
+// ImputeStep creates a step that fills missing values of the given features
+func ImputeStep(imputer Imputer, features ...string) *FeatureTransform {
+	return &FeatureTransform{Features: (&Model{}).WithImputer(imputer, features...).Features}
+}
+
+// EncodeStep creates a step that encodes the given categorical features
+// Pipeline steps transform training rows like any other rows, so target encoding in a step is
+// not out-of-fold; prefer Model.WithEncoder for target encoding
+func EncodeStep(encoder Encoder, features ...string) *FeatureTransform {
+	return &FeatureTransform{Features: (&Model{}).WithEncoder(encoder, features...).Features}
+}
+
+// BinStep creates a step that bins the given numeric features
+func BinStep(binner Binner, features ...string) *FeatureTransform {
+	return &FeatureTransform{Features: (&Model{}).WithBinner(binner, features...).Features}
+}
+
+// TimeStep creates a step that expands the given date/time features
+func TimeStep(timeFeatures TimeFeatures, features ...string) *FeatureTransform {
+	return &FeatureTransform{Features: (&Model{}).WithTime(timeFeatures, features...).Features}
+}
+
+// TextStep creates a step that vectorizes the given text features
+func TextStep(vectorizer TextVectorizer, features ...string) *FeatureTransform {
+	return &FeatureTransform{Features: (&Model{}).WithText(vectorizer, features...).Features}
+}
+
+// Fit learns the state of the feature specs
+func (t *FeatureTransform) Fit(inputs []map[string]interface{}, outputs []map[string]interface{}) error {
+	return (&Model{Features: t.Features}).fitPreprocessing(inputs, outputs)
+}
+
+// Transform applies the feature specs to a row
+func (t *FeatureTransform) Transform(input map[string]interface{}) map[string]interface{} {
+	return (&Model{Features: t.Features}).applyFeatureSpecs(input, -1)
+}
+
+// FuncTransform applies a function registered with RegisterFunc
+// It has no state to learn, so the same function must be registered wherever the model is used
+type FuncTransform struct {
+	Name string `json:"name"`
+}
+
+// Fit checks that the function is registered
+func (f *FuncTransform) Fit(inputs []map[string]interface{}, outputs []map[string]interface{}) error {
+	if f.function() == nil {
+		return fmt.Errorf("function %q is not registered: %w", f.Name, ErrInvalidInput)
+	}
+	return nil
+}
+
+// Transform calls the function; rows pass through unchanged if it is not registered
+func (f *FuncTransform) Transform(input map[string]interface{}) map[string]interface{} {
+	fn := f.function()
+	if fn == nil {
+		return input
+	}
+	return fn(input)
+}
+
+// UnmarshalJSON loads the step and checks that its function is registered
+func (f *FuncTransform) UnmarshalJSON(data []byte) error {
+	var saved struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	f.Name = saved.Name
+	if f.function() == nil {
+		return fmt.Errorf("function %q is not registered", f.Name)
+	}
+	return nil
+}
+
+// function returns the registered function, or nil
+func (f *FuncTransform) function() func(map[string]interface{}) map[string]interface{} {
+	transformersMu.RLock()
+	defer transformersMu.RUnlock()
+	return transformFuncs[f.Name]
+}
@@ -0,0 +1,192 @@
+package goml
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pipelineRows returns rows where the class depends on the area of a rectangle
+func pipelineRows() ([]map[string]interface{}, []map[string]interface{}) {
+	var inputs, outputs []map[string]interface{}
+	for w := 1; w <= 6; w++ {
+		for h := 1; h <= 6; h++ {
+			size := "small"
+			if w*h > 12 {
+				size = "large"
+			}
+			inputs = append(inputs, map[string]interface{}{"width": float64(w), "height": float64(h)})
+			outputs = append(outputs, map[string]interface{}{"size": size})
+		}
+	}
+	return inputs, outputs
+}
+
+// TestPipelineTrainPredict tests that Train fits the steps, Predict runs them and both survive a save and load
+func TestPipelineTrainPredict(t *testing.T) {
+	RegisterFunc("test_area", func(row map[string]interface{}) map[string]interface{} {
+		w, _ := row["width"].(float64)
+		h, _ := row["height"].(float64)
+		row["area"] = w * h
+		return row
+	})
+
+	engine := New()
+	engine.WithModel(NewCategoricalModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 100, BatchSize: 36})
+	if err := engine.WithPipeline(NewPipeline(&FuncTransform{Name: "test_area"}, &Scaler{Method: ScaleStandard})); err != nil {
+		t.Fatalf("Failed to set pipeline: %v", err)
+	}
+
+	inputs, outputs := pipelineRows()
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	// The scaler was fitted on the output of the function step
+	model, weights, _ := engine.snapshot()
+	scaler := model.Pipeline.Steps[1].(*Scaler)
+	if stats := scaler.Stats["area"]; stats == nil || stats.Count != 36 {
+		t.Fatalf("Expected the scaler to learn the area statistics, got %+v", stats)
+	}
+	if _, exists := weights.Get("area->size:large"); !exists {
+		t.Error("Expected a weight for the generated area feature")
+	}
+	if mean := model.Stats["area"].Mean(); math.Abs(mean) > 1e-9 {
+		t.Errorf("Expected the model to be trained on scaled features, area mean is %v", mean)
+	}
+
+	input := map[string]interface{}{"width": 6.0, "height": 5.0}
+	expected, err := engine.Predict(input)
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if expected["size"] != "large" {
+		t.Errorf("Expected large, got %v", expected["size"])
+	}
+	if len(input) != 2 {
+		t.Errorf("Predict modified the caller's input: %v", input)
+	}
+
+	// The fitted steps are saved with the model
+	modelJSON, _ := engine.GetModel()
+	weightsJSON, _ := engine.GetWeights()
+	if !strings.Contains(*modelJSON, `"type":"scaler"`) {
+		t.Errorf("Expected the pipeline in the model JSON, got %s", *modelJSON)
+	}
+
+	loaded := New()
+	if _, err := loaded.WithModel(*modelJSON); err != nil {
+		t.Fatalf("Failed to load model: %v", err)
+	}
+	loaded.WithWeights(*weightsJSON)
+	prediction, err := loaded.Predict(input)
+	if err != nil {
+		t.Fatalf("Prediction with the loaded model failed: %v", err)
+	}
+	if !reflect.DeepEqual(prediction, expected) {
+		t.Errorf("Expected the loaded model to predict %v, got %v", expected, prediction)
+	}
+
+	// Standardized features have a mean of about zero, which must not normalize linear gradients
+	linear := New()
+	linear.WithModel(NewLinearModel().WithPipeline(NewPipeline(&Scaler{Method: ScaleStandard})).JSON())
+	linear.WithConfig(&Config{LearningRate: 0.1, Epochs: 200, BatchSize: 36})
+	areas := make([]map[string]interface{}, len(inputs))
+	for i, input := range inputs {
+		areas[i] = map[string]interface{}{"y": 2*input["width"].(float64) + input["height"].(float64)}
+	}
+	if err := linear.Train(inputs, areas); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+	prediction, err = linear.Predict(input)
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if y, _ := prediction["y"].(float64); math.Abs(y-17) > 0.1 {
+		t.Errorf("Expected the linear model to predict 17 on standardized features, got %v", prediction)
+	}
+	if weightsJSON, _ := linear.GetWeights(); !strings.Contains(*weightsJSON, "width") {
+		t.Errorf("Expected the trained weights, got %s", *weightsJSON)
+	}
+}
+
+// TestPipelineTrainStream tests that streaming training fits the same steps as Train
+func TestPipelineTrainStream(t *testing.T) {
+	model := NewLinearModel().WithPipeline(NewPipeline(
+		ImputeStep(Imputer{Strategy: ImputeMean}, "width"),
+		&Scaler{Method: ScaleMinMax, Features: []string{"width"}},
+	))
+
+	inputs := []map[string]interface{}{{"width": 2.0}, {"width": nil}, {"width": 6.0}, {"height": 1.0}}
+	outputs := []map[string]interface{}{{"y": 1.0}, {"y": 2.0}, {"y": 3.0}, {"y": 2.0}}
+
+	batch := New()
+	batch.WithModel(model.JSON())
+	batch.WithConfig(&Config{LearningRate: 0.01, Epochs: 3, BatchSize: 2})
+	if err := batch.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	streamed := New()
+	streamed.WithModel(model.JSON())
+	streamed.WithConfig(&Config{LearningRate: 0.01, Epochs: 3, BatchSize: 2})
+	if err := streamed.TrainStream(NewSliceDataset(inputs, outputs)); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+
+	batchModel, _, _ := batch.snapshot()
+	streamedModel, _, _ := streamed.snapshot()
+	for _, m := range []*Model{batchModel, streamedModel} {
+		imputer := m.Pipeline.Steps[0].(*FeatureTransform).Features["width"].Impute
+		if imputer.Value != 4.0 {
+			t.Errorf("Expected the mean width 4 as fill value, got %v", imputer.Value)
+		}
+		scaler := m.Pipeline.Steps[1].(*Scaler)
+		if stats := scaler.Stats["width"]; stats == nil || stats.Count != 4 || len(scaler.Stats) != 1 {
+			t.Errorf("Expected scaling statistics for width only over the imputed rows, got %+v", scaler.Stats)
+		}
+		if stats := m.Stats["width"]; stats == nil || stats.Max != 1 {
+			t.Errorf("Expected model statistics of the scaled width, got %+v", stats)
+		}
+	}
+}
+
+// TestPipelineErrors tests rejection of invalid steps and steps that cannot be saved or loaded
+func TestPipelineErrors(t *testing.T) {
+	engine := New()
+	engine.WithModel(NewLinearModel().WithPipeline(NewPipeline(&Scaler{Method: "zscore"})).JSON())
+	err := engine.Train([]map[string]interface{}{{"x": 1.0}}, []map[string]interface{}{{"y": 1.0}})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for an unknown scale method, got %v", err)
+	}
+
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithPipeline(NewPipeline(&FuncTransform{Name: "test_unregistered"}))
+	err = engine.Train([]map[string]interface{}{{"x": 1.0}}, []map[string]interface{}{{"y": 1.0}})
+	if err == nil {
+		t.Error("Expected an error for an unregistered function")
+	}
+
+	model := NewLinearModel().WithPipeline(NewPipeline(unregisteredStep{}))
+	if _, err := model.clone(); err == nil {
+		t.Error("Expected an error saving an unregistered step type")
+	}
+
+	if _, err := New().WithModel(`{"type":"linear","pipeline":{"steps":[{"type":"unknown","params":{}}]}}`); err == nil {
+		t.Error("Expected an error loading an unknown step type")
+	}
+}
+
+// unregisteredStep is a Transformer whose type is not registered
+type unregisteredStep struct{}
+
+func (unregisteredStep) Fit(inputs []map[string]interface{}, outputs []map[string]interface{}) error {
+	return nil
+}
+
+func (unregisteredStep) Transform(input map[string]interface{}) map[string]interface{} {
+	return input
+}
@@ -0,0 +1,162 @@
+package goml
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+func init() {
+	RegisterTransformer("polynomial", func() Transformer { return &PolynomialFeatures{} })
+}
+
+// PolynomialFeatures is a pipeline step that adds polynomial and interaction features, so that
+// linear and logistic models can learn effects that are not additive in the raw fields
+// Products of numeric features are named by their factors, e.g. "size*rooms" and "size^2".
+// Explicit interactions may also cross boolean and categorical features: a string value
+// becomes the factor "<feature>=<value>", so crossing size with location generates features
+// such as "size*location=urban" that are size for urban rows and absent otherwise
+type PolynomialFeatures struct {
+	Degree          int        `json:"degree,omitempty"`           // Highest degree of the products of numeric features; below 2 generates none
+	InteractionOnly bool       `json:"interaction_only,omitempty"` // Only products of distinct features, no powers
+	Features        []string   `json:"features,omitempty"`         // Numeric features to expand; empty expands every numeric feature
+	Interactions    [][]string `json:"interactions,omitempty"`     // Explicit crosses of two or more features of any kind
+
+	Inputs []string `json:"inputs,omitempty"` // Numeric features expanded, learned by Fit
+}
+
+// Fit checks the configuration and learns which numeric features to expand
+func (p *PolynomialFeatures) Fit(inputs []map[string]interface{}, outputs []map[string]interface{}) error {
+	if p.Degree < 0 {
+		return fmt.Errorf("invalid polynomial degree %d: %w", p.Degree, ErrInvalidInput)
+	}
+	for _, interaction := range p.Interactions {
+		if len(interaction) < 2 {
+			return fmt.Errorf("interaction %v needs at least two features: %w", interaction, ErrInvalidInput)
+		}
+	}
+
+	p.Inputs = nil
+	if p.Degree < 2 {
+		return nil
+	}
+
+	if len(p.Features) > 0 {
+		p.Inputs = append([]string(nil), p.Features...)
+		sort.Strings(p.Inputs)
+		return nil
+	}
+
+	numeric := make(map[string]bool)
+	for _, input := range inputs {
+		for feature, val := range input {
+			if IsSupportedNumericType(val) {
+				numeric[feature] = true
+			}
+		}
+	}
+	for feature := range numeric {
+		p.Inputs = append(p.Inputs, feature)
+	}
+	sort.Strings(p.Inputs)
+	return nil
+}
+
+// Transform adds the generated features to a row
+// A product is left out when any of its factors is missing from the row
+func (p *PolynomialFeatures) Transform(input map[string]interface{}) map[string]interface{} {
+	if p.Degree >= 2 && len(p.Inputs) > 0 {
+		values := make([]float64, len(p.Inputs))
+		present := make([]bool, len(p.Inputs))
+		for i, feature := range p.Inputs {
+			values[i], present[i] = numericValue(input[feature])
+		}
+
+		for degree := 2; degree <= p.Degree; degree++ {
+			p.addProducts(input, values, present, make([]int, 0, degree), degree)
+		}
+	}
+
+	for _, interaction := range p.Interactions {
+		if name, val, ok := crossFeatures(input, interaction); ok {
+			input[name] = val
+		}
+	}
+	return input
+}
+
+// addProducts adds every product of the given degree whose factor indices extend term
+// Indices never decrease, so each product is generated once
+func (p *PolynomialFeatures) addProducts(row map[string]interface{}, values []float64, present []bool, term []int, degree int) {
+	if len(term) == degree {
+		product := 1.0
+		for _, i := range term {
+			product *= values[i]
+		}
+		row[p.termName(term)] = product
+		return
+	}
+
+	start := 0
+	if len(term) > 0 {
+		start = term[len(term)-1]
+		if p.InteractionOnly {
+			start++
+		}
+	}
+	for i := start; i < len(p.Inputs); i++ {
+		if present[i] {
+			p.addProducts(row, values, present, append(term, i), degree)
+		}
+	}
+}
+
+// termName names a product by its factors, with powers for repeated ones
+func (p *PolynomialFeatures) termName(term []int) string {
+	var factors []string
+	for i := 0; i < len(term); {
+		power := 1
+		for i+power < len(term) && term[i+power] == term[i] {
+			power++
+		}
+		factor := p.Inputs[term[i]]
+		if power > 1 {
+			factor = fmt.Sprintf("%s^%d", factor, power)
+		}
+		factors = append(factors, factor)
+		i += power
+	}
+	return strings.Join(factors, "*")
+}
+
+// crossFeatures returns the name and value of an interaction, or false if a factor is missing
+func crossFeatures(row map[string]interface{}, features []string) (string, float64, bool) {
+	names := make([]string, len(features))
+	product := 1.0
+	for i, feature := range features {
+		val, exists := row[feature]
+		if !exists || isMissing(val) {
+			return "", 0, false
+		}
+
+		if category, isString := val.(string); isString {
+			names[i] = feature + "=" + category
+			continue
+		}
+		x, ok := numericValue(val)
+		if !ok {
+			return "", 0, false
+		}
+		names[i] = feature
+		product *= x
+	}
+	return strings.Join(names, "*"), product, true
+}
+
+// numericValue converts numeric and boolean values to float64
+func numericValue(val interface{}) (float64, bool) {
+	if isMissing(val) || !(IsSupportedNumericType(val) || IsSupportedBooleanType(val)) {
+		return 0, false
+	}
+	return ConvertToFloat64(val, "")
+}
@@ -0,0 +1,90 @@
+package goml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestPolynomialTerms tests the generated powers and products and their names
+func TestPolynomialTerms(t *testing.T) {
+	poly := &PolynomialFeatures{Degree: 3}
+	if err := poly.Fit([]map[string]interface{}{{"a": 2.0, "b": 3, "c": "x"}}, nil); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	if !reflect.DeepEqual(poly.Inputs, []string{"a", "b"}) {
+		t.Fatalf("Expected the numeric features a and b, got %v", poly.Inputs)
+	}
+
+	row := poly.Transform(map[string]interface{}{"a": 2.0, "b": 3, "c": "x"})
+	expected := map[string]interface{}{
+		"a": 2.0, "b": 3, "c": "x",
+		"a^2": 4.0, "a*b": 6.0, "b^2": 9.0,
+		"a^3": 8.0, "a^2*b": 12.0, "a*b^2": 18.0, "b^3": 27.0,
+	}
+	if !reflect.DeepEqual(row, expected) {
+		t.Errorf("Expected %v, got %v", expected, row)
+	}
+
+	// Interaction-only products skip powers, and products with missing factors are left out
+	poly = &PolynomialFeatures{Degree: 3, InteractionOnly: true, Features: []string{"c", "b", "a"}}
+	poly.Fit(nil, nil)
+	row = poly.Transform(map[string]interface{}{"a": 2.0, "b": 3.0, "c": 5.0})
+	for name, want := range map[string]float64{"a*b": 6, "a*c": 10, "b*c": 15, "a*b*c": 30} {
+		if row[name] != want {
+			t.Errorf("Expected %s = %v, got %v", name, want, row[name])
+		}
+	}
+	if _, exists := row["a^2"]; exists {
+		t.Error("Expected no powers with InteractionOnly")
+	}
+	row = poly.Transform(map[string]interface{}{"a": 2.0, "c": nil})
+	if len(row) != 2 {
+		t.Errorf("Expected no products with missing factors, got %v", row)
+	}
+}
+
+// TestPolynomialInteractionWeights tests that numeric x categorical crosses appear in the weight keys
+func TestPolynomialInteractionWeights(t *testing.T) {
+	model := NewLinearModel().WithPipeline(NewPipeline(&PolynomialFeatures{
+		Interactions: [][]string{{"size", "location"}, {"size", "garden"}},
+	}))
+
+	engine := New()
+	engine.WithModel(model.JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 50, BatchSize: 4})
+
+	// Urban space costs more per square meter
+	inputs := []map[string]interface{}{
+		{"size": 1.0, "location": "urban", "garden": true},
+		{"size": 2.0, "location": "urban", "garden": false},
+		{"size": 1.0, "location": "rural", "garden": true},
+		{"size": 2.0, "location": "rural", "garden": false},
+	}
+	outputs := []map[string]interface{}{{"price": 3.0}, {"price": 6.0}, {"price": 1.0}, {"price": 2.0}}
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	_, weights, _ := engine.snapshot()
+	for _, key := range []string{"size*location=urban->price", "size*location=rural->price", "size*garden->price"} {
+		if _, exists := weights.Get(key); !exists {
+			t.Errorf("Expected weight %s", key)
+		}
+	}
+
+	urban, _ := engine.Predict(map[string]interface{}{"size": 2.0, "location": "urban", "garden": false})
+	rural, _ := engine.Predict(map[string]interface{}{"size": 2.0, "location": "rural", "garden": false})
+	if urban["price"].(float64) <= rural["price"].(float64) {
+		t.Errorf("Expected urban to cost more than rural, got %v and %v", urban["price"], rural["price"])
+	}
+}
+
+// TestPolynomialInvalidConfiguration tests rejection of negative degrees and single-feature interactions
+func TestPolynomialInvalidConfiguration(t *testing.T) {
+	for _, poly := range []*PolynomialFeatures{{Degree: -1}, {Interactions: [][]string{{"size"}}}} {
+		if err := poly.Fit(nil, nil); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for %+v, got %v", poly, err)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package goml
+
+import (
+	"sort"
+)
+
+// FeatureSpec configures how a single input feature is preprocessed
+// Specs are stored in Model.Features together with the state learned for them during
+// training, so Predict applies exactly the transforms the model was trained with
+type FeatureSpec struct {
+	List   *ListFeatures   `json:"list,omitempty"`   // Flattening of a list-valued feature
+	Impute *Imputer        `json:"impute,omitempty"` // Missing value handling
+	Encode *Encoder        `json:"encode,omitempty"` // Encoding of a categorical feature as a number
+	Bin    *Binner         `json:"bin,omitempty"`    // Discretization of a numeric feature
+	Time   *TimeFeatures   `json:"time,omitempty"`   // Expansion of a date/time feature
+	Text   *TextVectorizer `json:"text,omitempty"`   // Vectorization of a text feature
+}
+
+// featureSpec returns the spec of a feature, creating it if needed
+func (m *Model) featureSpec(feature string) *FeatureSpec {
+	if m.Features == nil {
+		m.Features = make(map[string]*FeatureSpec)
+	}
+	spec, exists := m.Features[feature]
+	if !exists || spec == nil {
+		spec = &FeatureSpec{}
+		m.Features[feature] = spec
+	}
+	return spec
+}
+
+// hasPreprocessing reports whether any feature has a transform configured or is excluded, or
+// the model has a pipeline
+func (m *Model) hasPreprocessing() bool {
+	return m.hasFeatureSpecs() || len(m.Exclude) > 0 || (m.Pipeline != nil && len(m.Pipeline.Steps) > 0)
+}
+
+// hasFeatureSpecs reports whether any feature has a transform configured
+func (m *Model) hasFeatureSpecs() bool {
+	for _, spec := range m.Features {
+		if spec != nil && (spec.List != nil || spec.Impute != nil || spec.Encode != nil || spec.Bin != nil || spec.Time != nil || spec.Text != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedFeatureSpecs returns the names of the configured features in lexical order
+// Excluded features are left out, so that imputers do not bring pruned features back
+func (m *Model) sortedFeatureSpecs() []string {
+	names := make([]string, 0, len(m.Features))
+	for name, spec := range m.Features {
+		if spec != nil && !containsString(m.Exclude, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasTimeValues reports whether a row holds dates in features without a spec, which are
+// expanded with DefaultTimeFeatures
+func (m *Model) hasTimeValues(input map[string]interface{}) bool {
+	for feature, val := range input {
+		if isTimeValue(val) && m.expandsTimeByDefault(feature) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandsTimeByDefault reports whether dates in a feature use DefaultTimeFeatures, which
+// is the case unless the feature is configured as a date, encoded or text feature
+func (m *Model) expandsTimeByDefault(feature string) bool {
+	spec := m.Features[feature]
+	return spec == nil || (spec.Time == nil && spec.Encode == nil && spec.Text == nil)
+}
+
+// preprocess applies the per-feature transforms and then the pipeline to an input row
+// The row is copied first, so callers' maps are never modified
+func (m *Model) preprocess(input map[string]interface{}) map[string]interface{} {
+	return m.preprocessAt(input, -1)
+}
+
+// preprocessAt preprocesses the training row at the given position, or an input to predict
+// when index is -1. Training rows are target encoded out-of-fold
+func (m *Model) preprocessAt(input map[string]interface{}, index int) map[string]interface{} {
+	row := m.applyFeatureSpecs(input, index)
+	if m.Pipeline != nil && len(m.Pipeline.Steps) > 0 {
+		row = m.Pipeline.transform(row)
+	}
+	return row
+}
+
+// applyFeatureSpecs applies the per-feature transforms to a copy of an input row
+// index is the position of a training row, or -1 for an input to predict
+func (m *Model) applyFeatureSpecs(input map[string]interface{}, index int) map[string]interface{} {
+	if !m.hasFeatureSpecs() && len(m.Exclude) == 0 && !m.hasImplicitPreprocessing(input) {
+		return input
+	}
+
+	row := copyRow(input)
+
+	// Nested objects and lists are flattened first, so the specs can refer to dotted names.
+	// Excluded features are dropped both before and after, so they may name either
+	m.exclude(row)
+	m.flatten(row)
+	m.exclude(row)
+
+	// Missing values are filled before the transforms that expand features
+	features := m.sortedFeatureSpecs()
+	for _, feature := range features {
+		if spec := m.Features[feature]; spec.Impute != nil {
+			spec.Impute.apply(feature, row)
+		}
+	}
+
+	for _, feature := range features {
+		if spec := m.Features[feature]; spec.Encode != nil {
+			spec.Encode.apply(feature, row, index)
+		}
+	}
+
+	for _, feature := range features {
+		if spec := m.Features[feature]; spec.Bin != nil {
+			spec.Bin.apply(feature, row)
+		}
+	}
+
+	// Dates are expanded before text so that date strings are not vectorized
+	for _, feature := range features {
+		if spec := m.Features[feature]; spec.Time != nil {
+			spec.Time.apply(feature, row)
+		}
+	}
+	var dates []string
+	for feature, val := range row {
+		if isTimeValue(val) && m.expandsTimeByDefault(feature) {
+			dates = append(dates, feature)
+		}
+	}
+	defaults := DefaultTimeFeatures()
+	for _, feature := range dates {
+		defaults.apply(feature, row)
+	}
+
+	for _, feature := range features {
+		if spec := m.Features[feature]; spec.Text != nil {
+			spec.Text.apply(feature, row)
+		}
+	}
+
+	return row
+}
+
+// exclude drops the excluded features from a row
+func (m *Model) exclude(row map[string]interface{}) {
+	for _, feature := range m.Exclude {
+		delete(row, feature)
+	}
+}
+
+// preprocessRows preprocesses training rows; offset is the position of the first row in
+// the training set, which decides the folds of out-of-fold target encoding
+func (m *Model) preprocessRows(inputs []map[string]interface{}, offset int) []map[string]interface{} {
+	if !m.hasPreprocessing() && !m.anyImplicitPreprocessing(inputs) {
+		return inputs
+	}
+
+	rows := make([]map[string]interface{}, len(inputs))
+	for i, input := range inputs {
+		rows[i] = m.preprocessAt(input, offset+i)
+	}
+	return rows
+}
+
+// hasImplicitPreprocessing reports whether a row needs preprocessing without any spec: nested
+// values are flattened and dates are expanded
+func (m *Model) hasImplicitPreprocessing(input map[string]interface{}) bool {
+	return hasNestedValues(input) || m.hasTimeValues(input)
+}
+
+// anyImplicitPreprocessing reports whether any row needs preprocessing without any spec
+func (m *Model) anyImplicitPreprocessing(inputs []map[string]interface{}) bool {
+	for _, input := range inputs {
+		if m.hasImplicitPreprocessing(input) {
+			return true
+		}
+	}
+	return false
+}
+
+// preprocessFitter learns the state of the per-feature transforms from training rows
+// Rows are observed one at a time so streaming training can fit without holding the dataset
+type preprocessFitter struct {
+	model   *Model
+	imputes map[string]*imputeObservation
+	encodes map[string]*encodeObservation
+	bins    map[string]*binObservation
+	texts   map[string]*textObservation
+}
+
+// newPreprocessFitter validates the feature specs and prepares to observe training rows
+func (m *Model) newPreprocessFitter() (*preprocessFitter, error) {
+	fitter := &preprocessFitter{
+		model:   m,
+		imputes: make(map[string]*imputeObservation),
+		encodes: make(map[string]*encodeObservation),
+		bins:    make(map[string]*binObservation),
+		texts:   make(map[string]*textObservation),
+	}
+
+	for _, feature := range m.sortedFeatureSpecs() {
+		spec := m.Features[feature]
+		if spec.List != nil {
+			if err := spec.List.validate(feature); err != nil {
+				return nil, err
+			}
+		}
+		if spec.Impute != nil {
+			if err := spec.Impute.validate(feature); err != nil {
+				return nil, err
+			}
+			fitter.imputes[feature] = &imputeObservation{}
+		}
+		if spec.Encode != nil {
+			if err := spec.Encode.validate(feature); err != nil {
+				return nil, err
+			}
+			fitter.encodes[feature] = &encodeObservation{}
+		}
+		if spec.Bin != nil {
+			if err := spec.Bin.validate(feature); err != nil {
+				return nil, err
+			}
+			fitter.bins[feature] = &binObservation{}
+		}
+		if spec.Time != nil {
+			if err := spec.Time.validate(feature); err != nil {
+				return nil, err
+			}
+		}
+		if spec.Text != nil {
+			if err := spec.Text.validate(feature); err != nil {
+				return nil, err
+			}
+			fitter.texts[feature] = &textObservation{}
+		}
+	}
+
+	return fitter, nil
+}
+
+// observe records one training row; rows must be observed in training set order
+func (f *preprocessFitter) observe(input map[string]interface{}, output map[string]interface{}) {
+	// Specs refer to the flattened names of nested values
+	if hasNestedValues(input) {
+		input = copyRow(input)
+		f.model.flatten(input)
+	}
+
+	for feature, obs := range f.imputes {
+		obs.observe(f.model.Features[feature].Impute.Strategy, input[feature])
+	}
+	for feature, obs := range f.encodes {
+		spec := f.model.Features[feature]
+		obs.observe(spec.Encode, f.constantFilled(spec, input[feature]), output)
+	}
+	for feature, obs := range f.bins {
+		spec := f.model.Features[feature]
+		obs.observe(spec.Bin, f.constantFilled(spec, input[feature]), output)
+	}
+	for feature, obs := range f.texts {
+		spec := f.model.Features[feature]
+		obs.observe(spec.Text, f.constantFilled(spec, input[feature]))
+	}
+}
+
+// constantFilled returns a value with constant imputation applied
+// Constant fill values are known up front, so transforms can learn from them
+func (f *preprocessFitter) constantFilled(spec *FeatureSpec, val interface{}) interface{} {
+	if isMissing(val) && spec.Impute != nil && spec.Impute.Strategy == ImputeConstant {
+		return spec.Impute.Value
+	}
+	return val
+}
+
+// finish stores the learned state in the feature specs
+func (f *preprocessFitter) finish() error {
+	for feature, obs := range f.imputes {
+		imputer := f.model.Features[feature].Impute
+		if imputer.Strategy != ImputeConstant && imputer.Strategy != ImputeNone {
+			imputer.Value = obs.fill(imputer.Strategy)
+		}
+	}
+	for feature, obs := range f.texts {
+		vectorizer := f.model.Features[feature].Text
+		vectorizer.Vocabulary = obs.vocabulary(vectorizer)
+	}
+
+	// Features are finished in lexical order, so errors are reproducible
+	for _, feature := range f.model.sortedFeatureSpecs() {
+		if obs, exists := f.encodes[feature]; exists {
+			if err := obs.finish(f.model.Features[feature].Encode, feature); err != nil {
+				return err
+			}
+		}
+		if obs, exists := f.bins[feature]; exists {
+			if err := obs.finish(f.model.Features[feature].Bin, feature); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fitPreprocessing learns the state of the per-feature transforms and then fits the
+// pipeline on the transformed training rows
+func (m *Model) fitPreprocessing(inputs []map[string]interface{}, outputs []map[string]interface{}) error {
+	if !m.hasPreprocessing() {
+		return nil
+	}
+
+	fitter, err := m.newPreprocessFitter()
+	if err != nil {
+		return err
+	}
+	for i, input := range inputs {
+		fitter.observe(input, rowAt(outputs, i))
+	}
+	if err := fitter.finish(); err != nil {
+		return err
+	}
+
+	if m.Pipeline == nil || len(m.Pipeline.Steps) == 0 {
+		return nil
+	}
+	rows := make([]map[string]interface{}, len(inputs))
+	for i, input := range inputs {
+		rows[i] = m.applyFeatureSpecs(input, i)
+	}
+	return m.Pipeline.fit(rows, outputs)
+}
+
+// relaxSchema adjusts an inferred schema to the feature preprocessing: imputed and excluded
+// features become optional since Predict fills or drops them, and date, encoded and text
+// features accept any value of their kind
+func (m *Model) relaxSchema() {
+	if m.Schema == nil || !m.Schema.Inferred {
+		return
+	}
+	for _, feature := range m.Exclude {
+		if field, exists := m.Schema.Inputs[feature]; exists {
+			field.Required = false
+		}
+	}
+	for feature, spec := range m.Features {
+		field, exists := m.Schema.Inputs[feature]
+		if spec == nil || !exists {
+			continue
+		}
+		if spec.Impute != nil {
+			field.Required = false
+		}
+		if spec.Time != nil {
+			field.Categories = nil
+			field.Min = nil
+			field.Max = nil
+		}
+		if spec.Encode != nil || spec.Text != nil {
+			field.Categories = nil
+		}
+	}
+}
+
+// rowAt returns the row at an index, or nil if there are fewer rows
+func rowAt(rows []map[string]interface{}, i int) map[string]interface{} {
+	if i < len(rows) {
+		return rows[i]
+	}
+	return nil
+}
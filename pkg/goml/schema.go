@@ -0,0 +1,433 @@
+package goml
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldKind is the kind of value a schema field holds
+type FieldKind string
+
+const (
+	KindNumeric     FieldKind = "numeric"     // int and float values
+	KindBoolean     FieldKind = "boolean"     // bool values
+	KindCategorical FieldKind = "categorical" // string values
+	KindTime        FieldKind = "time"        // time.Time values and RFC3339 strings
+	KindAny         FieldKind = "any"         // values of different kinds; not checked
+)
+
+// MaxSchemaCategories limits the categories recorded per field when a schema is inferred
+// Fields with more distinct values, such as free text, accept any string
+const MaxSchemaCategories = 1000
+
+// FieldSchema describes one input feature or output target
+type FieldSchema struct {
+	Kind       FieldKind `json:"kind"`
+	Required   bool      `json:"required,omitempty"`   // Field must be present, not nil and not NaN; see Schema.ValidateInput
+	Categories []string  `json:"categories,omitempty"` // Allowed values of a categorical field; empty allows any
+	Min        *float64  `json:"min,omitempty"`        // Smallest allowed numeric value
+	Max        *float64  `json:"max,omitempty"`        // Largest allowed numeric value
+}
+
+// Schema declares the input features and output targets a model expects, keyed by name
+// It is inferred when the model is trained and saved with the model JSON. Engine.Predict
+// rejects rows that do not match it; see Config.StrictSchema
+type Schema struct {
+	Inputs   map[string]*FieldSchema `json:"inputs"`
+	Outputs  map[string]*FieldSchema `json:"outputs,omitempty"`
+	Inferred bool                    `json:"inferred,omitempty"` // Schema was inferred from training data rather than declared
+}
+
+// InferSchema infers a schema from training rows
+// Fields present in every row are required; numeric ranges and categories are the observed ones
+func InferSchema(inputs []map[string]interface{}, outputs []map[string]interface{}) *Schema {
+	builder := newSchemaBuilder()
+	for i := range inputs {
+		var output map[string]interface{}
+		if i < len(outputs) {
+			output = outputs[i]
+		}
+		builder.observe(inputs[i], output)
+	}
+	return builder.build()
+}
+
+// ValidateInput checks an input row against the schema
+// Lenient mode rejects values of the wrong kind, which the models would otherwise skip, and
+// missing required features of declared schemas. Inferred schemas keep treating missing
+// features as zero in lenient mode, since their required flags only record that a feature
+// was present in every training row. Strict mode also rejects missing required features of
+// inferred schemas, unknown features, unseen categories and numeric values outside the range.
+// Errors wrap ErrInvalidInput
+func (s *Schema) ValidateInput(input map[string]interface{}, strict bool) error {
+	problems := validateFields(s.Inputs, input, "feature", strict, s.enforcesRequired(strict))
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(problems, "; "), ErrInvalidInput)
+	}
+	return nil
+}
+
+// ValidateOutput checks a training output row against the schema like ValidateInput; errors
+// wrap ErrInvalidOutput
+func (s *Schema) ValidateOutput(output map[string]interface{}, strict bool) error {
+	problems := validateFields(s.Outputs, output, "target", strict, s.enforcesRequired(strict))
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(problems, "; "), ErrInvalidOutput)
+	}
+	return nil
+}
+
+// enforcesRequired reports whether missing required fields are rejected: always for declared
+// schemas, and only in strict mode for inferred ones
+func (s *Schema) enforcesRequired(strict bool) bool {
+	return strict || !s.Inferred
+}
+
+// extend widens an inferred schema to accept new training rows
+// New fields are added as optional, fields missing from a new row become optional, and the
+// ranges and categories grow to include the new values
+func (s *Schema) extend(inputs []map[string]interface{}, outputs []map[string]interface{}) {
+	for i := range inputs {
+		extendFields(s.Inputs, inputs[i])
+		if i < len(outputs) {
+			if s.Outputs == nil {
+				s.Outputs = make(map[string]*FieldSchema)
+			}
+			extendFields(s.Outputs, outputs[i])
+		}
+	}
+}
+
+// validateFields returns a description of every field that does not match its schema, in name order
+// Missing required fields are only reported when required is set
+func validateFields(fields map[string]*FieldSchema, row map[string]interface{}, role string, strict bool, required bool) []string {
+	var problems []string
+
+	for _, name := range sortedFieldNames(fields) {
+		field := fields[name]
+		val, exists := row[name]
+		if !exists || isMissing(val) {
+			if required && field.Required {
+				problems = append(problems, fmt.Sprintf("%s %q is required", role, name))
+			}
+			continue
+		}
+
+		if problem := field.check(val, strict); problem != "" {
+			problems = append(problems, fmt.Sprintf("%s %q %s", role, name, problem))
+		}
+	}
+
+	if strict {
+		var unknown []string
+		for name := range row {
+			if _, exists := fields[name]; !exists {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+
+		for _, name := range unknown {
+			problem := fmt.Sprintf("%s %q is not in the schema", role, name)
+			if suggestion := closestFieldName(name, fields); suggestion != "" {
+				problem += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems
+}
+
+// check describes why a value does not match the field, or returns "" if it does
+func (f *FieldSchema) check(val interface{}, strict bool) string {
+	kind := valueKind(val)
+	if f.Kind == KindAny {
+		return ""
+	}
+	if kind != f.Kind {
+		return fmt.Sprintf("must be %s, got %T", f.Kind, val)
+	}
+
+	if !strict {
+		return ""
+	}
+
+	switch kind {
+	case KindNumeric:
+		x, _ := ConvertToFloat64(val, "")
+		if f.Min != nil && x < *f.Min {
+			return fmt.Sprintf("value %v is below the minimum %v", x, *f.Min)
+		}
+		if f.Max != nil && x > *f.Max {
+			return fmt.Sprintf("value %v is above the maximum %v", x, *f.Max)
+		}
+	case KindCategorical:
+		if len(f.Categories) > 0 && !f.hasCategory(val.(string)) {
+			return fmt.Sprintf("has unknown category %q", val)
+		}
+	}
+
+	return ""
+}
+
+// hasCategory reports whether a category is allowed; Categories is kept sorted
+func (f *FieldSchema) hasCategory(category string) bool {
+	i := sort.SearchStrings(f.Categories, category)
+	return i < len(f.Categories) && f.Categories[i] == category
+}
+
+// widen extends the field to accept a value
+func (f *FieldSchema) widen(val interface{}) {
+	kind := valueKind(val)
+	if f.Kind == KindAny || kind == KindAny {
+		f.makeAny()
+		return
+	}
+	if f.Kind != kind {
+		f.makeAny()
+		return
+	}
+
+	switch kind {
+	case KindNumeric:
+		x, _ := ConvertToFloat64(val, "")
+		if f.Min != nil && x < *f.Min {
+			f.Min = &x
+		}
+		if f.Max != nil && x > *f.Max {
+			f.Max = &x
+		}
+	case KindCategorical:
+		// An empty category list already accepts anything
+		category := val.(string)
+		if len(f.Categories) == 0 || f.hasCategory(category) {
+			return
+		}
+		if len(f.Categories) >= MaxSchemaCategories {
+			f.Categories = nil
+			return
+		}
+		f.Categories = append(f.Categories, category)
+		sort.Strings(f.Categories)
+	}
+}
+
+// makeAny turns the field into one that accepts values of any kind
+func (f *FieldSchema) makeAny() {
+	f.Kind = KindAny
+	f.Categories = nil
+	f.Min = nil
+	f.Max = nil
+}
+
+// extendFields widens the fields of a schema to accept a row
+func extendFields(fields map[string]*FieldSchema, row map[string]interface{}) {
+	for name, field := range fields {
+		if val, exists := row[name]; !exists || isMissing(val) {
+			field.Required = false
+		}
+	}
+
+	for name, val := range row {
+		if isMissing(val) {
+			continue
+		}
+		field, exists := fields[name]
+		if !exists {
+			fields[name] = newFieldSchema(val)
+			continue
+		}
+		field.widen(val)
+	}
+}
+
+// newFieldSchema creates an optional field that accepts a single value
+func newFieldSchema(val interface{}) *FieldSchema {
+	field := &FieldSchema{Kind: valueKind(val)}
+	switch field.Kind {
+	case KindNumeric:
+		x, _ := ConvertToFloat64(val, "")
+		lo, hi := x, x
+		field.Min = &lo
+		field.Max = &hi
+	case KindCategorical:
+		field.Categories = []string{val.(string)}
+	}
+	return field
+}
+
+// schemaBuilder accumulates observed values to infer a schema
+type schemaBuilder struct {
+	rows    int
+	inputs  map[string]*fieldObservation
+	outputs map[string]*fieldObservation
+}
+
+// fieldObservation is what has been seen of one field
+type fieldObservation struct {
+	kind       FieldKind
+	count      int
+	min, max   float64
+	categories map[string]bool // nil once there are more than MaxSchemaCategories
+}
+
+// newSchemaBuilder creates an empty schema builder
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		inputs:  make(map[string]*fieldObservation),
+		outputs: make(map[string]*fieldObservation),
+	}
+}
+
+// observe records one input/output pair
+func (b *schemaBuilder) observe(input map[string]interface{}, output map[string]interface{}) {
+	b.rows++
+	for name, val := range input {
+		b.observeField(b.inputs, name, val)
+	}
+	for name, val := range output {
+		b.observeField(b.outputs, name, val)
+	}
+}
+
+// observeField records one value of a field
+func (b *schemaBuilder) observeField(fields map[string]*fieldObservation, name string, val interface{}) {
+	if isMissing(val) {
+		return
+	}
+
+	kind := valueKind(val)
+	obs, exists := fields[name]
+	if !exists {
+		obs = &fieldObservation{kind: kind, categories: make(map[string]bool)}
+		fields[name] = obs
+	} else if obs.kind != kind {
+		obs.kind = KindAny
+	}
+	obs.count++
+
+	switch v := val.(type) {
+	case string:
+		if obs.categories != nil {
+			obs.categories[v] = true
+			if len(obs.categories) > MaxSchemaCategories {
+				obs.categories = nil
+			}
+		}
+	default:
+		if kind != KindNumeric {
+			return
+		}
+		x, _ := ConvertToFloat64(v, "")
+		if obs.count == 1 || x < obs.min {
+			obs.min = x
+		}
+		if obs.count == 1 || x > obs.max {
+			obs.max = x
+		}
+	}
+}
+
+// build creates the schema from the observations
+func (b *schemaBuilder) build() *Schema {
+	schema := &Schema{
+		Inputs:   make(map[string]*FieldSchema),
+		Outputs:  make(map[string]*FieldSchema),
+		Inferred: true,
+	}
+	for name, obs := range b.inputs {
+		schema.Inputs[name] = b.buildField(obs, b.rows)
+	}
+	for name, obs := range b.outputs {
+		schema.Outputs[name] = b.buildField(obs, b.rows)
+	}
+	return schema
+}
+
+// buildField creates a field schema from an observation; the field is required when
+// it was seen in all of the rows
+func (b *schemaBuilder) buildField(obs *fieldObservation, rows int) *FieldSchema {
+	field := &FieldSchema{
+		Kind:     obs.kind,
+		Required: rows > 0 && obs.count == rows,
+	}
+
+	switch obs.kind {
+	case KindNumeric:
+		lo, hi := obs.min, obs.max
+		field.Min = &lo
+		field.Max = &hi
+	case KindCategorical:
+		for category := range obs.categories {
+			field.Categories = append(field.Categories, category)
+		}
+		sort.Strings(field.Categories)
+	}
+
+	return field
+}
+
+// valueKind returns the schema kind of a value
+func valueKind(val interface{}) FieldKind {
+	switch {
+	case IsSupportedNumericType(val):
+		return KindNumeric
+	case IsSupportedBooleanType(val):
+		return KindBoolean
+	case isTimeValue(val):
+		return KindTime
+	default:
+		if _, ok := val.(string); ok {
+			return KindCategorical
+		}
+		return KindAny
+	}
+}
+
+// sortedFieldNames returns the field names of a schema in lexical order
+func sortedFieldNames(fields map[string]*FieldSchema) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// closestFieldName suggests a schema field for a misspelled name, or "" if none is close
+func closestFieldName(name string, fields map[string]*FieldSchema) string {
+	best := ""
+	bestDistance := 3 // Only suggest names within two edits
+	for _, candidate := range sortedFieldNames(fields) {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(candidate)); d < bestDistance {
+			best = candidate
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,192 @@
+package goml
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newSchemaEngine trains a linear model on houses with a numeric, a categorical and an optional feature
+func newSchemaEngine(t *testing.T, strict bool) *Engine {
+	t.Helper()
+
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 50, BatchSize: 4, StrictSchema: strict})
+
+	inputs := []map[string]interface{}{
+		{"size": 1.0, "location": "urban", "garden": true},
+		{"size": 2.0, "location": "rural"},
+		{"size": 3.0, "location": "urban"},
+		{"size": 4.0, "location": "suburban", "garden": false},
+	}
+	outputs := []map[string]interface{}{
+		{"price": 2.0}, {"price": 4.0}, {"price": 6.0}, {"price": 8.0},
+	}
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+	return engine
+}
+
+// TestInferSchema tests kinds, required flags, ranges and categories inferred from training data
+func TestInferSchema(t *testing.T) {
+	engine := newSchemaEngine(t, false)
+
+	modelJSON, _ := engine.GetModel()
+	var model Model
+	if err := json.Unmarshal([]byte(*modelJSON), &model); err != nil {
+		t.Fatalf("Failed to load model: %v", err)
+	}
+
+	schema := model.Schema
+	if schema == nil || !schema.Inferred {
+		t.Fatalf("Expected an inferred schema in the model JSON, got %+v", schema)
+	}
+
+	size := schema.Inputs["size"]
+	if size.Kind != KindNumeric || !size.Required || *size.Min != 1.0 || *size.Max != 4.0 {
+		t.Errorf("Unexpected size schema: %+v", size)
+	}
+
+	location := schema.Inputs["location"]
+	if location.Kind != KindCategorical || strings.Join(location.Categories, ",") != "rural,suburban,urban" {
+		t.Errorf("Unexpected location schema: %+v", location)
+	}
+
+	garden := schema.Inputs["garden"]
+	if garden.Kind != KindBoolean || garden.Required {
+		t.Errorf("Expected an optional boolean garden feature, got %+v", garden)
+	}
+
+	if price := schema.Outputs["price"]; price == nil || price.Kind != KindNumeric {
+		t.Errorf("Unexpected price schema: %+v", price)
+	}
+}
+
+// TestPredictSchemaLenient tests that lenient mode rejects only mistyped features
+func TestPredictSchemaLenient(t *testing.T) {
+	engine := newSchemaEngine(t, false)
+
+	// Missing features of an inferred schema are treated as zero, as before schemas
+	missing, err := engine.Predict(map[string]interface{}{"sise": 2.0, "location": "urban"})
+	if err != nil {
+		t.Fatalf("Expected lenient mode to accept a missing feature, got %v", err)
+	}
+	zero, _ := engine.Predict(map[string]interface{}{"size": 0.0, "location": "urban"})
+	if missing["price"] != zero["price"] {
+		t.Errorf("Expected a missing size to predict like a zero size, got %v and %v", missing, zero)
+	}
+
+	_, err = engine.Predict(map[string]interface{}{"size": "large", "location": "urban"})
+	if !errors.Is(err, ErrInvalidInput) || !strings.Contains(err.Error(), "must be numeric") {
+		t.Errorf("Expected a kind error, got %v", err)
+	}
+
+	// Unknown features, new categories and values outside the range are accepted
+	_, err = engine.Predict(map[string]interface{}{"size": 10.0, "location": "coastal", "extra": 1})
+	if err != nil {
+		t.Errorf("Expected lenient mode to accept the input, got %v", err)
+	}
+}
+
+// TestPredictSchemaStrict tests that strict mode rejects unknown features, categories and ranges
+func TestPredictSchemaStrict(t *testing.T) {
+	engine := newSchemaEngine(t, true)
+
+	if _, err := engine.Predict(map[string]interface{}{"size": 2.5, "location": "rural", "garden": true}); err != nil {
+		t.Fatalf("Expected a valid input to pass, got %v", err)
+	}
+
+	tests := []struct {
+		input    map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"size": 2.0, "location": "urban", "gardn": true}, `feature "gardn" is not in the schema (did you mean "garden"?)`},
+		{map[string]interface{}{"size": 2.0, "location": "coastal"}, `has unknown category "coastal"`},
+		{map[string]interface{}{"size": 9.0, "location": "urban"}, "above the maximum 4"},
+		{map[string]interface{}{"size": 0.5, "location": "urban"}, "below the minimum 1"},
+		{map[string]interface{}{"location": "urban"}, `feature "size" is required`},
+	}
+
+	for _, test := range tests {
+		_, err := engine.Predict(test.input)
+		if !errors.Is(err, ErrInvalidInput) || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected error containing %q for %v, got %v", test.expected, test.input, err)
+		}
+	}
+
+	// Batch prediction enforces the schema per row
+	_, err := engine.PredictBatch([]map[string]interface{}{{"size": 2.0, "location": "urban"}, {"location": "urban"}})
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 1 || batchErr.Errors[1] == nil {
+		t.Errorf("Expected only the second row to fail, got %v", err)
+	}
+}
+
+// TestDeclaredSchemaValidatesTraining tests that a declared schema is kept and enforced on Train
+func TestDeclaredSchemaValidatesTraining(t *testing.T) {
+	model := NewLinearModel()
+	model.Schema = &Schema{
+		Inputs:  map[string]*FieldSchema{"x": {Kind: KindNumeric, Required: true}},
+		Outputs: map[string]*FieldSchema{"y": {Kind: KindNumeric, Required: true}},
+	}
+
+	engine := New()
+	engine.WithModel(model.JSON())
+
+	err := engine.Train(
+		[]map[string]interface{}{{"x": 1.0}, {"x": "two"}},
+		[]map[string]interface{}{{"y": 1.0}, {"y": 2.0}},
+	)
+	if !errors.Is(err, ErrInvalidInput) || !strings.Contains(err.Error(), "training row 1") {
+		t.Errorf("Expected the second training row to be rejected, got %v", err)
+	}
+
+	err = engine.Train(
+		[]map[string]interface{}{{"x": 1.0}, {"x": 2.0}},
+		[]map[string]interface{}{{"y": 1.0}, {}},
+	)
+	if !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Expected a missing target to be rejected, got %v", err)
+	}
+
+	err = engine.Train(
+		[]map[string]interface{}{{"x": 1.0}, {"x": 2.0}},
+		[]map[string]interface{}{{"y": 1.0}, {"y": 2.0}},
+	)
+	if err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	trained, _, _ := engine.snapshot()
+	if trained.Schema.Inferred || trained.Schema.Inputs["x"].Min != nil {
+		t.Errorf("Expected the declared schema to be kept, got %+v", trained.Schema)
+	}
+}
+
+// TestPartialFitExtendsSchema tests that online updates widen an inferred schema
+func TestPartialFitExtendsSchema(t *testing.T) {
+	engine := newSchemaEngine(t, true)
+
+	err := engine.PartialFit(
+		[]map[string]interface{}{{"size": 6.0, "location": "coastal", "floor": 3}},
+		[]map[string]interface{}{{"price": 12.0}},
+	)
+	if err != nil {
+		t.Fatalf("PartialFit failed: %v", err)
+	}
+
+	model, _, _ := engine.snapshot()
+	if *model.Schema.Inputs["size"].Max != 6.0 || !model.Schema.Inputs["location"].hasCategory("coastal") {
+		t.Errorf("Expected the range and categories to grow, got %+v %+v", model.Schema.Inputs["size"], model.Schema.Inputs["location"])
+	}
+	if floor := model.Schema.Inputs["floor"]; floor == nil || floor.Required {
+		t.Errorf("Expected a new optional feature, got %+v", floor)
+	}
+
+	if _, err := engine.Predict(map[string]interface{}{"size": 5.5, "location": "coastal"}); err != nil {
+		t.Errorf("Expected the extended schema to accept the input, got %v", err)
+	}
+}
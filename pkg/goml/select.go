@@ -0,0 +1,631 @@
+package goml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// SelectMethod selects how features are scored for selection
+type SelectMethod string
+
+const (
+	SelectVariance    SelectMethod = "variance"    // Variance of numeric features, Gini impurity of categorical ones
+	SelectCorrelation SelectMethod = "correlation" // Absolute correlation with a numeric target (correlation ratio for categorical features)
+	SelectChiSquared  SelectMethod = "chi2"        // Chi-squared statistic of the feature/target contingency table
+	SelectMutualInfo  SelectMethod = "mutual_info" // Mutual information with the target, in nats
+	SelectL1          SelectMethod = "l1"          // Absolute coefficients of an L1-regularized linear fit
+	SelectRFE         SelectMethod = "rfe"         // Recursive feature elimination with a linear fit
+)
+
+// FeatureSelector configures feature selection
+// Features are the keys of the input rows. Numeric and boolean features are used as numbers and
+// all others as categories; numeric features are binned into quantiles for chi-squared and mutual
+// information. Without K or Threshold, features scoring above zero are kept, which drops constant
+// features for variance and features with a zero coefficient for L1. Recursive feature
+// elimination keeps K features, or half of them by default
+type FeatureSelector struct {
+	Method    SelectMethod `json:"method"`
+	Target    string       `json:"target,omitempty"`    // Target to score against; may be omitted when there is only one
+	K         int          `json:"k,omitempty"`         // Keep the K best features
+	Threshold float64      `json:"threshold,omitempty"` // Keep features scoring at least this, or above it for variance
+	Alpha     float64      `json:"alpha,omitempty"`     // L1 penalty for SelectL1 (default 0.01)
+	Step      int          `json:"step,omitempty"`      // Features removed per round of SelectRFE (default 1)
+	Bins      int          `json:"bins,omitempty"`      // Quantile bins of numeric values for chi-squared and mutual information (default 10)
+}
+
+// FeatureScore is the selection score of one feature
+type FeatureScore struct {
+	Feature  string  `json:"feature"`
+	Score    float64 `json:"score"`
+	Selected bool    `json:"selected"`
+}
+
+// SelectionReport is the result of feature selection
+// For recursive feature elimination the score is the elimination round a feature survived to
+type SelectionReport struct {
+	Method   SelectMethod   `json:"method"`
+	Target   string         `json:"target,omitempty"`
+	Scores   []FeatureScore `json:"scores"` // Best first, ties in lexical order
+	Selected []string       `json:"selected"`
+	Dropped  []string       `json:"dropped"`
+}
+
+// SelectFeatures scores the features of the training rows and selects the best ones
+func SelectFeatures(inputs []map[string]interface{}, outputs []map[string]interface{}, selector FeatureSelector) (*SelectionReport, error) {
+	if len(inputs) != len(outputs) {
+		return nil, fmt.Errorf("number of input samples (%d) must match number of output samples (%d)", len(inputs), len(outputs))
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no training data provided")
+	}
+
+	data, err := newSelectionData(inputs, outputs, &selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var scores map[string]float64
+	switch selector.Method {
+	case SelectVariance:
+		scores = data.varianceScores()
+	case SelectCorrelation:
+		if data.classes != nil {
+			return nil, fmt.Errorf("correlation needs a numeric target, %q is categorical: %w", selector.Target, ErrInvalidOutput)
+		}
+		scores = data.correlationScores()
+	case SelectChiSquared, SelectMutualInfo:
+		scores = data.contingencyScores(selector.Method, selector.binCount())
+	case SelectL1:
+		alpha := selector.Alpha
+		if alpha == 0 {
+			alpha = 0.01
+		}
+		scores = data.coefficientScores(data.features, alpha, 0)
+	case SelectRFE:
+		scores = data.eliminationScores(selector.K, selector.Step)
+	default:
+		return nil, fmt.Errorf("unknown selection method %q: %w", selector.Method, ErrInvalidInput)
+	}
+
+	return newSelectionReport(&selector, data.features, scores), nil
+}
+
+// binCount returns the number of quantile bins of numeric values
+func (s *FeatureSelector) binCount() int {
+	if s.Bins == 0 {
+		return 10
+	}
+	return s.Bins
+}
+
+// newSelectionReport ranks the scores and applies K or the threshold
+func newSelectionReport(selector *FeatureSelector, features []string, scores map[string]float64) *SelectionReport {
+	report := &SelectionReport{Method: selector.Method, Target: selector.Target}
+	for _, feature := range features {
+		report.Scores = append(report.Scores, FeatureScore{Feature: feature, Score: scores[feature]})
+	}
+	sort.SliceStable(report.Scores, func(i, j int) bool { return report.Scores[i].Score > report.Scores[j].Score })
+
+	k := selector.K
+	if selector.Method == SelectRFE && k == 0 {
+		k = (len(features) + 1) / 2
+	}
+	for i := range report.Scores {
+		score := &report.Scores[i]
+		switch {
+		case k > 0:
+			score.Selected = i < k
+		case selector.Method == SelectVariance || selector.Threshold == 0:
+			score.Selected = score.Score > selector.Threshold
+		default:
+			score.Selected = score.Score >= selector.Threshold
+		}
+
+		if score.Selected {
+			report.Selected = append(report.Selected, score.Feature)
+		} else {
+			report.Dropped = append(report.Dropped, score.Feature)
+		}
+	}
+	sort.Strings(report.Selected)
+	sort.Strings(report.Dropped)
+	return report
+}
+
+// selectionData holds the training rows as columns
+// Missing numeric values are replaced by the feature mean and missing categories by ""
+type selectionData struct {
+	features    []string
+	numeric     map[string][]float64
+	categorical map[string][]string
+	targets     [][]float64 // Target value, or one-hot class vector, per row
+	classes     []string    // Classes of a categorical target; nil for numeric targets
+}
+
+// newSelectionData collects the rows that have the target
+func newSelectionData(inputs []map[string]interface{}, outputs []map[string]interface{}, selector *FeatureSelector) (*selectionData, error) {
+	if selector.Target == "" {
+		targets := sortedRowKeys(outputs)
+		if len(targets) != 1 {
+			return nil, fmt.Errorf("feature selection needs a Target when rows have %d targets: %w", len(targets), ErrInvalidOutput)
+		}
+		selector.Target = targets[0]
+	}
+
+	var rows []map[string]interface{}
+	var ys []interface{}
+	for i, output := range outputs {
+		if y, exists := output[selector.Target]; exists && !isMissing(y) {
+			rows = append(rows, inputs[i])
+			ys = append(ys, y)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no rows have the target %q: %w", selector.Target, ErrInvalidOutput)
+	}
+
+	order := make([]int, len(ys))
+	for i := range order {
+		order[i] = i
+	}
+	data := &selectionData{
+		features:    sortedRowKeys(rows),
+		numeric:     make(map[string][]float64),
+		categorical: make(map[string][]string),
+		targets:     targetVectors(ys, order),
+	}
+	// Classes are ordered as in the one-hot target vectors
+	categories := make(map[string]int)
+	for _, y := range ys {
+		if category, isString := y.(string); isString {
+			categories[category] = 0
+		}
+	}
+	if len(categories) > 0 {
+		data.classes = sortedCategories(categories)
+	}
+
+	for _, feature := range data.features {
+		isNumeric := true
+		for _, row := range rows {
+			if val := row[feature]; !isMissing(val) && !IsSupportedNumericType(val) && !IsSupportedBooleanType(val) {
+				isNumeric = false
+				break
+			}
+		}
+
+		if !isNumeric {
+			column := make([]string, len(rows))
+			for i, row := range rows {
+				if val := row[feature]; !isMissing(val) {
+					column[i] = fmt.Sprintf("%v", val)
+				}
+			}
+			data.categorical[feature] = column
+			continue
+		}
+
+		column := make([]float64, len(rows))
+		present := make([]bool, len(rows))
+		stats := &FeatureStats{}
+		for i, row := range rows {
+			if x, ok := numericValue(row[feature]); ok {
+				column[i], present[i] = x, true
+				stats.Add(x)
+			}
+		}
+		for i := range column {
+			if !present[i] {
+				column[i] = stats.Mean()
+			}
+		}
+		data.numeric[feature] = column
+	}
+	return data, nil
+}
+
+// varianceScores scores numeric features by variance and categorical ones by Gini impurity
+func (d *selectionData) varianceScores() map[string]float64 {
+	scores := make(map[string]float64)
+	for feature, column := range d.numeric {
+		stats := &FeatureStats{}
+		for _, x := range column {
+			stats.Add(x)
+		}
+		scores[feature] = stats.Variance()
+	}
+	for feature, column := range d.categorical {
+		counts := make(map[string]int)
+		for _, category := range column {
+			counts[category]++
+		}
+		gini := 1.0
+		for _, count := range counts {
+			p := float64(count) / float64(len(column))
+			gini -= p * p
+		}
+		scores[feature] = gini
+	}
+	return scores
+}
+
+// correlationScores scores numeric features by absolute Pearson correlation and categorical
+// features by the correlation ratio, both in [0, 1]
+func (d *selectionData) correlationScores() map[string]float64 {
+	y := make([]float64, len(d.targets))
+	for i, vector := range d.targets {
+		y[i] = vector[0]
+	}
+	yStats := &FeatureStats{}
+	for _, v := range y {
+		yStats.Add(v)
+	}
+
+	scores := make(map[string]float64)
+	for feature, x := range d.numeric {
+		xStats := &FeatureStats{}
+		covariance := 0.0
+		for i := range x {
+			xStats.Add(x[i])
+		}
+		for i := range x {
+			covariance += (x[i] - xStats.Mean()) * (y[i] - yStats.Mean())
+		}
+		if denominator := xStats.StdDev() * yStats.StdDev() * float64(len(x)); denominator > 0 {
+			scores[feature] = math.Abs(covariance / denominator)
+		}
+	}
+
+	for feature, column := range d.categorical {
+		groups := make(map[string]*FeatureStats)
+		for i, category := range column {
+			if groups[category] == nil {
+				groups[category] = &FeatureStats{}
+			}
+			groups[category].Add(y[i])
+		}
+		between := 0.0
+		for _, group := range groups {
+			between += float64(group.Count) * math.Pow(group.Mean()-yStats.Mean(), 2)
+		}
+		if total := yStats.M2; total > 0 {
+			scores[feature] = math.Sqrt(between / total)
+		}
+	}
+	return scores
+}
+
+// contingencyScores scores features by the chi-squared statistic or the mutual information of
+// their contingency table with the target
+func (d *selectionData) contingencyScores(method SelectMethod, bins int) map[string]float64 {
+	targetLevels := make([]string, len(d.targets))
+	if d.classes != nil {
+		for i, vector := range d.targets {
+			for c, hot := range vector {
+				if hot == 1 {
+					targetLevels[i] = d.classes[c]
+				}
+			}
+		}
+	} else {
+		y := make([]float64, len(d.targets))
+		for i, vector := range d.targets {
+			y[i] = vector[0]
+		}
+		targetLevels = quantileLevels(y, bins)
+	}
+
+	scores := make(map[string]float64)
+	for feature, x := range d.numeric {
+		scores[feature] = contingencyScore(method, quantileLevels(x, bins), targetLevels)
+	}
+	for feature, column := range d.categorical {
+		scores[feature] = contingencyScore(method, column, targetLevels)
+	}
+	return scores
+}
+
+// quantileLevels bins numeric values into quantiles and names each value by its bin
+func quantileLevels(values []float64, bins int) []string {
+	binner := &Binner{Method: BinQuantile, Bins: bins}
+	(&binObservation{values: values}).finish(binner, "")
+
+	levels := make([]string, len(values))
+	for i, x := range values {
+		levels[i] = fmt.Sprintf("bin%d", binner.Bin(x))
+	}
+	return levels
+}
+
+// contingencyScore computes the chi-squared statistic or mutual information of two variables
+func contingencyScore(method SelectMethod, xs []string, ys []string) float64 {
+	n := float64(len(xs))
+	joint := make(map[[2]string]float64)
+	xCounts := make(map[string]float64)
+	yCounts := make(map[string]float64)
+	for i := range xs {
+		joint[[2]string{xs[i], ys[i]}]++
+		xCounts[xs[i]]++
+		yCounts[ys[i]]++
+	}
+
+	score := 0.0
+	if method == SelectMutualInfo {
+		for cell, count := range joint {
+			score += count / n * math.Log(count*n/(xCounts[cell[0]]*yCounts[cell[1]]))
+		}
+		return math.Max(score, 0)
+	}
+
+	for x, xCount := range xCounts {
+		for y, yCount := range yCounts {
+			expected := xCount * yCount / n
+			diff := joint[[2]string{x, y}] - expected
+			score += diff * diff / expected
+		}
+	}
+	return score
+}
+
+// designMatrix builds standardized columns for the given features, one per numeric feature and
+// one per category of a categorical feature, and returns the feature of each column
+func (d *selectionData) designMatrix(features []string) ([][]float64, []string) {
+	var columns [][]float64
+	var owners []string
+	for _, feature := range features {
+		if x, isNumeric := d.numeric[feature]; isNumeric {
+			columns = append(columns, standardize(x))
+			owners = append(owners, feature)
+			continue
+		}
+
+		column := d.categorical[feature]
+		categories := make(map[string]int)
+		for _, category := range column {
+			categories[category] = 0
+		}
+		for _, category := range sortedCategories(categories) {
+			indicator := make([]float64, len(column))
+			for i := range column {
+				if column[i] == category {
+					indicator[i] = 1
+				}
+			}
+			columns = append(columns, standardize(indicator))
+			owners = append(owners, feature)
+		}
+	}
+	return columns, owners
+}
+
+// standardize returns the values with zero mean and unit variance; constant columns become zero
+func standardize(values []float64) []float64 {
+	stats := &FeatureStats{}
+	for _, x := range values {
+		stats.Add(x)
+	}
+	result := make([]float64, len(values))
+	if std := stats.StdDev(); std > 0 {
+		for i, x := range values {
+			result[i] = (x - stats.Mean()) / std
+		}
+	}
+	return result
+}
+
+// coefficientScores fits an elastic net to the standardized targets and scores each feature by
+// the sum of the absolute coefficients of its columns
+func (d *selectionData) coefficientScores(features []string, l1 float64, l2 float64) map[string]float64 {
+	columns, owners := d.designMatrix(features)
+
+	scores := make(map[string]float64, len(features))
+	for _, feature := range features {
+		scores[feature] = 0
+	}
+	for dim := range d.targets[0] {
+		y := make([]float64, len(d.targets))
+		for i, vector := range d.targets {
+			y[i] = vector[dim]
+		}
+		for j, coefficient := range elasticNet(columns, standardize(y), l1, l2) {
+			scores[owners[j]] += math.Abs(coefficient)
+		}
+	}
+	return scores
+}
+
+// elasticNet fits standardized columns to a centered target by coordinate descent, minimizing
+// 1/(2n)*||y - Xb||^2 + l1*||b||_1 + l2/2*||b||^2
+func elasticNet(columns [][]float64, y []float64, l1 float64, l2 float64) []float64 {
+	n := float64(len(y))
+	coefficients := make([]float64, len(columns))
+	residual := append([]float64(nil), y...)
+
+	for iteration := 0; iteration < 1000; iteration++ {
+		maxChange := 0.0
+		for j, x := range columns {
+			norm := 0.0
+			rho := 0.0
+			for i := range x {
+				norm += x[i] * x[i]
+				rho += x[i] * (residual[i] + x[i]*coefficients[j])
+			}
+			if norm == 0 {
+				continue
+			}
+			norm /= n
+			rho /= n
+
+			// Soft thresholding gives exact zeros for weak columns
+			updated := 0.0
+			if rho > l1 {
+				updated = (rho - l1) / (norm + l2)
+			} else if rho < -l1 {
+				updated = (rho + l1) / (norm + l2)
+			}
+
+			if change := updated - coefficients[j]; change != 0 {
+				for i := range x {
+					residual[i] -= change * x[i]
+				}
+				coefficients[j] = updated
+				maxChange = math.Max(maxChange, math.Abs(change))
+			}
+		}
+		if maxChange < 1e-6 {
+			break
+		}
+	}
+	return coefficients
+}
+
+// eliminationScores repeatedly fits a ridge regression and removes the features with the
+// smallest coefficients; a feature's score is the number of rounds it survived
+func (d *selectionData) eliminationScores(k int, step int) map[string]float64 {
+	if k <= 0 {
+		k = (len(d.features) + 1) / 2
+	}
+	if step <= 0 {
+		step = 1
+	}
+
+	scores := make(map[string]float64, len(d.features))
+	active := append([]string(nil), d.features...)
+	round := 0.0
+	for len(active) > k {
+		coefficients := d.coefficientScores(active, 0, 1e-3)
+
+		// Weakest first, ties in lexical order
+		sort.SliceStable(active, func(i, j int) bool {
+			if coefficients[active[i]] != coefficients[active[j]] {
+				return coefficients[active[i]] < coefficients[active[j]]
+			}
+			return active[i] < active[j]
+		})
+		remove := min(step, len(active)-k)
+		for _, feature := range active[:remove] {
+			scores[feature] = round
+		}
+		active = active[remove:]
+		round++
+	}
+	for _, feature := range active {
+		scores[feature] = round
+	}
+	return scores
+}
+
+// SelectFeatures scores the features of the training rows and prunes the dropped ones from the
+// engine's model, so that the final training uses only the selected features
+func (e *Engine) SelectFeatures(inputs []map[string]interface{}, outputs []map[string]interface{}, selector FeatureSelector) (*SelectionReport, error) {
+	report, err := SelectFeatures(inputs, outputs, selector)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.Prune(report.Dropped...); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Prune removes input features from the engine's model
+// The features are dropped from every input before preprocessing, at training and prediction
+// time, and become optional in the schema. Their weights and statistics are deleted, along with
+// those of every feature generated from them, such as "feature=value" categories, missing
+// indicators, date parts, hash buckets, nested fields and polynomial terms
+func (e *Engine) Prune(features ...string) error {
+	e.trainMu.Lock()
+	defer e.trainMu.Unlock()
+
+	model, weights, _ := e.snapshot()
+	if model == nil {
+		return fmt.Errorf("model not initialized")
+	}
+
+	// Published models and weights are never mutated, so the pruning happens on copies
+	pruned, err := model.clone()
+	if err != nil {
+		return err
+	}
+	prunedWeights := weights
+	if weights != nil {
+		prunedWeights = weights.clone()
+	}
+
+	for _, feature := range features {
+		if !containsString(pruned.Exclude, feature) {
+			pruned.Exclude = append(pruned.Exclude, feature)
+		}
+		for name := range pruned.Stats {
+			if pruned.generatedFrom(name, feature) {
+				delete(pruned.Stats, name)
+			}
+		}
+		if pruned.Schema != nil {
+			if field, exists := pruned.Schema.Inputs[feature]; exists {
+				field.Required = false
+			}
+		}
+		if prunedWeights != nil {
+			for key := range prunedWeights.Values {
+				if pruned.prunesWeight(key, feature) {
+					delete(prunedWeights.Values, key)
+				}
+			}
+		}
+	}
+	sort.Strings(pruned.Exclude)
+
+	e.mu.Lock()
+	e.model = pruned
+	e.weights = prunedWeights
+	e.mu.Unlock()
+	return nil
+}
+
+// derivedSeparators are the characters preprocessing puts between a field name and the rest
+// of the name of a feature derived from it: categories and bins ("="), missing indicators,
+// date parts and list aggregates ("_"), hash buckets ("#"), nested fields (".") and powers ("^")
+const derivedSeparators = "=_#.^"
+
+// hasFieldPrefix reports whether a feature name is a field or starts with it and a separator
+func hasFieldPrefix(name string, field string) bool {
+	if name == field {
+		return true
+	}
+	return len(name) > len(field) && strings.HasPrefix(name, field) && strings.ContainsRune(derivedSeparators, rune(name[len(field)]))
+}
+
+// generatedFrom reports whether a model feature was generated from an input field
+// Features are attributed to the longest input field of the schema they start with, so
+// pruning "size" keeps the features of a "size_total" field
+func (m *Model) generatedFrom(name string, field string) bool {
+	if !hasFieldPrefix(name, field) {
+		return false
+	}
+	if m.Schema != nil {
+		for other := range m.Schema.Inputs {
+			if len(other) > len(field) && hasFieldPrefix(name, other) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// prunesWeight reports whether a weight key belongs to a pruned feature: its input is the
+// feature, was generated from it, or is a polynomial term with a factor generated from it
+func (m *Model) prunesWeight(key string, feature string) bool {
+	input, _, found := strings.Cut(key, "->")
+	if !found {
+		return false
+	}
+	for _, factor := range strings.Split(input, "*") {
+		if m.generatedFrom(factor, feature) {
+			return true
+		}
+	}
+	return false
+}
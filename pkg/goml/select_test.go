@@ -0,0 +1,262 @@
+package goml
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// selectionRows returns rows where y depends on "signal" and "color", "noise" is unrelated
+// and "constant" never changes
+func selectionRows() ([]map[string]interface{}, []map[string]interface{}) {
+	var inputs, outputs []map[string]interface{}
+	for i := 0; i < 40; i++ {
+		signal := float64(i % 10)
+		color := []string{"red", "blue"}[i%2]
+		y := 2 * signal
+		if color == "red" {
+			y += 20
+		}
+		inputs = append(inputs, map[string]interface{}{
+			"signal":   signal,
+			"color":    color,
+			"noise":    math.Sin(float64(i*i) * 7.3),
+			"constant": 1.0,
+		})
+		outputs = append(outputs, map[string]interface{}{"y": y})
+	}
+	return inputs, outputs
+}
+
+// scoreOf returns the score of a feature in a report
+func scoreOf(t *testing.T, report *SelectionReport, feature string) float64 {
+	t.Helper()
+	for _, score := range report.Scores {
+		if score.Feature == feature {
+			return score.Score
+		}
+	}
+	t.Fatalf("No score for %s in %+v", feature, report.Scores)
+	return 0
+}
+
+// TestSelectFeaturesMethods tests that every method ranks the informative features first
+func TestSelectFeaturesMethods(t *testing.T) {
+	inputs, outputs := selectionRows()
+
+	variance, err := SelectFeatures(inputs, outputs, FeatureSelector{Method: SelectVariance})
+	if err != nil {
+		t.Fatalf("Selection failed: %v", err)
+	}
+	if !reflect.DeepEqual(variance.Dropped, []string{"constant"}) {
+		t.Errorf("Expected only the constant feature to be dropped, got %v", variance.Dropped)
+	}
+	if score := scoreOf(t, variance, "color"); score != 0.5 {
+		t.Errorf("Expected a Gini impurity of 0.5 for color, got %v", score)
+	}
+
+	// Few bins keep chi-squared and mutual information from rewarding the many levels of noise
+	for _, method := range []SelectMethod{SelectCorrelation, SelectChiSquared, SelectMutualInfo, SelectL1, SelectRFE} {
+		report, err := SelectFeatures(inputs, outputs, FeatureSelector{Method: method, K: 2, Bins: 4})
+		if err != nil {
+			t.Fatalf("%s selection failed: %v", method, err)
+		}
+		if report.Target != "y" {
+			t.Errorf("Expected the only target to be used, got %q", report.Target)
+		}
+		if !reflect.DeepEqual(report.Selected, []string{"color", "signal"}) {
+			t.Errorf("Expected %s to select color and signal, got %+v", method, report.Scores)
+		}
+		if report.Scores[0].Score < report.Scores[len(report.Scores)-1].Score {
+			t.Errorf("Expected %s scores best first, got %+v", method, report.Scores)
+		}
+	}
+
+	correlation, _ := SelectFeatures(inputs, outputs, FeatureSelector{Method: SelectCorrelation, Threshold: 0.5})
+	if !reflect.DeepEqual(correlation.Selected, []string{"color"}) {
+		t.Errorf("Expected only color to correlate at least 0.5, got %+v", correlation.Scores)
+	}
+
+	// A strong penalty shrinks every coefficient to zero
+	lasso, _ := SelectFeatures(inputs, outputs, FeatureSelector{Method: SelectL1, Alpha: 10})
+	if len(lasso.Selected) != 0 {
+		t.Errorf("Expected no features with a strong L1 penalty, got %+v", lasso.Scores)
+	}
+}
+
+// TestSelectFeaturesCategoricalTarget tests scoring against a class target
+func TestSelectFeaturesCategoricalTarget(t *testing.T) {
+	inputs, outputs := selectionRows()
+	for i := range outputs {
+		outputs[i]["label"] = "low"
+		if inputs[i]["signal"].(float64) >= 5 {
+			outputs[i]["label"] = "high"
+		}
+	}
+
+	report, err := SelectFeatures(inputs, outputs, FeatureSelector{Method: SelectMutualInfo, Target: "label", K: 1})
+	if err != nil {
+		t.Fatalf("Selection failed: %v", err)
+	}
+	if !reflect.DeepEqual(report.Selected, []string{"signal"}) {
+		t.Errorf("Expected signal to select the label, got %+v", report.Scores)
+	}
+	if score := scoreOf(t, report, "signal"); math.Abs(score-math.Log(2)) > 1e-9 {
+		t.Errorf("Expected the mutual information of signal to be ln 2, got %v", score)
+	}
+
+	_, err = SelectFeatures(inputs, outputs, FeatureSelector{Method: SelectCorrelation, Target: "label"})
+	if !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Expected ErrInvalidOutput for correlation with a class target, got %v", err)
+	}
+	_, err = SelectFeatures(inputs, outputs, FeatureSelector{Method: SelectChiSquared})
+	if !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Expected ErrInvalidOutput without a target for several targets, got %v", err)
+	}
+	_, err = SelectFeatures(inputs, outputs, FeatureSelector{Method: "boruta", Target: "y"})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for an unknown method, got %v", err)
+	}
+}
+
+// TestEnginePrune tests that pruned features lose their weights and are ignored afterwards
+func TestEnginePrune(t *testing.T) {
+	inputs, outputs := selectionRows()
+
+	engine := New()
+	engine.WithModel(NewCategoricalModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 20, BatchSize: 10, StrictSchema: true})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	report, err := engine.SelectFeatures(inputs, outputs, FeatureSelector{Method: SelectCorrelation, K: 2})
+	if err != nil {
+		t.Fatalf("Selection failed: %v", err)
+	}
+	if !reflect.DeepEqual(report.Dropped, []string{"constant", "noise"}) {
+		t.Fatalf("Expected constant and noise to be dropped, got %+v", report.Scores)
+	}
+
+	model, weights, _ := engine.snapshot()
+	if !reflect.DeepEqual(model.Exclude, []string{"constant", "noise"}) {
+		t.Errorf("Expected the dropped features to be excluded, got %v", model.Exclude)
+	}
+	for key := range weights.Values {
+		if model.prunesWeight(key, "noise") || model.prunesWeight(key, "constant") {
+			t.Errorf("Expected weight %s to be pruned", key)
+		}
+	}
+	kept := false
+	for key := range weights.Values {
+		kept = kept || model.prunesWeight(key, "signal")
+	}
+	if !kept {
+		t.Errorf("Expected the weights of the selected features to be kept")
+	}
+
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Retraining failed: %v", err)
+	}
+	model, weights, _ = engine.snapshot()
+	for key := range weights.Values {
+		if model.prunesWeight(key, "noise") {
+			t.Errorf("Expected retraining to ignore the pruned features, got weight %s", key)
+		}
+	}
+	if _, exists := model.Stats["noise"]; exists {
+		t.Errorf("Expected no statistics of the pruned features")
+	}
+
+	// Inputs may keep sending or leave out the pruned features
+	for _, input := range []map[string]interface{}{inputs[0], {"signal": 1.0, "color": "red"}} {
+		if _, err := engine.Predict(input); err != nil {
+			t.Errorf("Prediction of %v failed: %v", input, err)
+		}
+	}
+}
+
+// TestPrunesWeight tests which weight keys belong to a pruned feature
+func TestPrunesWeight(t *testing.T) {
+	model := &Model{Schema: &Schema{Inputs: map[string]*FieldSchema{"tags": {}, "tags_total": {}, "other": {}}}}
+	cases := map[string]bool{
+		"tags->y":                  true,
+		"tags=a->y:buy":            true,
+		"tags_count->y":            true,
+		"tags.name=x->y":           true,
+		"tags#3->y":                true,
+		"other*tags^2->y":          true,
+		"bias->y":                  false,
+		"tagsize->y":               false,
+		"tags_total->y":            false,
+		"tags_total_is_missing->y": false,
+		"other->y:tags=a":          false,
+	}
+	for key, want := range cases {
+		if got := model.prunesWeight(key, "tags"); got != want {
+			t.Errorf("Expected prunesWeight(%q) to be %v, got %v", key, want, got)
+		}
+	}
+}
+
+// TestPruneDerivedFeatures tests that the features generated from a pruned field lose their weights
+func TestPruneDerivedFeatures(t *testing.T) {
+	model := NewCategoricalModel().WithImputer(Imputer{Strategy: ImputeMean, Indicator: true}, "size")
+
+	engine := New()
+	engine.WithModel(model.JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 20, BatchSize: 7})
+
+	var inputs, outputs []map[string]interface{}
+	for day := 1; day <= 14; day++ {
+		date := time.Date(2024, 1, day, 9, 0, 0, 0, time.UTC)
+		input := map[string]interface{}{"created": date.Format(time.RFC3339), "size": float64(day), "size_total": float64(2 * day)}
+		if day%5 == 0 {
+			delete(input, "size")
+		}
+		inputs = append(inputs, input)
+		outputs = append(outputs, map[string]interface{}{"label": []string{"a", "b"}[day%2]})
+	}
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+	_, weights, _ := engine.snapshot()
+	for _, key := range []string{"size_is_missing->label:a", "created_hour_sin->label:a"} {
+		if _, exists := weights.Get(key); !exists {
+			t.Fatalf("Expected weight %s before pruning", key)
+		}
+	}
+
+	if err := engine.Prune("size", "created"); err != nil {
+		t.Fatalf("Pruning failed: %v", err)
+	}
+	pruned, weights, _ := engine.snapshot()
+	for key := range weights.Values {
+		if strings.HasPrefix(key, "size_is_missing->") || strings.HasPrefix(key, "size->") || strings.HasPrefix(key, "created_") {
+			t.Errorf("Expected weight %s to be pruned", key)
+		}
+	}
+	if _, exists := weights.Get("size_total->label:a"); !exists {
+		t.Errorf("Expected the weights of size_total to be kept")
+	}
+	if _, exists := pruned.Stats["created_hour_sin"]; exists {
+		t.Errorf("Expected no statistics of the pruned date parts")
+	}
+	if _, exists := pruned.Stats["size_total"]; !exists {
+		t.Errorf("Expected the statistics of size_total to be kept")
+	}
+
+	// Retraining must not bring the pruned features back through their imputer
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Retraining failed: %v", err)
+	}
+	_, weights, _ = engine.snapshot()
+	for key := range weights.Values {
+		if strings.HasPrefix(key, "size_is_missing->") || strings.HasPrefix(key, "size->") || strings.HasPrefix(key, "created_") {
+			t.Errorf("Expected weight %s not to be relearned after pruning", key)
+		}
+	}
+}
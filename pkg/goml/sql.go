@@ -0,0 +1,413 @@
+package goml
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// SQLLoader reads database/sql result sets into the input and output rows Engine.Train expects
+// SQL NULL values are treated as missing values and left out of the row maps
+type SQLLoader struct {
+	Roles       map[string]ColumnRole // Role per column name
+	DefaultRole ColumnRole            // Role of columns not listed in Roles
+}
+
+// SQLData holds the rows produced by an SQLLoader
+type SQLData struct {
+	Inputs  []map[string]interface{} // Input features per row
+	Outputs []map[string]interface{} // Targets per row
+	IDs     []map[string]interface{} // ID columns per row, empty when no column has RoleID
+}
+
+// NewSQLLoader creates a loader where every column is an input unless assigned another role
+func NewSQLLoader() *SQLLoader {
+	return &SQLLoader{
+		Roles:       make(map[string]ColumnRole),
+		DefaultRole: RoleInput,
+	}
+}
+
+// WithRole assigns a role to one or more columns
+func (l *SQLLoader) WithRole(role ColumnRole, columns ...string) *SQLLoader {
+	if l.Roles == nil {
+		l.Roles = make(map[string]ColumnRole)
+	}
+	for _, column := range columns {
+		l.Roles[column] = role
+	}
+	return l
+}
+
+// Query runs a query and loads all of its rows; see Load
+func (l *SQLLoader) Query(ctx context.Context, db *sql.DB, query string, args ...interface{}) (*SQLData, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query: %w", err)
+	}
+	return l.Load(rows)
+}
+
+// Load reads and closes a result set, splitting each row by column role
+// Values load as int, float64, bool or string based on the column's database type
+func (l *SQLLoader) Load(rows *sql.Rows) (*SQLData, error) {
+	defer rows.Close()
+
+	reader, err := newSQLRowReader(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &SQLData{}
+	hasIDs := false
+	for _, column := range reader.columns {
+		if l.role(column) == RoleID {
+			hasIDs = true
+		}
+	}
+
+	for rows.Next() {
+		input, output, id, err := reader.read(l)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", len(data.Inputs)+1, err)
+		}
+		data.Inputs = append(data.Inputs, input)
+		data.Outputs = append(data.Outputs, output)
+		if hasIDs {
+			data.IDs = append(data.IDs, id)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return data, nil
+}
+
+// role returns the role of a column
+func (l *SQLLoader) role(column string) ColumnRole {
+	if role, ok := l.Roles[column]; ok {
+		return role
+	}
+	return l.DefaultRole
+}
+
+// SQLDataset streams training rows from a query for Engine.TrainStream
+// The query is executed again every time the dataset is reset, so it should return
+// the same rows each run
+type SQLDataset struct {
+	loader *SQLLoader
+	ctx    context.Context
+	db     *sql.DB
+	query  string
+	args   []interface{}
+	rows   *sql.Rows
+	reader *sqlRowReader
+}
+
+// NewSQLDataset creates a dataset over a query; the query runs on the first Reset or Next
+func (l *SQLLoader) NewSQLDataset(ctx context.Context, db *sql.DB, query string, args ...interface{}) *SQLDataset {
+	return &SQLDataset{
+		loader: l,
+		ctx:    ctx,
+		db:     db,
+		query:  query,
+		args:   args,
+	}
+}
+
+// Next returns the input and output maps of the next row, or io.EOF at the end
+func (d *SQLDataset) Next() (map[string]interface{}, map[string]interface{}, error) {
+	if d.rows == nil {
+		if err := d.Reset(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !d.rows.Next() {
+		err := d.rows.Err()
+		d.rows.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read rows: %w", err)
+		}
+		return nil, nil, io.EOF
+	}
+
+	input, output, _, err := d.reader.read(d.loader)
+	return input, output, err
+}
+
+// Reset closes the current result set and runs the query again
+func (d *SQLDataset) Reset() error {
+	if err := d.Close(); err != nil {
+		return err
+	}
+
+	rows, err := d.db.QueryContext(d.ctx, d.query, d.args...)
+	if err != nil {
+		return fmt.Errorf("failed to run query: %w", err)
+	}
+
+	reader, err := newSQLRowReader(rows)
+	if err != nil {
+		rows.Close()
+		return err
+	}
+
+	d.rows = rows
+	d.reader = reader
+	return nil
+}
+
+// Close releases the current result set
+func (d *SQLDataset) Close() error {
+	if d.rows == nil {
+		return nil
+	}
+	err := d.rows.Close()
+	d.rows = nil
+	return err
+}
+
+// ScoreRows predicts every row of a result set and writes each prediction through a
+// prepared statement, returning the number of rows written
+// Statement arguments are taken from params in order; each name is looked up in the
+// prediction first and then in the row's ID columns, for example
+//
+//	stmt, _ := db.Prepare("UPDATE houses SET predicted_price = ? WHERE id = ?")
+//	engine.ScoreRows(ctx, rows, loader, stmt, "price", "id")
+//
+// Rows are predicted in batches of Config.BatchSize with PredictBatch. Scoring stops at
+// the first failing row. Drivers that allow a single connection may need rows and stmt
+// to come from different connections
+func (e *Engine) ScoreRows(ctx context.Context, rows *sql.Rows, loader *SQLLoader, stmt *sql.Stmt, params ...string) (int, error) {
+	defer rows.Close()
+
+	if loader == nil {
+		loader = NewSQLLoader()
+	}
+
+	reader, err := newSQLRowReader(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	_, _, config := e.snapshot()
+	batchSize := config.BatchSize
+	if batchSize < 1 {
+		batchSize = DefaultConfig().BatchSize
+	}
+
+	written := 0
+	var inputs, ids []map[string]interface{}
+
+	// flush predicts the pending rows and writes their predictions
+	flush := func() error {
+		if len(inputs) == 0 {
+			return nil
+		}
+
+		predictions, err := e.PredictBatch(inputs)
+		if err != nil {
+			return fmt.Errorf("failed to score rows: %w", err)
+		}
+
+		for i, prediction := range predictions {
+			args, err := scoreArgs(params, prediction, ids[i])
+			if err != nil {
+				return fmt.Errorf("row %d: %w", written+1, err)
+			}
+			if _, err := stmt.ExecContext(ctx, args...); err != nil {
+				return fmt.Errorf("failed to write prediction for row %d: %w", written+1, err)
+			}
+			written++
+		}
+
+		inputs, ids = inputs[:0], ids[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		input, _, id, err := reader.read(loader)
+		if err != nil {
+			return written, fmt.Errorf("row %d: %w", written+len(inputs)+1, err)
+		}
+		inputs = append(inputs, input)
+		ids = append(ids, id)
+
+		if len(inputs) >= batchSize {
+			if err := flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return written, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	if err := flush(); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// scoreArgs builds the statement arguments for one prediction
+func scoreArgs(params []string, prediction map[string]interface{}, id map[string]interface{}) ([]interface{}, error) {
+	args := make([]interface{}, 0, len(params))
+	for _, param := range params {
+		val, ok := prediction[param]
+		if !ok {
+			val, ok = id[param]
+		}
+		if !ok {
+			return nil, fmt.Errorf("no prediction or ID column named %q: %w", param, ErrInvalidOutput)
+		}
+
+		// Probability maps and other composite values cannot be bound to a parameter
+		switch val.(type) {
+		case map[string]float64, map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("value of %q cannot be written to SQL: %w", param, ErrInvalidOutput)
+		}
+		args = append(args, val)
+	}
+	return args, nil
+}
+
+// sqlRowReader scans the rows of a result set into row maps
+type sqlRowReader struct {
+	rows    *sql.Rows
+	columns []string
+	types   []ColumnType
+	values  []interface{}
+	dest    []interface{}
+}
+
+// newSQLRowReader prepares scanning for a result set
+func newSQLRowReader(rows *sql.Rows) (*sqlRowReader, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	reader := &sqlRowReader{
+		rows:    rows,
+		columns: columns,
+		types:   make([]ColumnType, len(columns)),
+		values:  make([]interface{}, len(columns)),
+		dest:    make([]interface{}, len(columns)),
+	}
+	for i := range reader.values {
+		reader.dest[i] = &reader.values[i]
+	}
+
+	// Database types decide how text values from drivers such as MySQL are parsed
+	if columnTypes, err := rows.ColumnTypes(); err == nil {
+		for i, columnType := range columnTypes {
+			reader.types[i] = sqlColumnType(columnType.DatabaseTypeName())
+		}
+	}
+
+	return reader, nil
+}
+
+// read scans the current row into input, output and ID maps
+func (r *sqlRowReader) read(loader *SQLLoader) (map[string]interface{}, map[string]interface{}, map[string]interface{}, error) {
+	if err := r.rows.Scan(r.dest...); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	input := make(map[string]interface{})
+	output := make(map[string]interface{})
+	id := make(map[string]interface{})
+
+	for i, column := range r.columns {
+		role := loader.role(column)
+		if role == RoleIgnore {
+			continue
+		}
+
+		val, ok := convertSQLValue(r.values[i], r.types[i])
+		if !ok {
+			// SQL NULL is a missing value
+			continue
+		}
+
+		switch role {
+		case RoleOutput:
+			output[column] = val
+		case RoleID:
+			id[column] = val
+		default:
+			input[column] = val
+		}
+	}
+
+	return input, output, id, nil
+}
+
+// sqlColumnType maps a database type name to the column type its values load as
+func sqlColumnType(databaseType string) ColumnType {
+	name := strings.ToUpper(databaseType)
+	switch {
+	case strings.Contains(name, "BOOL"):
+		return ColumnBool
+	case strings.Contains(name, "INT") && !strings.Contains(name, "INTERVAL") && !strings.Contains(name, "POINT"):
+		return ColumnInt
+	case strings.Contains(name, "DECIMAL"), strings.Contains(name, "NUMERIC"), strings.Contains(name, "REAL"),
+		strings.Contains(name, "FLOAT"), strings.Contains(name, "DOUBLE"):
+		return ColumnFloat
+	default:
+		return ColumnUnknown
+	}
+}
+
+// convertSQLValue converts a scanned driver value to the Go types the models use
+// It returns false for SQL NULL
+func convertSQLValue(val interface{}, columnType ColumnType) (interface{}, bool) {
+	switch v := val.(type) {
+	case nil:
+		return nil, false
+	case int64:
+		if columnType == ColumnBool {
+			// Drivers without a boolean type store flags as 0/1
+			return v != 0, true
+		}
+		if v >= math.MinInt && v <= math.MaxInt {
+			return int(v), true
+		}
+		return v, true
+	case []byte:
+		return convertSQLText(string(v), columnType), true
+	case string:
+		return convertSQLText(v, columnType), true
+	default:
+		// float64, bool and time.Time are used as scanned
+		return v, true
+	}
+}
+
+// convertSQLText parses text from numeric and boolean columns, keeping other text as strings
+func convertSQLText(text string, columnType ColumnType) interface{} {
+	if columnType == ColumnUnknown {
+		return text
+	}
+	if columnType == ColumnBool {
+		// Drivers may return booleans as "t"/"f" or "1"/"0"
+		switch strings.ToLower(text) {
+		case "t", "1":
+			return true
+		case "f", "0":
+			return false
+		}
+	}
+
+	val, err := parseColumnValue(strings.TrimSpace(text), columnType)
+	if err != nil {
+		return text
+	}
+	return val
+}
@@ -0,0 +1,266 @@
+package goml
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeDatabase is an in-process database/sql stand-in with canned query results
+type fakeDatabase struct {
+	mu      sync.Mutex
+	results map[string]*fakeResult
+	execs   map[string][][]driver.Value
+	queries int
+}
+
+// fakeResult is the result set returned for a query
+type fakeResult struct {
+	columns []string
+	types   []string
+	rows    [][]driver.Value
+}
+
+var (
+	fakeDatabasesMu sync.Mutex
+	fakeDatabases   = make(map[string]*fakeDatabase)
+)
+
+func init() {
+	sql.Register("gomlfake", fakeDriver{})
+}
+
+// openFakeDB creates a named fake database and opens it through database/sql
+func openFakeDB(t *testing.T, results map[string]*fakeResult) (*sql.DB, *fakeDatabase) {
+	fake := &fakeDatabase{results: results, execs: make(map[string][][]driver.Value)}
+
+	fakeDatabasesMu.Lock()
+	fakeDatabases[t.Name()] = fake
+	fakeDatabasesMu.Unlock()
+
+	db, err := sql.Open("gomlfake", t.Name())
+	if err != nil {
+		t.Fatalf("Failed to open fake database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, fake
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDatabasesMu.Lock()
+	defer fakeDatabasesMu.Unlock()
+	fake, ok := fakeDatabases[name]
+	if !ok {
+		return nil, errors.New("unknown fake database")
+	}
+	return &fakeConn{db: fake}, nil
+}
+
+type fakeConn struct {
+	db *fakeDatabase
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+type fakeStmt struct {
+	db    *fakeDatabase
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.execs[s.query] = append(s.db.execs[s.query], args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	result, ok := s.db.results[s.query]
+	if !ok {
+		return nil, errors.New("unknown query")
+	}
+	s.db.queries++
+	return &fakeRows{result: result}, nil
+}
+
+type fakeRows struct {
+	result *fakeResult
+	pos    int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.result.types[index]
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+// houseResult returns a result set of houses with a NULL value and text-encoded numbers
+func houseResult() *fakeResult {
+	return &fakeResult{
+		columns: []string{"id", "size", "rooms", "urban", "city", "price"},
+		types:   []string{"BIGINT", "DECIMAL", "INTEGER", "BOOLEAN", "TEXT", "REAL"},
+		rows: [][]driver.Value{
+			{int64(1), []byte("1.0"), int64(2), int64(1), []byte("riga"), 3.0},
+			{int64(2), []byte("2.0"), int64(3), int64(0), []byte("tallinn"), 5.0},
+			{int64(3), []byte("3.0"), nil, true, []byte("riga"), 7.0},
+			{int64(4), []byte("4.0"), int64(4), false, nil, 9.0},
+		},
+	}
+}
+
+// TestSQLLoaderQuery tests converting columns into typed training rows
+func TestSQLLoaderQuery(t *testing.T) {
+	db, _ := openFakeDB(t, map[string]*fakeResult{"SELECT * FROM houses": houseResult()})
+
+	loader := NewSQLLoader().WithRole(RoleID, "id").WithRole(RoleOutput, "price")
+	data, err := loader.Query(context.Background(), db, "SELECT * FROM houses")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(data.Inputs) != 4 || len(data.Outputs) != 4 || len(data.IDs) != 4 {
+		t.Fatalf("Expected 4 rows, got %d inputs, %d outputs, %d IDs", len(data.Inputs), len(data.Outputs), len(data.IDs))
+	}
+
+	first := data.Inputs[0]
+	if first["size"] != 1.0 || first["rooms"] != 2 || first["urban"] != true || first["city"] != "riga" {
+		t.Errorf("Unexpected value types in first row: %#v", first)
+	}
+	if _, exists := data.Inputs[2]["rooms"]; exists {
+		t.Errorf("Expected NULL to be left out as a missing value, got %v", data.Inputs[2])
+	}
+	if _, exists := data.Inputs[3]["city"]; exists {
+		t.Errorf("Expected NULL to be left out as a missing value, got %v", data.Inputs[3])
+	}
+	if data.Outputs[1]["price"] != 5.0 || data.IDs[1]["id"] != 2 {
+		t.Errorf("Unexpected output or ID: %v %v", data.Outputs[1], data.IDs[1])
+	}
+	if _, exists := data.Inputs[0]["id"]; exists {
+		t.Error("ID columns should not be inputs")
+	}
+}
+
+// TestSQLDatasetTrainStream tests streaming training that re-runs the query every epoch
+func TestSQLDatasetTrainStream(t *testing.T) {
+	db, fake := openFakeDB(t, map[string]*fakeResult{"SELECT size, price FROM houses": {
+		columns: []string{"size", "price"},
+		types:   []string{"REAL", "REAL"},
+		rows: [][]driver.Value{
+			{1.0, 3.0}, {2.0, 5.0}, {3.0, 7.0}, {4.0, 9.0},
+		},
+	}})
+
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 200, BatchSize: 2})
+
+	loader := NewSQLLoader().WithRole(RoleOutput, "price")
+	ds := loader.NewSQLDataset(context.Background(), db, "SELECT size, price FROM houses")
+	defer ds.Close()
+
+	if err := engine.TrainStream(ds); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+
+	// One statistics pass plus one pass per epoch
+	if fake.queries != 201 {
+		t.Errorf("Expected 201 query runs, got %d", fake.queries)
+	}
+
+	prediction, err := engine.Predict(map[string]interface{}{"size": 5.0})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if price := prediction["price"].(float64); price < 9 || price > 13 {
+		t.Errorf("Prediction outside expected range: %v", price)
+	}
+}
+
+// TestScoreRows tests writing batch predictions back through a prepared statement
+func TestScoreRows(t *testing.T) {
+	db, fake := openFakeDB(t, map[string]*fakeResult{"SELECT * FROM houses": houseResult()})
+	ctx := context.Background()
+
+	loader := NewSQLLoader().
+		WithRole(RoleID, "id").
+		WithRole(RoleIgnore, "price", "city", "urban", "rooms")
+	training, err := loader.Query(ctx, db, "SELECT * FROM houses")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	outputs := make([]map[string]interface{}, len(training.Inputs))
+	for i, input := range training.Inputs {
+		outputs[i] = map[string]interface{}{"price": 2*input["size"].(float64) + 1}
+	}
+
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 200, BatchSize: 3})
+	if err := engine.Train(training.Inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	update := "UPDATE houses SET predicted_price = ? WHERE id = ?"
+	stmt, err := db.Prepare(update)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	defer stmt.Close()
+
+	rows, err := db.QueryContext(ctx, "SELECT * FROM houses")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	written, err := engine.ScoreRows(ctx, rows, loader, stmt, "price", "id")
+	if err != nil {
+		t.Fatalf("ScoreRows failed: %v", err)
+	}
+	if written != 4 || len(fake.execs[update]) != 4 {
+		t.Fatalf("Expected 4 writes, got %d (%d executed)", written, len(fake.execs[update]))
+	}
+
+	for i, args := range fake.execs[update] {
+		if args[1] != int64(i+1) {
+			t.Errorf("Expected id %d as the second argument, got %v", i+1, args[1])
+		}
+		if _, ok := args[0].(float64); !ok {
+			t.Errorf("Expected a float prediction, got %T", args[0])
+		}
+	}
+
+	// Unknown parameters fail instead of writing incomplete rows
+	rows, _ = db.QueryContext(ctx, "SELECT * FROM houses")
+	if _, err := engine.ScoreRows(ctx, rows, loader, stmt, "missing"); !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Expected ErrInvalidOutput for an unknown parameter, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package goml
+
+import (
+	"math"
+)
+
+// centeredTolerance is the size of a mean, relative to the spread of the feature, below which
+// the feature counts as centered
+const centeredTolerance = 1e-9
+
+// FeatureStats holds running statistics of a numeric feature
+// They are updated incrementally so online training can keep them current
+type FeatureStats struct {
+	Count int     `json:"count"`
+	Sum   float64 `json:"sum"`
+	M2    float64 `json:"m2"` // Sum of squared deviations from the mean (Welford's method)
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+}
+
+// Add folds a new observation into the statistics
+func (s *FeatureStats) Add(x float64) {
+	oldMean := s.Mean()
+
+	s.Count++
+	s.Sum += x
+	s.M2 += (x - oldMean) * (x - s.Mean())
+
+	if s.Count == 1 || x < s.Min {
+		s.Min = x
+	}
+	if s.Count == 1 || x > s.Max {
+		s.Max = x
+	}
+}
+
+// Mean returns the mean of the observations, or 0 if there are none
+func (s *FeatureStats) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// Variance returns the population variance of the observations
+func (s *FeatureStats) Variance() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.M2 / float64(s.Count)
+}
+
+// StdDev returns the population standard deviation of the observations
+func (s *FeatureStats) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// centered reports whether the mean of the feature is zero up to rounding, as for standardized
+// features and principal components
+func (s *FeatureStats) centered() bool {
+	return math.Abs(s.Mean()) <= centeredTolerance*math.Max(s.StdDev(), 1)
+}
+
+// featureMeans returns the training means that normalize the gradients of the features
+// Centered features are left out, since dividing by a mean of about zero blows the weights up
+func (m *Model) featureMeans(features []string) map[string]float64 {
+	means := make(map[string]float64)
+	for _, feature := range features {
+		if stats, ok := m.Stats[feature]; ok && stats.Count > 0 && !stats.centered() {
+			means[feature] = stats.Mean()
+		}
+	}
+	return means
+}
+
+// updateStats folds the numeric features of the given rows into the model's running statistics
+func (m *Model) updateStats(inputs []map[string]interface{}) {
+	if m.Stats == nil {
+		m.Stats = make(map[string]*FeatureStats)
+	}
+
+	for _, input := range inputs {
+		for feature, val := range input {
+			// Only include numeric types, like the mean used for normalization
+			if !IsSupportedNumericType(val) {
+				continue
+			}
+
+			// Safe to convert since we checked type
+			numVal, _ := ConvertToFloat64(val, "")
+
+			stats, exists := m.Stats[feature]
+			if !exists {
+				stats = &FeatureStats{}
+				m.Stats[feature] = stats
+			}
+			stats.Add(numVal)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package goml
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// TimePart is a feature derived from a date/time value
+type TimePart string
+
+const (
+	TimeEpoch     TimePart = "epoch"       // Seconds since the Unix epoch
+	TimeYear      TimePart = "year"        // Calendar year
+	TimeMonth     TimePart = "month"       // Month, 1-12
+	TimeDay       TimePart = "day"         // Day of the month, 1-31
+	TimeDayOfYear TimePart = "day_of_year" // Day of the year, 1-366
+	TimeWeekday   TimePart = "weekday"     // Day of the week, 0 (Sunday) to 6
+	TimeHour      TimePart = "hour"        // Hour of the day, 0-23
+	TimeMinute    TimePart = "minute"      // Minute of the hour, 0-59
+	TimeWeekend   TimePart = "weekend"     // 1 on Saturday and Sunday, 0 otherwise
+)
+
+// timePeriods holds the period of each part that can be encoded cyclically
+var timePeriods = map[TimePart]float64{
+	TimeMonth:     12,
+	TimeDay:       31,
+	TimeDayOfYear: 366,
+	TimeWeekday:   7,
+	TimeHour:      24,
+	TimeMinute:    60,
+}
+
+// TimeFeatures expands a date/time feature into derived numeric features
+// Values can be time.Time, RFC3339 strings, strings in Layout, or Unix seconds. Parts become
+// features named "<feature>_<part>", cyclic parts "<feature>_<part>_sin" and "<feature>_<part>_cos",
+// and calendars "<feature>_<calendar>"
+type TimeFeatures struct {
+	Parts    []TimePart `json:"parts"`
+	Cyclic   bool       `json:"cyclic,omitempty"`   // Encode periodic parts as sin/cos pairs so that e.g. hour 23 is close to hour 0
+	Layout   string     `json:"layout,omitempty"`   // time.Parse layout for strings that are not RFC3339
+	Location string     `json:"location,omitempty"` // IANA time zone the parts are computed in (default UTC)
+
+	// Calendars are named sets of dates, such as holidays, that add a 1/0 feature each
+	// Dates are "2006-01-02" for a single day or "01-02" for a day every year
+	Calendars map[string][]string `json:"calendars,omitempty"`
+}
+
+// DefaultTimeFeatures returns the expansion used for time.Time values and RFC3339 strings in
+// features without a TimeFeatures configuration: cyclic hour, weekday and month
+func DefaultTimeFeatures() TimeFeatures {
+	return TimeFeatures{
+		Parts:  []TimePart{TimeHour, TimeWeekday, TimeMonth},
+		Cyclic: true,
+	}
+}
+
+// WithTime declares how one or more date/time features are expanded
+func (m *Model) WithTime(features TimeFeatures, names ...string) *Model {
+	for _, name := range names {
+		spec := m.featureSpec(name)
+		featuresCopy := features
+		featuresCopy.Parts = append([]TimePart(nil), features.Parts...)
+		spec.Time = &featuresCopy
+	}
+	return m
+}
+
+// validate checks the time feature configuration
+func (tf *TimeFeatures) validate(feature string) error {
+	for _, part := range tf.Parts {
+		switch part {
+		case TimeEpoch, TimeYear, TimeMonth, TimeDay, TimeDayOfYear, TimeWeekday, TimeHour, TimeMinute, TimeWeekend:
+		default:
+			return fmt.Errorf("feature %q: unknown time part %q: %w", feature, part, ErrInvalidInput)
+		}
+	}
+
+	if _, err := loadTimeLocation(tf.Location); err != nil {
+		return fmt.Errorf("feature %q: %w: %w", feature, err, ErrInvalidInput)
+	}
+
+	for name, dates := range tf.Calendars {
+		for _, date := range dates {
+			if _, err := time.Parse("2006-01-02", date); err == nil {
+				continue
+			}
+			if _, err := time.Parse("01-02", date); err == nil {
+				continue
+			}
+			return fmt.Errorf("feature %q: calendar %q has invalid date %q: %w", feature, name, date, ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// apply replaces the time feature in the row with its derived features
+// Values that cannot be read as a time are dropped, like other unusable values
+func (tf *TimeFeatures) apply(feature string, row map[string]interface{}) {
+	val, exists := row[feature]
+	if !exists {
+		return
+	}
+	delete(row, feature)
+
+	t, ok := tf.parse(val)
+	if !ok {
+		return
+	}
+
+	if loc, err := loadTimeLocation(tf.Location); err == nil {
+		t = t.In(loc)
+	}
+
+	for _, part := range tf.Parts {
+		x := timePartValue(t, part)
+		name := feature + "_" + string(part)
+
+		if period, periodic := timePeriods[part]; periodic && tf.Cyclic {
+			angle := 2 * math.Pi * x / period
+			row[name+"_sin"] = math.Sin(angle)
+			row[name+"_cos"] = math.Cos(angle)
+			continue
+		}
+		row[name] = x
+	}
+
+	date := t.Format("2006-01-02")
+	monthDay := t.Format("01-02")
+	for name, dates := range tf.Calendars {
+		inCalendar := 0.0
+		for _, d := range dates {
+			if d == date || d == monthDay {
+				inCalendar = 1.0
+				break
+			}
+		}
+		row[feature+"_"+name] = inCalendar
+	}
+}
+
+// parse reads a time from a time.Time, a string or Unix seconds
+func (tf *TimeFeatures) parse(val interface{}) (time.Time, bool) {
+	switch v := val.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+		return *v, true
+	case string:
+		if tf.Layout != "" {
+			loc, err := loadTimeLocation(tf.Location)
+			if err != nil {
+				return time.Time{}, false
+			}
+			t, err := time.ParseInLocation(tf.Layout, v, loc)
+			return t, err == nil
+		}
+		return parseTimestamp(v)
+	default:
+		if IsSupportedNumericType(v) {
+			seconds, _ := ConvertToFloat64(v, "")
+			whole, frac := math.Modf(seconds)
+			return time.Unix(int64(whole), int64(frac*1e9)).UTC(), true
+		}
+		return time.Time{}, false
+	}
+}
+
+// timePartValue returns the numeric value of a part
+// Month, day and day of year count from 1 and weekday, hour and minute from 0. Both encode
+// cyclically with the right period, since the last value of a period lands where 0 would
+func timePartValue(t time.Time, part TimePart) float64 {
+	switch part {
+	case TimeEpoch:
+		return float64(t.UnixNano()) / 1e9
+	case TimeYear:
+		return float64(t.Year())
+	case TimeMonth:
+		return float64(t.Month())
+	case TimeDay:
+		return float64(t.Day())
+	case TimeDayOfYear:
+		return float64(t.YearDay())
+	case TimeWeekday:
+		return float64(t.Weekday())
+	case TimeHour:
+		return float64(t.Hour())
+	case TimeMinute:
+		return float64(t.Minute())
+	case TimeWeekend:
+		if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+			return 1
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+// parseTimestamp parses an RFC3339 string, with or without fractional seconds
+func parseTimestamp(s string) (time.Time, bool) {
+	// Cheap shape check so ordinary strings are not run through the parser
+	if len(s) < len("2006-01-02T15:04:05Z") || s[4] != '-' || s[7] != '-' || (s[10] != 'T' && s[10] != 't') {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// isTimeValue reports whether a value is recognized as a date/time without configuration
+func isTimeValue(val interface{}) bool {
+	switch v := val.(type) {
+	case time.Time:
+		return true
+	case *time.Time:
+		return v != nil
+	case string:
+		_, ok := parseTimestamp(v)
+		return ok
+	default:
+		return false
+	}
+}
+
+// timeLocations caches loaded time zones by name
+var timeLocations sync.Map
+
+// loadTimeLocation loads a time zone by IANA name; empty means UTC
+func loadTimeLocation(name string) (*time.Location, error) {
+	if name == "" || name == "UTC" {
+		return time.UTC, nil
+	}
+	if loc, ok := timeLocations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	timeLocations.Store(name, loc)
+	return loc, nil
+}
@@ -0,0 +1,150 @@
+package goml
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestTimeFeaturesApply tests the derived parts, cyclic encodings, calendars and time zones
+func TestTimeFeaturesApply(t *testing.T) {
+	features := TimeFeatures{
+		Parts:     []TimePart{TimeEpoch, TimeYear, TimeHour, TimeWeekday, TimeWeekend},
+		Location:  "Europe/Riga",
+		Calendars: map[string][]string{"holiday": {"12-25", "2024-06-24"}},
+	}
+
+	// 22:30 UTC on Christmas Eve is already Christmas Day in Riga
+	ts := time.Date(2024, 12, 24, 22, 30, 0, 0, time.UTC)
+	row := map[string]interface{}{"ts": ts, "other": 1.0}
+	features.apply("ts", row)
+
+	expected := map[string]float64{
+		"ts_epoch":   float64(ts.Unix()),
+		"ts_year":    2024,
+		"ts_hour":    0,
+		"ts_weekday": float64(time.Wednesday),
+		"ts_weekend": 0,
+		"ts_holiday": 1,
+	}
+	for key, want := range expected {
+		if got, ok := row[key].(float64); !ok || got != want {
+			t.Errorf("Expected %s = %v, got %v", key, want, row[key])
+		}
+	}
+	if _, exists := row["ts"]; exists {
+		t.Error("Expected the original date feature to be replaced")
+	}
+	if row["other"] != 1.0 {
+		t.Error("Expected other features to be kept")
+	}
+
+	// Cyclic hours put 23:00 next to 00:00
+	cyclic := TimeFeatures{Parts: []TimePart{TimeHour}, Cyclic: true}
+	late := map[string]interface{}{"ts": "2024-03-01T23:00:00Z"}
+	early := map[string]interface{}{"ts": "2024-03-01T00:00:00Z"}
+	noon := map[string]interface{}{"ts": "2024-03-01T12:00:00Z"}
+	for _, r := range []map[string]interface{}{late, early, noon} {
+		cyclic.apply("ts", r)
+	}
+	distance := func(a, b map[string]interface{}) float64 {
+		return math.Hypot(a["ts_hour_sin"].(float64)-b["ts_hour_sin"].(float64), a["ts_hour_cos"].(float64)-b["ts_hour_cos"].(float64))
+	}
+	if distance(late, early) >= distance(early, noon) {
+		t.Errorf("Expected 23:00 closer to 00:00 than noon is, got %v and %v", distance(late, early), distance(early, noon))
+	}
+
+	// Layouts read other date formats and numbers are Unix seconds
+	layout := TimeFeatures{Parts: []TimePart{TimeMonth, TimeDay}, Layout: "02/01/2006"}
+	row = map[string]interface{}{"a": "15/08/2023", "b": float64(ts.Unix())}
+	layout.apply("a", row)
+	layout.apply("b", row)
+	if row["a_month"] != 8.0 || row["a_day"] != 15.0 || row["b_month"] != 12.0 || row["b_day"] != 24.0 {
+		t.Errorf("Unexpected parts from layout and Unix seconds: %v", row)
+	}
+}
+
+// TestTimeFeaturesTrainPredict tests that dates are expanded the same way at train and predict time
+func TestTimeFeaturesTrainPredict(t *testing.T) {
+	model := NewCategoricalModel().WithTime(TimeFeatures{Parts: []TimePart{TimeWeekend}}, "day")
+
+	engine := New()
+	engine.WithModel(model.JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 200, BatchSize: 7})
+
+	// Weekends are busy; the created timestamp uses the default expansion
+	var inputs, outputs []map[string]interface{}
+	for day := 1; day <= 14; day++ {
+		date := time.Date(2024, 1, day, 9, 0, 0, 0, time.UTC)
+		visits := "quiet"
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			visits = "busy"
+		}
+		inputs = append(inputs, map[string]interface{}{"day": date, "created": date.Format(time.RFC3339)})
+		outputs = append(outputs, map[string]interface{}{"visits": visits})
+	}
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	trained, weights, _ := engine.snapshot()
+	for _, key := range []string{"day_weekend->visits:busy", "created_hour_sin->visits:busy", "created_weekday_cos->visits:busy", "created_month_sin->visits:busy"} {
+		if _, exists := weights.Get(key); !exists {
+			t.Errorf("Expected weight %s", key)
+		}
+	}
+	if kind := trained.Schema.Inputs["created"].Kind; kind != KindTime {
+		t.Errorf("Expected RFC3339 strings to be inferred as time, got %s", kind)
+	}
+
+	saturday, err := engine.Predict(map[string]interface{}{"day": "2024-02-03T09:00:00Z", "created": "2024-02-03T09:00:00Z"})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	monday, err := engine.Predict(map[string]interface{}{"day": "2024-02-05T09:00:00Z", "created": "2024-02-05T09:00:00Z"})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if saturday["visits"] != "busy" || monday["visits"] != "quiet" {
+		t.Errorf("Expected a busy Saturday and a quiet Monday, got %v and %v", saturday["visits"], monday["visits"])
+	}
+
+	// Streaming training expands dates the same way
+	streamed := New()
+	streamed.WithModel(model.JSON())
+	streamed.WithConfig(&Config{LearningRate: 0.1, Epochs: 2, BatchSize: 7})
+	if err := streamed.TrainStream(NewSliceDataset(inputs, outputs)); err != nil {
+		t.Fatalf("Streaming training failed: %v", err)
+	}
+	streamedModel, _, _ := streamed.snapshot()
+	if stats := streamedModel.Stats["created_hour_cos"]; stats == nil || stats.Count != 14 {
+		t.Errorf("Expected statistics of the default date features, got %+v", stats)
+	}
+}
+
+// TestTimeFeaturesInvalidConfiguration tests rejection of unknown parts, time zones and calendar dates
+func TestTimeFeaturesInvalidConfiguration(t *testing.T) {
+	for _, features := range []TimeFeatures{
+		{Parts: []TimePart{"fortnight"}},
+		{Parts: []TimePart{TimeHour}, Location: "Mars/Olympus"},
+		{Calendars: map[string][]string{"holiday": {"Dec 25"}}},
+	} {
+		engine := New()
+		engine.WithModel(NewLinearModel().WithTime(features, "ts").JSON())
+
+		err := engine.Train([]map[string]interface{}{{"ts": "2024-01-01T00:00:00Z"}}, []map[string]interface{}{{"y": 1.0}})
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for %+v, got %v", features, err)
+		}
+	}
+}
+
+// TestConvertTimeToFloat64 tests that time.Time values convert to Unix seconds
+func TestConvertTimeToFloat64(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 1, 500000000, time.UTC)
+	x, ok := ConvertToFloat64(ts, "")
+	if !ok || x != float64(ts.Unix())+0.5 {
+		t.Errorf("Expected %v, got %v (%v)", float64(ts.Unix())+0.5, x, ok)
+	}
+}
@@ -0,0 +1,237 @@
+package goml
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// TextMode selects how a text feature is turned into numeric features
+type TextMode string
+
+const (
+	TextCount   TextMode = "count"   // Bag-of-words: number of occurrences of each vocabulary term
+	TextBinary  TextMode = "binary"  // Bag-of-words: 1 for each vocabulary term present
+	TextTFIDF   TextMode = "tfidf"   // Term frequency times inverse document frequency, L2 normalized
+	TextHashing TextMode = "hashing" // Term counts hashed into a fixed number of buckets, no vocabulary
+)
+
+// EnglishStopWords is a small list of common English words to use as TextVectorizer.StopWords
+var EnglishStopWords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from", "has", "have",
+	"he", "her", "his", "i", "in", "is", "it", "its", "of", "on", "or", "our", "she", "so",
+	"that", "the", "their", "them", "there", "they", "this", "to", "was", "we", "were",
+	"will", "with", "you", "your",
+}
+
+// TextVectorizer turns a string feature into one numeric feature per term
+// Text is lowercased and split into words at every character that is not a letter or digit.
+// Vocabulary terms become features named "<feature>=<term>" and hash buckets features named
+// "<feature>#<bucket>". Terms missing from a row are left out, which the models treat as 0
+type TextVectorizer struct {
+	Mode        TextMode `json:"mode"`
+	NGramMin    int      `json:"ngram_min,omitempty"`    // Smallest n-gram length (default 1)
+	NGramMax    int      `json:"ngram_max,omitempty"`    // Largest n-gram length (default NGramMin)
+	StopWords   []string `json:"stop_words,omitempty"`   // Words dropped before n-grams are formed
+	MinDF       int      `json:"min_df,omitempty"`       // Terms in fewer training rows are dropped
+	MaxFeatures int      `json:"max_features,omitempty"` // Keep only the terms in the most training rows (0 keeps all)
+	HashBuckets int      `json:"hash_buckets,omitempty"` // Number of buckets for TextHashing
+
+	Vocabulary map[string]float64 `json:"vocabulary,omitempty"` // Term -> IDF weight (1 unless TF-IDF), learned by Train
+}
+
+// WithText declares one or more string features as text to vectorize
+// The vocabulary and IDF weights are learned when the model is trained and saved with the model
+func (m *Model) WithText(vectorizer TextVectorizer, features ...string) *Model {
+	for _, feature := range features {
+		spec := m.featureSpec(feature)
+		vectorizerCopy := vectorizer
+		vectorizerCopy.StopWords = append([]string(nil), vectorizer.StopWords...)
+		spec.Text = &vectorizerCopy
+	}
+	return m
+}
+
+// validate checks the vectorizer configuration
+func (v *TextVectorizer) validate(feature string) error {
+	switch v.Mode {
+	case TextCount, TextBinary, TextTFIDF:
+	case TextHashing:
+		if v.HashBuckets < 1 {
+			return fmt.Errorf("feature %q: hashing requires a positive number of buckets: %w", feature, ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("feature %q: unknown text mode %q: %w", feature, v.Mode, ErrInvalidInput)
+	}
+
+	if v.NGramMin < 0 || (v.NGramMax > 0 && v.NGramMax < v.ngramRange()[0]) {
+		return fmt.Errorf("feature %q: invalid n-gram range %d-%d: %w", feature, v.NGramMin, v.NGramMax, ErrInvalidInput)
+	}
+	return nil
+}
+
+// ngramRange returns the smallest and largest n-gram length
+func (v *TextVectorizer) ngramRange() [2]int {
+	lo := v.NGramMin
+	if lo < 1 {
+		lo = 1
+	}
+	hi := v.NGramMax
+	if hi < lo {
+		hi = lo
+	}
+	return [2]int{lo, hi}
+}
+
+// terms splits a text into its words and n-grams
+func (v *TextVectorizer) terms(text string) []string {
+	stopWords := make(map[string]bool, len(v.StopWords))
+	for _, word := range v.StopWords {
+		stopWords[strings.ToLower(word)] = true
+	}
+
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !stopWords[word] {
+			words = append(words, word)
+		}
+	}
+
+	ngrams := v.ngramRange()
+	var terms []string
+	for n := ngrams[0]; n <= ngrams[1]; n++ {
+		for i := 0; i+n <= len(words); i++ {
+			terms = append(terms, strings.Join(words[i:i+n], " "))
+		}
+	}
+	return terms
+}
+
+// apply replaces the text feature in the row with its term features
+func (v *TextVectorizer) apply(feature string, row map[string]interface{}) {
+	val, exists := row[feature]
+	if !exists {
+		return
+	}
+	delete(row, feature)
+
+	text, ok := val.(string)
+	if !ok {
+		return
+	}
+
+	counts := make(map[string]float64)
+	for _, term := range v.terms(text) {
+		if v.Mode == TextHashing {
+			counts[fmt.Sprintf("%s#%d", feature, hashBucket(term, v.HashBuckets))]++
+			continue
+		}
+		if _, known := v.Vocabulary[term]; known {
+			counts[term]++
+		}
+	}
+
+	if v.Mode == TextHashing {
+		for key, count := range counts {
+			row[key] = count
+		}
+		return
+	}
+
+	// Sum of squares for the L2 normalization of TF-IDF rows
+	norm := 0.0
+	for term, count := range counts {
+		switch v.Mode {
+		case TextBinary:
+			counts[term] = 1
+		case TextTFIDF:
+			counts[term] = count * v.Vocabulary[term]
+			norm += counts[term] * counts[term]
+		}
+	}
+
+	for term, weight := range counts {
+		if v.Mode == TextTFIDF && norm > 0 {
+			weight /= math.Sqrt(norm)
+		}
+		row[feature+"="+term] = weight
+	}
+}
+
+// hashBucket maps a term to a bucket with 32-bit FNV-1a
+func hashBucket(term string, buckets int) int {
+	h := fnv.New32a()
+	h.Write([]byte(term))
+	return int(h.Sum32() % uint32(buckets))
+}
+
+// textObservation counts the training rows each term appears in
+type textObservation struct {
+	documents int
+	df        map[string]int
+}
+
+// observe records the terms of one training value; every row counts as a document
+func (o *textObservation) observe(v *TextVectorizer, val interface{}) {
+	o.documents++
+	if v.Mode == TextHashing {
+		return
+	}
+
+	text, ok := val.(string)
+	if !ok {
+		return
+	}
+
+	if o.df == nil {
+		o.df = make(map[string]int)
+	}
+	seen := make(map[string]bool)
+	for _, term := range v.terms(text) {
+		if !seen[term] {
+			seen[term] = true
+			o.df[term]++
+		}
+	}
+}
+
+// vocabulary builds the vocabulary and IDF weights from the document frequencies
+// IDF uses the smoothed form ln((1+n)/(1+df)) + 1
+func (o *textObservation) vocabulary(v *TextVectorizer) map[string]float64 {
+	if v.Mode == TextHashing {
+		return nil
+	}
+
+	terms := make([]string, 0, len(o.df))
+	for term, df := range o.df {
+		if df >= v.MinDF {
+			terms = append(terms, term)
+		}
+	}
+
+	// Most frequent terms first, ties in lexical order, so MaxFeatures is reproducible
+	sort.Slice(terms, func(i, j int) bool {
+		if o.df[terms[i]] != o.df[terms[j]] {
+			return o.df[terms[i]] > o.df[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	if v.MaxFeatures > 0 && len(terms) > v.MaxFeatures {
+		terms = terms[:v.MaxFeatures]
+	}
+
+	vocabulary := make(map[string]float64, len(terms))
+	for _, term := range terms {
+		weight := 1.0
+		if v.Mode == TextTFIDF {
+			weight = math.Log(float64(1+o.documents)/float64(1+o.df[term])) + 1
+		}
+		vocabulary[term] = weight
+	}
+	return vocabulary
+}
@@ -0,0 +1,174 @@
+package goml
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestTextTerms tests tokenization, stop words and n-grams
+func TestTextTerms(t *testing.T) {
+	vectorizer := &TextVectorizer{Mode: TextCount, NGramMin: 1, NGramMax: 2, StopWords: []string{"the"}}
+
+	terms := vectorizer.terms("The QUICK fox, the lazy-dog!")
+	expected := []string{"quick", "fox", "lazy", "dog", "quick fox", "fox lazy", "lazy dog"}
+	if !reflect.DeepEqual(terms, expected) {
+		t.Errorf("Expected terms %v, got %v", expected, terms)
+	}
+}
+
+// TestTextTFIDF tests the learned vocabulary, IDF weights and normalized TF-IDF features
+func TestTextTFIDF(t *testing.T) {
+	model := NewLinearModel().WithText(TextVectorizer{Mode: TextTFIDF, MinDF: 2}, "description")
+
+	inputs := []map[string]interface{}{
+		{"description": "cheap cheap flat"},
+		{"description": "cheap house"},
+		{"description": "large house garden"},
+		{"description": "flat"},
+	}
+	if err := model.fitPreprocessing(inputs, nil); err != nil {
+		t.Fatalf("Fitting failed: %v", err)
+	}
+
+	vocabulary := model.Features["description"].Text.Vocabulary
+	if len(vocabulary) != 3 {
+		t.Fatalf("Expected the terms in at least 2 rows, got %v", vocabulary)
+	}
+	if idf := vocabulary["cheap"]; math.Abs(idf-(math.Log(5.0/3.0)+1)) > 1e-12 {
+		t.Errorf("Unexpected IDF for cheap: %v", idf)
+	}
+
+	row := model.preprocess(map[string]interface{}{"description": "Cheap cheap flat with pool", "rooms": 2})
+	if _, exists := row["description"]; exists {
+		t.Error("Expected the text feature to be replaced by its terms")
+	}
+	if _, exists := row["description=pool"]; exists {
+		t.Error("Terms outside the vocabulary should be ignored")
+	}
+	if row["rooms"] != 2 {
+		t.Errorf("Other features should be kept, got %v", row)
+	}
+
+	cheap, flat := row["description=cheap"].(float64), row["description=flat"].(float64)
+	if norm := cheap*cheap + flat*flat; math.Abs(norm-1) > 1e-12 {
+		t.Errorf("Expected an L2 normalized row, got norm %v", norm)
+	}
+	if cheap <= flat {
+		t.Errorf("Expected the repeated term to weigh more: cheap %v, flat %v", cheap, flat)
+	}
+}
+
+// TestTextMaxFeaturesAndBinary tests vocabulary pruning by document frequency and binary weights
+func TestTextMaxFeaturesAndBinary(t *testing.T) {
+	model := NewLinearModel().WithText(TextVectorizer{Mode: TextBinary, MaxFeatures: 2}, "tags")
+
+	err := model.fitPreprocessing([]map[string]interface{}{
+		{"tags": "red blue"}, {"tags": "blue green"}, {"tags": "red blue green"}, {"tags": "yellow"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Fitting failed: %v", err)
+	}
+
+	// blue is in 3 rows; green and red tie with 2 and the tie goes to the lexically smaller term
+	vocabulary := model.Features["tags"].Text.Vocabulary
+	if len(vocabulary) != 2 || vocabulary["blue"] != 1 || vocabulary["green"] != 1 {
+		t.Errorf("Unexpected vocabulary: %v", vocabulary)
+	}
+
+	row := model.preprocess(map[string]interface{}{"tags": "blue blue green"})
+	if row["tags=blue"] != 1.0 || row["tags=green"] != 1.0 {
+		t.Errorf("Expected binary term weights, got %v", row)
+	}
+}
+
+// TestTextHashing tests fixed-width hashed features without a vocabulary
+func TestTextHashing(t *testing.T) {
+	model := NewLinearModel().WithText(TextVectorizer{Mode: TextHashing, HashBuckets: 8}, "merchant")
+	if err := model.fitPreprocessing([]map[string]interface{}{{"merchant": "Corner Coffee Shop"}}, nil); err != nil {
+		t.Fatalf("Fitting failed: %v", err)
+	}
+
+	if model.Features["merchant"].Text.Vocabulary != nil {
+		t.Error("Hashing should not learn a vocabulary")
+	}
+
+	row := model.preprocess(map[string]interface{}{"merchant": "coffee coffee unseen-word"})
+	total := 0.0
+	for key, val := range row {
+		if !strings.HasPrefix(key, "merchant#") {
+			t.Errorf("Unexpected feature %s", key)
+		}
+		total += val.(float64)
+	}
+	if total != 4 {
+		t.Errorf("Expected every term to be counted in a bucket, got %v", row)
+	}
+	if bucket := hashBucket("coffee", 8); row[fmt.Sprintf("merchant#%d", bucket)].(float64) < 2 {
+		t.Errorf("Expected coffee to be counted twice in bucket %d, got %v", bucket, row)
+	}
+}
+
+// TestTextClassification tests a categorical model trained and served on text features
+func TestTextClassification(t *testing.T) {
+	model := NewCategoricalModel().WithText(TextVectorizer{Mode: TextTFIDF, StopWords: EnglishStopWords}, "subject")
+
+	engine := New()
+	engine.WithModel(model.JSON())
+	engine.WithConfig(&Config{LearningRate: 0.5, Epochs: 200, BatchSize: 8})
+
+	inputs := []map[string]interface{}{
+		{"subject": "win a free prize now"},
+		{"subject": "free money waiting for you"},
+		{"subject": "claim your free prize"},
+		{"subject": "cheap money offer"},
+		{"subject": "meeting agenda for monday"},
+		{"subject": "project status and agenda"},
+		{"subject": "notes from the project meeting"},
+		{"subject": "monday status update"},
+	}
+	outputs := []map[string]interface{}{
+		{"label": "spam"}, {"label": "spam"}, {"label": "spam"}, {"label": "spam"},
+		{"label": "work"}, {"label": "work"}, {"label": "work"}, {"label": "work"},
+	}
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	trained, weights, _ := engine.snapshot()
+	if _, exists := trained.Features["subject"].Text.Vocabulary["the"]; exists {
+		t.Error("Stop words should not be in the vocabulary")
+	}
+
+	for text, expected := range map[string]string{"free prize money": "spam", "project meeting agenda": "work"} {
+		prediction, err := engine.Predict(map[string]interface{}{"subject": text})
+		if err != nil {
+			t.Fatalf("Prediction failed: %v", err)
+		}
+		if prediction["label"] != expected {
+			t.Errorf("Expected %s for %q, got %v", expected, text, prediction["label"])
+		}
+	}
+
+	// Strict mode accepts any text for a text feature
+	strict := &Config{StrictSchema: true}
+	if _, err := predictWith(trained, weights, strict, map[string]interface{}{"subject": "an unseen subject"}); err != nil {
+		t.Errorf("Expected strict mode to accept new text, got %v", err)
+	}
+}
+
+// TestTextInvalidConfiguration tests rejection of invalid vectorizer settings
+func TestTextInvalidConfiguration(t *testing.T) {
+	for _, vectorizer := range []TextVectorizer{{Mode: "words"}, {Mode: TextHashing}, {Mode: TextCount, NGramMin: 3, NGramMax: 2}} {
+		engine := New()
+		engine.WithModel(NewLinearModel().WithText(vectorizer, "x").JSON())
+
+		err := engine.Train([]map[string]interface{}{{"x": "a b"}}, []map[string]interface{}{{"y": 1.0}})
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for %+v, got %v", vectorizer, err)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package goml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// defaultThreshold is the decision threshold of boolean targets without a threshold of their own
+const defaultThreshold = 0.5
+
+// ThresholdObjective selects what the decision threshold of a boolean target is optimized for
+type ThresholdObjective string
+
+const (
+	ObjectiveF1     ThresholdObjective = "f1"     // Maximize the F1 score of the positive class
+	ObjectiveYouden ThresholdObjective = "youden" // Maximize Youden's J, sensitivity + specificity - 1
+	ObjectiveCost   ThresholdObjective = "cost"   // Minimize the average cost of ThresholdOptimizer.Costs
+)
+
+// CostMatrix is the cost of every outcome of a boolean decision
+// Gains can be given as negative costs
+type CostMatrix struct {
+	TruePositive  float64 `json:"true_positive"`
+	FalsePositive float64 `json:"false_positive"`
+	TrueNegative  float64 `json:"true_negative"`
+	FalseNegative float64 `json:"false_negative"`
+}
+
+// ThresholdOptimizer configures the optimization of decision thresholds
+type ThresholdOptimizer struct {
+	Objective ThresholdObjective `json:"objective,omitempty"` // Default ObjectiveF1
+	Costs     *CostMatrix        `json:"costs,omitempty"`     // Required for ObjectiveCost
+}
+
+// Confusion counts the outcomes of boolean decisions
+type Confusion struct {
+	TruePositives  int `json:"true_positives"`
+	FalsePositives int `json:"false_positives"`
+	TrueNegatives  int `json:"true_negatives"`
+	FalseNegatives int `json:"false_negatives"`
+}
+
+// TargetThreshold is the optimized decision threshold of one boolean target
+// For ObjectiveCost the scores are average costs per row, lower being better
+type TargetThreshold struct {
+	Target    string    `json:"target"`
+	Threshold float64   `json:"threshold"`
+	Score     float64   `json:"score"`
+	Before    float64   `json:"before"` // Score with the threshold used before optimizing
+	Confusion Confusion `json:"confusion"`
+}
+
+// ThresholdReport is the result of optimizing decision thresholds
+type ThresholdReport struct {
+	Objective ThresholdObjective `json:"objective"`
+	Targets   []TargetThreshold  `json:"targets"`
+}
+
+// objective returns the objective, defaulting to F1
+func (o ThresholdOptimizer) objective() ThresholdObjective {
+	if o.Objective == "" {
+		return ObjectiveF1
+	}
+	return o.Objective
+}
+
+// gain scores a confusion matrix, higher being better
+func (o ThresholdOptimizer) gain(c Confusion) float64 {
+	tp, fp, tn, fn := float64(c.TruePositives), float64(c.FalsePositives), float64(c.TrueNegatives), float64(c.FalseNegatives)
+	switch o.objective() {
+	case ObjectiveYouden:
+		j := -1.0
+		if tp+fn > 0 {
+			j += tp / (tp + fn)
+		}
+		if tn+fp > 0 {
+			j += tn / (tn + fp)
+		}
+		return j
+	case ObjectiveCost:
+		cost := tp*o.Costs.TruePositive + fp*o.Costs.FalsePositive + tn*o.Costs.TrueNegative + fn*o.Costs.FalseNegative
+		return -cost / (tp + fp + tn + fn)
+	default:
+		if 2*tp+fp+fn == 0 {
+			return 0
+		}
+		return 2 * tp / (2*tp + fp + fn)
+	}
+}
+
+// score reports the gain of a confusion matrix in the units of the objective
+func (o ThresholdOptimizer) score(c Confusion) float64 {
+	if o.objective() == ObjectiveCost {
+		return -o.gain(c)
+	}
+	return o.gain(c)
+}
+
+// threshold returns the decision threshold of a boolean target
+func (m *Model) threshold(target string) float64 {
+	if threshold, exists := m.Thresholds[target]; exists {
+		return threshold
+	}
+	return defaultThreshold
+}
+
+// decide stores the label of a boolean target for a probability, and with
+// Config.Probabilities the probability itself under "<target>_prob"
+func (m *Model) decide(result map[string]interface{}, target string, p float64, config *Config) {
+	result[target] = p >= m.threshold(target)
+	if config.probabilities() {
+		result[target+"_prob"] = p
+	}
+}
+
+// confusionAt counts the outcomes of deciding positive at or above a threshold
+func confusionAt(probabilities []float64, outcomes []bool, threshold float64) Confusion {
+	var c Confusion
+	for i, p := range probabilities {
+		switch {
+		case p >= threshold && outcomes[i]:
+			c.TruePositives++
+		case p >= threshold:
+			c.FalsePositives++
+		case outcomes[i]:
+			c.FalseNegatives++
+		default:
+			c.TrueNegatives++
+		}
+	}
+	return c
+}
+
+// optimizeThreshold sweeps the thresholds between the distinct probabilities and returns the
+// best one with its confusion matrix. Thresholds lie halfway between neighbouring
+// probabilities, and ties go to the threshold closest to 0.5
+func optimizeThreshold(probabilities []float64, outcomes []bool, optimizer ThresholdOptimizer) (float64, Confusion) {
+	order := make([]int, len(probabilities))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return probabilities[order[a]] < probabilities[order[b]] })
+
+	// The lowest probability as threshold decides every row positive
+	var c Confusion
+	for _, positive := range outcomes {
+		if positive {
+			c.TruePositives++
+		} else {
+			c.FalsePositives++
+		}
+	}
+	bestThreshold, bestConfusion := probabilities[order[0]], c
+	best := optimizer.gain(c)
+
+	for start := 0; start < len(order); {
+		// Decide the rows sharing the next probability negative
+		p := probabilities[order[start]]
+		end := start
+		for end < len(order) && probabilities[order[end]] == p {
+			if outcomes[order[end]] {
+				c.TruePositives--
+				c.FalseNegatives++
+			} else {
+				c.FalsePositives--
+				c.TrueNegatives++
+			}
+			end++
+		}
+
+		var threshold float64
+		switch {
+		case end < len(order):
+			threshold = (p + probabilities[order[end]]) / 2
+		case p < 1:
+			threshold = (p + 1) / 2
+		default:
+			return bestThreshold, bestConfusion
+		}
+
+		gain := optimizer.gain(c)
+		if gain > best+1e-12 || (gain > best-1e-12 && math.Abs(threshold-defaultThreshold) < math.Abs(bestThreshold-defaultThreshold)) {
+			best, bestThreshold, bestConfusion = gain, threshold, c
+		}
+		start = end
+	}
+	return bestThreshold, bestConfusion
+}
+
+// SetThreshold sets the decision threshold of a boolean target of a logistic or mixed model
+// Rows are predicted positive when the calibrated probability is at least the threshold
+func (e *Engine) SetThreshold(target string, threshold float64) error {
+	if threshold < 0 || threshold > 1 || math.IsNaN(threshold) {
+		return fmt.Errorf("threshold %v of %q is not a probability: %w", threshold, target, ErrInvalidInput)
+	}
+
+	e.trainMu.Lock()
+	defer e.trainMu.Unlock()
+
+	model, weights, _ := e.snapshot()
+	if model == nil {
+		return fmt.Errorf("model not initialized")
+	}
+	switch model.Type {
+	case "logistic":
+		if weights == nil {
+			return ErrModelNotTrained
+		}
+		if _, exists := weights.Get("bias->" + target); !exists {
+			return fmt.Errorf("unknown target %q: %w", target, ErrInvalidOutput)
+		}
+	case "mixed":
+		if model.Targets[target] != "boolean" {
+			return fmt.Errorf("target %q is not boolean: %w", target, ErrInvalidOutput)
+		}
+	default:
+		return fmt.Errorf("model type %q has no boolean targets: %w", model.Type, ErrUnsupportedModelType)
+	}
+
+	// Published models are never mutated, so the threshold goes on a copy
+	updated, err := model.clone()
+	if err != nil {
+		return err
+	}
+	if updated.Thresholds == nil {
+		updated.Thresholds = make(map[string]float64)
+	}
+	updated.Thresholds[target] = threshold
+
+	e.mu.Lock()
+	e.model = updated
+	e.mu.Unlock()
+	return nil
+}
+
+// OptimizeThresholds chooses the decision threshold of every boolean target of a logistic or
+// mixed model on held-out rows and stores the thresholds with the model
+// The thresholds apply to the calibrated probabilities, so calibrate first. Train keeps them
+func (e *Engine) OptimizeThresholds(inputs []map[string]interface{}, outputs []map[string]interface{}, optimizer ThresholdOptimizer) (*ThresholdReport, error) {
+	switch optimizer.objective() {
+	case ObjectiveF1, ObjectiveYouden:
+	case ObjectiveCost:
+		if optimizer.Costs == nil {
+			return nil, fmt.Errorf("the cost objective needs a cost matrix: %w", ErrInvalidInput)
+		}
+	default:
+		return nil, fmt.Errorf("unknown threshold objective %q: %w", optimizer.Objective, ErrInvalidInput)
+	}
+	if len(inputs) != len(outputs) {
+		return nil, fmt.Errorf("number of input samples (%d) must match number of output samples (%d)", len(inputs), len(outputs))
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no validation data provided")
+	}
+
+	e.trainMu.Lock()
+	defer e.trainMu.Unlock()
+
+	model, weights, _ := e.snapshot()
+	if model == nil {
+		return nil, fmt.Errorf("model not initialized")
+	}
+	if weights == nil {
+		return nil, ErrModelNotTrained
+	}
+
+	// Calibrated held-out probabilities and outcomes per boolean target
+	probabilities := make(map[string][]float64)
+	outcomes := make(map[string][]bool)
+	for i, input := range inputs {
+		binary, _, err := model.probabilities(input, weights)
+		if err != nil {
+			return nil, err
+		}
+		for target, p := range binary {
+			if y, exists := outputs[i][target]; exists && !isMissing(y) {
+				probabilities[target] = append(probabilities[target], model.calibratedProbability(target, p))
+				outcomes[target] = append(outcomes[target], isPositive(y))
+			}
+		}
+	}
+	if len(probabilities) == 0 {
+		return nil, fmt.Errorf("no validation rows have a boolean target: %w", ErrInvalidOutput)
+	}
+
+	// Published models are never mutated, so the thresholds go on a copy
+	updated, err := model.clone()
+	if err != nil {
+		return nil, err
+	}
+	if updated.Thresholds == nil {
+		updated.Thresholds = make(map[string]float64)
+	}
+
+	targets := make([]string, 0, len(probabilities))
+	for target := range probabilities {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	report := &ThresholdReport{Objective: optimizer.objective()}
+	for _, target := range targets {
+		threshold, confusion := optimizeThreshold(probabilities[target], outcomes[target], optimizer)
+		updated.Thresholds[target] = threshold
+		report.Targets = append(report.Targets, TargetThreshold{
+			Target:    target,
+			Threshold: threshold,
+			Score:     optimizer.score(confusion),
+			Before:    optimizer.score(confusionAt(probabilities[target], outcomes[target], model.threshold(target))),
+			Confusion: confusion,
+		})
+	}
+
+	e.mu.Lock()
+	e.model = updated
+	e.mu.Unlock()
+	return report, nil
+}
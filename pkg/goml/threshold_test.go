@@ -0,0 +1,145 @@
+package goml
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestOptimizeThreshold tests the threshold sweep for every objective
+func TestOptimizeThreshold(t *testing.T) {
+	probabilities := []float64{0.8, 0.2, 0.6, 0.4}
+	outcomes := []bool{true, false, false, true}
+
+	threshold, confusion := optimizeThreshold(probabilities, outcomes, ThresholdOptimizer{})
+	if math.Abs(threshold-0.3) > 1e-12 || confusion != (Confusion{TruePositives: 2, FalsePositives: 1, TrueNegatives: 1}) {
+		t.Errorf("Expected the F1 threshold 0.3 with one false positive, got %v and %+v", threshold, confusion)
+	}
+
+	costs := ThresholdOptimizer{Objective: ObjectiveCost, Costs: &CostMatrix{FalsePositive: 5, FalseNegative: 1}}
+	threshold, confusion = optimizeThreshold(probabilities, outcomes, costs)
+	if math.Abs(threshold-0.7) > 1e-12 || costs.score(confusion) != 0.25 {
+		t.Errorf("Expected the cost threshold 0.7 with an average cost of 0.25, got %v and %v", threshold, costs.score(confusion))
+	}
+
+	youden := ThresholdOptimizer{Objective: ObjectiveYouden}
+	if j := youden.score(Confusion{TruePositives: 3, FalseNegatives: 1, TrueNegatives: 1, FalsePositives: 1}); math.Abs(j-0.25) > 1e-12 {
+		t.Errorf("Expected Youden's J of 0.25, got %v", j)
+	}
+}
+
+// TestThresholdPredict tests thresholds stored with a logistic model and labels with probabilities
+func TestThresholdPredict(t *testing.T) {
+	inputs, outputs := calibrationRows(1, 200)
+	for i := range outputs {
+		outputs[i] = map[string]interface{}{"flag": outputs[i]["flag"]}
+	}
+
+	engine := New()
+	engine.WithModel(NewLogisticModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 50, BatchSize: 20})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+	input := map[string]interface{}{"x": 3.0}
+	raw, _ := engine.Predict(input)
+	p := raw["flag"].(float64)
+
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 50, BatchSize: 20, Probabilities: true})
+	prediction, err := engine.Predict(input)
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if prediction["flag"] != (p >= 0.5) || prediction["flag_prob"] != p {
+		t.Errorf("Expected the label at 0.5 and the probability %v, got %v", p, prediction)
+	}
+
+	if err := engine.SetThreshold("flag", p+0.01); err != nil {
+		t.Fatalf("Setting the threshold failed: %v", err)
+	}
+	if prediction, _ := engine.Predict(input); prediction["flag"] != false {
+		t.Errorf("Expected a negative label below the threshold, got %v", prediction)
+	}
+	if err := engine.SetThreshold("flag", 1.5); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for a threshold above 1, got %v", err)
+	}
+	if err := engine.SetThreshold("other", 0.5); !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Expected ErrInvalidOutput for an unknown target, got %v", err)
+	}
+
+	heldInputs, heldOutputs := calibrationRows(2, 500)
+	report, err := engine.OptimizeThresholds(heldInputs, heldOutputs, ThresholdOptimizer{Objective: ObjectiveYouden})
+	if err != nil {
+		t.Fatalf("Threshold optimization failed: %v", err)
+	}
+	if len(report.Targets) != 1 || report.Targets[0].Target != "flag" || report.Targets[0].Score < report.Targets[0].Before {
+		t.Fatalf("Expected an improved threshold for flag, got %+v", report.Targets)
+	}
+	model, _, _ := engine.snapshot()
+	if model.Thresholds["flag"] != report.Targets[0].Threshold {
+		t.Errorf("Expected the threshold %v to be stored with the model, got %v", report.Targets[0].Threshold, model.Thresholds)
+	}
+
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Retraining failed: %v", err)
+	}
+	if model, _, _ := engine.snapshot(); model.Thresholds["flag"] != report.Targets[0].Threshold {
+		t.Errorf("Expected retraining to keep the thresholds, got %v", model.Thresholds)
+	}
+
+	if _, err := engine.OptimizeThresholds(heldInputs, heldOutputs, ThresholdOptimizer{Objective: ObjectiveCost}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for the cost objective without costs, got %v", err)
+	}
+	if _, err := engine.OptimizeThresholds(heldInputs, heldOutputs, ThresholdOptimizer{Objective: "auc"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for an unknown objective, got %v", err)
+	}
+}
+
+// TestThresholdMixed tests boolean and categorical labels with probabilities in a mixed model
+func TestThresholdMixed(t *testing.T) {
+	inputs, outputs := calibrationRows(1, 300)
+	for i := range outputs {
+		outputs[i]["flag"] = outputs[i]["flag"] == 1.0
+	}
+
+	engine := New()
+	engine.WithModel(NewMixedModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 50, BatchSize: 20, Probabilities: true})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	low, _ := engine.Predict(map[string]interface{}{"x": 1.2})
+	high, err := engine.Predict(map[string]interface{}{"x": 4.8})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if low["flag"] != false || high["flag"] != true || low["flag_prob"].(float64) >= high["flag_prob"].(float64) {
+		t.Errorf("Expected boolean targets to be learned, got %v and %v", low, high)
+	}
+	probs := high["label_probs"].(map[string]float64)
+	if high["label_prob"] != probs[high["label"].(string)] {
+		t.Errorf("Expected the probability of the predicted label, got %v", high)
+	}
+
+	report, err := engine.OptimizeThresholds(inputs, outputs, ThresholdOptimizer{Objective: ObjectiveCost, Costs: &CostMatrix{FalsePositive: 1, FalseNegative: 4}})
+	if err != nil {
+		t.Fatalf("Threshold optimization failed: %v", err)
+	}
+	if report.Targets[0].Threshold >= 0.5 || report.Targets[0].Score > report.Targets[0].Before {
+		t.Errorf("Expected costly misses to lower the threshold, got %+v", report.Targets[0])
+	}
+
+	categorical := New()
+	categorical.WithModel(NewCategoricalModel().JSON())
+	labels := make([]map[string]interface{}, len(outputs))
+	for i := range outputs {
+		labels[i] = map[string]interface{}{"label": outputs[i]["label"]}
+	}
+	if err := categorical.Train(inputs, labels); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+	if err := categorical.SetThreshold("label", 0.5); !errors.Is(err, ErrUnsupportedModelType) {
+		t.Errorf("Expected ErrUnsupportedModelType for a categorical model, got %v", err)
+	}
+}
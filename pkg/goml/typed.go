@@ -0,0 +1,436 @@
+package goml
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TypedEngine wraps an Engine to train and predict with Go structs instead of maps
+//
+// Struct fields are mapped to features and targets with goml tags:
+//
+//	type House struct {
+//		Size     float64 `goml:"size"`
+//		Location string  `goml:"location"`
+//		Price    float64 `goml:"price,target"` // skipped when House is used as the input
+//	}
+//
+//	type Estimate struct {
+//		Price    float64            `goml:"price"`
+//		Segment  string             `goml:"segment"`
+//		Score    float64            `goml:"segment,prob"`  // probability of the predicted segment
+//		Segments map[string]float64 `goml:"segment,probs"` // probability of every segment
+//	}
+//
+// Untagged exported fields use the field name, and fields tagged "-" are skipped. In fields
+// marked target are skipped, and when Out marks any field as a target only those fields are
+// predicted, so a single record type can hold both the features and the targets. Otherwise
+// every Out field that is not a prob or probs field is a target. Pointer fields are optional:
+// nil is a missing value on input and stays nil when a prediction has no value for it
+type TypedEngine[In any, Out any] struct {
+	engine  *Engine
+	inputs  []typedField
+	outputs []typedField
+}
+
+// typedField describes how one struct field maps to a row key
+type typedField struct {
+	name   string       // Feature or target name
+	index  []int        // Field index for reflect.Value.FieldByIndex
+	kind   reflect.Kind // Kind of the field, after dereferencing pointers
+	ptr    bool         // Field is a pointer to its value
+	target bool         // Field is tagged as a target
+	prob   bool         // Field receives the probability of the predicted value
+	probs  bool         // Field receives the probability of every category
+}
+
+// NewTyped creates a typed engine over an existing engine
+// A nil engine creates a new one whose model type is chosen from the first output on Train
+func NewTyped[In any, Out any](engine *Engine) (*TypedEngine[In, Out], error) {
+	if engine == nil {
+		engine = New()
+	}
+
+	inputs, err := typedFields(reflect.TypeOf((*In)(nil)).Elem())
+	if err != nil {
+		return nil, fmt.Errorf("input type: %w: %w", err, ErrInvalidInput)
+	}
+
+	outputs, err := typedFields(reflect.TypeOf((*Out)(nil)).Elem())
+	if err != nil {
+		return nil, fmt.Errorf("output type: %w: %w", err, ErrInvalidOutput)
+	}
+
+	// When some Out fields are marked as targets, the remaining plain fields are features
+	// of a shared record type and are not predicted
+	if hasTargetField(outputs) {
+		targets := outputs[:0]
+		for _, field := range outputs {
+			if field.target || field.prob || field.probs {
+				targets = append(targets, field)
+			}
+		}
+		outputs = targets
+	}
+
+	return &TypedEngine[In, Out]{
+		engine:  engine,
+		inputs:  inputs,
+		outputs: outputs,
+	}, nil
+}
+
+// Engine returns the underlying engine, e.g. to save the model and weights
+func (t *TypedEngine[In, Out]) Engine() *Engine {
+	return t.engine
+}
+
+// Train trains the model on paired input and output structs
+func (t *TypedEngine[In, Out]) Train(inputs []In, outputs []Out) error {
+	inputMaps, outputMaps := t.toMaps(inputs, outputs)
+	t.ensureModel(outputMaps)
+	return t.engine.Train(inputMaps, outputMaps)
+}
+
+// PartialFit continues training on new input and output structs
+func (t *TypedEngine[In, Out]) PartialFit(inputs []In, outputs []Out) error {
+	inputMaps, outputMaps := t.toMaps(inputs, outputs)
+	t.ensureModel(outputMaps)
+	return t.engine.PartialFit(inputMaps, outputMaps)
+}
+
+// Predict performs inference and returns the prediction as an Out struct
+func (t *TypedEngine[In, Out]) Predict(input In) (Out, error) {
+	var out Out
+
+	// Labels are decided with the model the prediction was made with
+	model, weights, config := t.engine.snapshot()
+	prediction, err := predictWith(model, weights, config, t.inputMap(input))
+	if err != nil {
+		return out, err
+	}
+
+	err = t.fillOutput(&out, prediction, model)
+	return out, err
+}
+
+// PredictBatch performs inference on many inputs; see Engine.PredictBatch
+// Rows that fail are left as zero values and reported through a *BatchError
+func (t *TypedEngine[In, Out]) PredictBatch(inputs []In) ([]Out, error) {
+	inputMaps := make([]map[string]interface{}, len(inputs))
+	for i, input := range inputs {
+		inputMaps[i] = t.inputMap(input)
+	}
+
+	model, weights, config := t.engine.snapshot()
+	predictions, batchErr := predictBatchWith(model, weights, config, inputMaps)
+
+	results := make([]Out, len(inputs))
+	for i, prediction := range predictions {
+		if prediction == nil {
+			continue
+		}
+		if err := t.fillOutput(&results[i], prediction, model); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+	}
+
+	return results, batchErr
+}
+
+// toMaps converts paired structs into input and output rows
+func (t *TypedEngine[In, Out]) toMaps(inputs []In, outputs []Out) ([]map[string]interface{}, []map[string]interface{}) {
+	inputMaps := make([]map[string]interface{}, len(inputs))
+	for i, input := range inputs {
+		inputMaps[i] = t.inputMap(input)
+	}
+
+	outputMaps := make([]map[string]interface{}, len(outputs))
+	for i, output := range outputs {
+		outputMaps[i] = structToMap(reflect.ValueOf(output), t.outputs, true)
+	}
+
+	return inputMaps, outputMaps
+}
+
+// inputMap converts an input struct into a feature row
+func (t *TypedEngine[In, Out]) inputMap(input In) map[string]interface{} {
+	return structToMap(reflect.ValueOf(input), t.inputs, false)
+}
+
+// ensureModel picks a model type from the first output when the engine has no model yet
+func (t *TypedEngine[In, Out]) ensureModel(outputs []map[string]interface{}) {
+	if len(outputs) == 0 {
+		return
+	}
+
+	t.engine.mu.Lock()
+	if t.engine.model == nil {
+		t.engine.model = NewAutoModel(outputs[0])
+	}
+	t.engine.mu.Unlock()
+}
+
+// fillOutput sets the Out fields from a prediction made with a model
+func (t *TypedEngine[In, Out]) fillOutput(out *Out, prediction map[string]interface{}, model *Model) error {
+	value := reflect.ValueOf(out).Elem()
+
+	for _, field := range t.outputs {
+		dest := value.FieldByIndex(field.index)
+
+		if field.probs {
+			if probs, ok := prediction[field.name+"_probs"].(map[string]float64); ok {
+				copied := make(map[string]float64, len(probs))
+				for category, prob := range probs {
+					copied[category] = prob
+				}
+				dest.Set(reflect.ValueOf(copied))
+			}
+			continue
+		}
+
+		var val interface{}
+		var ok bool
+		if field.prob {
+			val, ok = predictedProbability(prediction, field.name, model)
+		} else {
+			val, ok = prediction[field.name]
+		}
+		if !ok {
+			continue
+		}
+
+		// Numbers in bool fields are decided with the target's decision threshold
+		if _, isBool := val.(bool); !isBool && field.kind == reflect.Bool {
+			if f, isNumber := numericValue(val); isNumber {
+				val = f >= model.threshold(field.name)
+			}
+		}
+
+		if err := setTypedField(dest, field, val); err != nil {
+			return fmt.Errorf("field %s: %w", field.name, err)
+		}
+	}
+
+	return nil
+}
+
+// predictedProbability returns the probability of a target's predicted value
+// It is the probability of the label when the prediction holds one (see Config.Probabilities),
+// the highest category probability for categorical targets, or the prediction itself for
+// the targets of logistic models, which predict probabilities
+func predictedProbability(prediction map[string]interface{}, target string, model *Model) (float64, bool) {
+	if prob, ok := prediction[target+"_prob"].(float64); ok {
+		return prob, true
+	}
+	if probs, ok := prediction[target+"_probs"].(map[string]float64); ok && len(probs) > 0 {
+		best := 0.0
+		for _, prob := range probs {
+			if prob > best {
+				best = prob
+			}
+		}
+		return best, true
+	}
+
+	if prob, ok := prediction[target].(float64); ok && model.Type == "logistic" {
+		return prob, true
+	}
+	return 0, false
+}
+
+// typedFields reads the field mapping of a struct type from its goml tags
+func typedFields(typ reflect.Type) ([]typedField, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct", typ)
+	}
+
+	var fields []typedField
+	for i := 0; i < typ.NumField(); i++ {
+		structField := typ.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+
+		tag := structField.Tag.Get("goml")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = structField.Name
+		}
+
+		field := typedField{name: name, index: structField.Index}
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "":
+			case "target":
+				field.target = true
+			case "prob":
+				field.prob = true
+			case "probs":
+				field.probs = true
+			default:
+				return nil, fmt.Errorf("field %s has unknown goml option %q", structField.Name, option)
+			}
+		}
+
+		fieldType := structField.Type
+		if field.probs {
+			if fieldType != reflect.TypeOf(map[string]float64{}) {
+				return nil, fmt.Errorf("probs field %s must be a map[string]float64", structField.Name)
+			}
+			fields = append(fields, field)
+			continue
+		}
+
+		if fieldType.Kind() == reflect.Pointer {
+			field.ptr = true
+			fieldType = fieldType.Elem()
+		}
+
+		field.kind = fieldType.Kind()
+		switch field.kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64, reflect.Bool, reflect.String:
+		default:
+			return nil, fmt.Errorf("field %s has unsupported type %s", structField.Name, structField.Type)
+		}
+
+		if field.prob && field.kind != reflect.Float32 && field.kind != reflect.Float64 {
+			return nil, fmt.Errorf("prob field %s must be a float", structField.Name)
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// hasTargetField reports whether any field is tagged as a target
+func hasTargetField(fields []typedField) bool {
+	for _, field := range fields {
+		if field.target {
+			return true
+		}
+	}
+	return false
+}
+
+// structToMap converts a struct into a row map
+// Input rows skip target fields; output rows skip the probability fields
+func structToMap(value reflect.Value, fields []typedField, outputs bool) map[string]interface{} {
+	row := make(map[string]interface{})
+
+	for _, field := range fields {
+		if field.prob || field.probs || (!outputs && field.target) {
+			continue
+		}
+
+		fieldValue := value.FieldByIndex(field.index)
+		if field.ptr {
+			if fieldValue.IsNil() {
+				// Missing value
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		// Integers load as int and floats as float64, the types the models handle
+		switch field.kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			row[field.name] = int(fieldValue.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			row[field.name] = int(fieldValue.Uint())
+		case reflect.Float32, reflect.Float64:
+			row[field.name] = fieldValue.Float()
+		case reflect.Bool:
+			row[field.name] = fieldValue.Bool()
+		case reflect.String:
+			row[field.name] = fieldValue.String()
+		}
+	}
+
+	return row
+}
+
+// setTypedField stores a predicted value in a struct field, converting it to the field type
+// Regression outputs are rounded for integer fields. Numbers are decided by fillOutput
+// before they reach bool fields
+func setTypedField(dest reflect.Value, field typedField, val interface{}) error {
+	if field.ptr {
+		ptr := reflect.New(dest.Type().Elem())
+		dest.Set(ptr)
+		dest = ptr.Elem()
+	}
+
+	switch field.kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, err := typedFloat(val)
+		if err != nil {
+			return err
+		}
+		dest.SetInt(int64(math.Round(f)))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, err := typedFloat(val)
+		if err != nil {
+			return err
+		}
+		if f < 0 {
+			f = 0
+		}
+		dest.SetUint(uint64(math.Round(f)))
+	case reflect.Float32, reflect.Float64:
+		f, err := typedFloat(val)
+		if err != nil {
+			return err
+		}
+		dest.SetFloat(f)
+	case reflect.Bool:
+		switch v := val.(type) {
+		case bool:
+			dest.SetBool(v)
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("cannot convert %q to bool: %w", v, ErrInvalidOutput)
+			}
+			dest.SetBool(b)
+		default:
+			return fmt.Errorf("cannot convert %T to bool: %w", val, ErrInvalidOutput)
+		}
+	case reflect.String:
+		if s, ok := val.(string); ok {
+			dest.SetString(s)
+		} else {
+			// Categorical predictions that look numeric are returned as numbers
+			dest.SetString(fmt.Sprintf("%v", val))
+		}
+	}
+
+	return nil
+}
+
+// typedFloat converts a predicted value to float64
+func typedFloat(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || isNonFinite(v) {
+			return 0, fmt.Errorf("cannot convert %q to a number: %w", v, ErrInvalidOutput)
+		}
+		return f, nil
+	default:
+		f, ok := ConvertToFloat64(v, "")
+		if !ok {
+			return 0, fmt.Errorf("cannot convert %T to a number: %w", val, ErrInvalidOutput)
+		}
+		return f, nil
+	}
+}
@@ -0,0 +1,208 @@
+package goml
+
+import (
+	"errors"
+	"testing"
+)
+
+// typedHouse holds both the features and the target of a training record
+type typedHouse struct {
+	Size   float64  `goml:"size"`
+	Rooms  int      `goml:"rooms"`
+	Garden *float64 `goml:"garden"`
+	Note   string   `goml:"-"`
+	Price  float64  `goml:"price,target"`
+}
+
+// typedEstimate is a typed regression prediction
+type typedEstimate struct {
+	Price   float64 `goml:"price"`
+	Rounded int     `goml:"price"`
+	Missing *int    `goml:"unknown"`
+}
+
+// typedFlower is a typed classification record
+type typedFlower struct {
+	PetalLength float64 `goml:"petal_length"`
+}
+
+// typedSpecies is a typed classification prediction
+type typedSpecies struct {
+	Species     string             `goml:"species"`
+	Probability float64            `goml:"species,prob"`
+	All         map[string]float64 `goml:"species,probs"`
+}
+
+// TestTypedEngineRegression tests training and prediction with structs and a shared record type
+func TestTypedEngineRegression(t *testing.T) {
+	engine := New()
+	engine.WithModel(NewLinearModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.01, Epochs: 500, BatchSize: 4})
+
+	typed, err := NewTyped[typedHouse, typedHouse](engine)
+	if err != nil {
+		t.Fatalf("NewTyped failed: %v", err)
+	}
+
+	garden := 1.0
+	houses := []typedHouse{
+		{Size: 1, Rooms: 1, Garden: &garden, Note: "a", Price: 3},
+		{Size: 2, Rooms: 2, Price: 5},
+		{Size: 3, Rooms: 3, Garden: &garden, Price: 7},
+		{Size: 4, Rooms: 4, Price: 9},
+	}
+	if err := typed.Train(houses, houses); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	_, weights, _ := engine.snapshot()
+	if _, exists := weights.Get("price->price"); exists {
+		t.Error("Target fields should not be used as features")
+	}
+	if _, exists := weights.Get("size->size"); exists {
+		t.Error("Feature fields of a shared record should not be used as targets")
+	}
+	if _, exists := weights.Get("size->price"); !exists {
+		t.Error("Expected a weight for the size feature")
+	}
+
+	estimator, err := NewTyped[typedHouse, typedEstimate](engine)
+	if err != nil {
+		t.Fatalf("NewTyped failed: %v", err)
+	}
+	estimate, err := estimator.Predict(typedHouse{Size: 2.5, Rooms: 2})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if estimate.Price < 4 || estimate.Price > 8 {
+		t.Errorf("Prediction outside expected range: %v", estimate.Price)
+	}
+	if float64(estimate.Rounded) != float64(int(estimate.Price+0.5)) {
+		t.Errorf("Expected the integer field to hold the rounded prediction, got %d for %v", estimate.Rounded, estimate.Price)
+	}
+	if estimate.Missing != nil {
+		t.Errorf("Expected a nil pointer for a target without a prediction, got %v", *estimate.Missing)
+	}
+}
+
+// TestTypedEngineClassification tests typed labels and probability fields with automatic model selection
+func TestTypedEngineClassification(t *testing.T) {
+	typed, err := NewTyped[typedFlower, typedSpecies](nil)
+	if err != nil {
+		t.Fatalf("NewTyped failed: %v", err)
+	}
+	typed.Engine().WithConfig(&Config{LearningRate: 0.1, Epochs: 300, BatchSize: 6})
+
+	flowers := []typedFlower{{1.0}, {1.2}, {1.1}, {5.0}, {5.2}, {5.1}}
+	species := []typedSpecies{{Species: "setosa"}, {Species: "setosa"}, {Species: "setosa"}, {Species: "virginica"}, {Species: "virginica"}, {Species: "virginica"}}
+	if err := typed.Train(flowers, species); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+
+	if model, _, _ := typed.Engine().snapshot(); model.Type != "categorical" {
+		t.Errorf("Expected a categorical model for string targets, got %s", model.Type)
+	}
+
+	predictions, err := typed.PredictBatch([]typedFlower{{1.05}, {5.15}})
+	if err != nil {
+		t.Fatalf("Batch prediction failed: %v", err)
+	}
+	if predictions[0].Species != "setosa" || predictions[1].Species != "virginica" {
+		t.Errorf("Unexpected labels: %+v", predictions)
+	}
+	for _, prediction := range predictions {
+		if prediction.Probability < 0.5 || prediction.Probability != prediction.All[prediction.Species] {
+			t.Errorf("Expected the probability of the predicted label, got %+v", prediction)
+		}
+		if len(prediction.All) != 2 {
+			t.Errorf("Expected probabilities for both species, got %v", prediction.All)
+		}
+	}
+}
+
+// TestTypedEngineInvalidTypes tests rejection of unsupported struct types and tags
+func TestTypedEngineInvalidTypes(t *testing.T) {
+	if _, err := NewTyped[int, typedEstimate](nil); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for a non-struct input, got %v", err)
+	}
+
+	type badOption struct {
+		X float64 `goml:"x,weird"`
+	}
+	if _, err := NewTyped[typedFlower, badOption](nil); !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Expected ErrInvalidOutput for an unknown option, got %v", err)
+	}
+
+	type badField struct {
+		X []float64 `goml:"x"`
+	}
+	if _, err := NewTyped[badField, typedEstimate](nil); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for an unsupported field type, got %v", err)
+	}
+
+	type badProbs struct {
+		P []float64 `goml:"p,probs"`
+	}
+	if _, err := NewTyped[typedFlower, badProbs](nil); !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Expected ErrInvalidOutput for a probs field of the wrong type, got %v", err)
+	}
+}
+
+// TestTypedEngineThresholds tests bool fields decided with the model's threshold and probability fields
+func TestTypedEngineThresholds(t *testing.T) {
+	type point struct {
+		X float64 `goml:"x"`
+	}
+	type decision struct {
+		Flag        bool    `goml:"flag"`
+		Probability float64 `goml:"flag,prob"`
+	}
+
+	inputs, outputs := calibrationRows(1, 200)
+	for i := range outputs {
+		outputs[i] = map[string]interface{}{"flag": outputs[i]["flag"]}
+	}
+	engine := New()
+	engine.WithModel(NewLogisticModel().JSON())
+	engine.WithConfig(&Config{LearningRate: 0.1, Epochs: 50, BatchSize: 20})
+	if err := engine.Train(inputs, outputs); err != nil {
+		t.Fatalf("Training failed: %v", err)
+	}
+	raw, _ := engine.Predict(map[string]interface{}{"x": 3.0})
+	p := raw["flag"].(float64)
+
+	typed, err := NewTyped[point, decision](engine)
+	if err != nil {
+		t.Fatalf("NewTyped failed: %v", err)
+	}
+	for _, threshold := range []float64{p - 0.01, p + 0.01} {
+		if err := engine.SetThreshold("flag", threshold); err != nil {
+			t.Fatalf("Setting the threshold failed: %v", err)
+		}
+		prediction, err := typed.Predict(point{X: 3})
+		if err != nil {
+			t.Fatalf("Prediction failed: %v", err)
+		}
+		if prediction.Flag != (p >= threshold) || prediction.Probability != p {
+			t.Errorf("Expected the label at threshold %v and the probability %v, got %+v", threshold, p, prediction)
+		}
+	}
+
+	// Regression outputs are not probabilities, even when they lie between 0 and 1
+	type share struct {
+		Share       float64  `goml:"share"`
+		Probability *float64 `goml:"share,prob"`
+	}
+	linear := New()
+	linear.WithModel(NewLinearModel().JSON())
+	linear.WithConfig(&Config{LearningRate: 0.01, Epochs: 100, BatchSize: 4})
+	linear.Train([]map[string]interface{}{{"x": 1.0}, {"x": 2.0}, {"x": 3.0}, {"x": 4.0}}, []map[string]interface{}{{"share": 0.2}, {"share": 0.3}, {"share": 0.4}, {"share": 0.5}})
+	shares, _ := NewTyped[point, share](linear)
+	prediction, err := shares.Predict(point{X: 2})
+	if err != nil {
+		t.Fatalf("Prediction failed: %v", err)
+	}
+	if prediction.Probability != nil {
+		t.Errorf("Expected no probability for a regression target, got %v", *prediction.Probability)
+	}
+}
@@ -1,5 +1,10 @@
 package goml
 
+import (
+	"sort"
+	"time"
+)
+
 // ConvertToFloat64 converts different types to float64 for model training and prediction
 // Handles numeric types, bool, and strings in a consistent way
 func ConvertToFloat64(val interface{}, oneHotKey string) (float64, bool) {
@@ -26,6 +31,9 @@ func ConvertToFloat64(val interface{}, oneHotKey string) (float64, bool) {
 			return 1.0, true
 		}
 		return 0.0, true
+	case time.Time:
+		// Dates are seconds since the Unix epoch; see TimeFeatures for richer encodings
+		return float64(v.UnixNano()) / 1e9, true
 	default:
 		return 0.0, false
 	}
@@ -64,3 +72,26 @@ func ConvertToBool(val interface{}) (bool, bool) {
 		return false, false
 	}
 }
+
+// sortedKeys returns the keys of a row in lexical order
+// Training iterates features and targets in this order so results are reproducible
+func sortedKeys(row map[string]interface{}) []string {
+	keys := make([]string, 0, len(row))
+	for key := range row {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedRowKeys returns the union of the keys of all rows in lexical order
+// Rows may omit keys for missing values, so no single row is assumed to hold them all
+func sortedRowKeys(rows []map[string]interface{}) []string {
+	union := make(map[string]interface{})
+	for _, row := range rows {
+		for key := range row {
+			union[key] = nil
+		}
+	}
+	return sortedKeys(union)
+}
@@ -2,15 +2,22 @@ package goml
 
 import (
 	"encoding/json"
+	"sync"
 )
 
 // Weights stores the learned weights for the model
 type Weights struct {
 	Values map[string]interface{} `json:"values"`
+
+	// mu guards Values while targets or sub-models are trained concurrently
+	mu sync.RWMutex
 }
 
 // JSON serializes the weights to JSON
 func (w *Weights) JSON() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
 	bytes, err := json.Marshal(w)
 	if err != nil {
 		return "{}"
@@ -20,17 +27,26 @@ func (w *Weights) JSON() string {
 
 // Get retrieves a weight value by key
 func (w *Weights) Get(key string) (interface{}, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
 	val, exists := w.Values[key]
 	return val, exists
 }
 
 // Set updates a weight value
 func (w *Weights) Set(key string, value interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	w.Values[key] = value
 }
 
 // GetFloat retrieves a weight as a float64
 func (w *Weights) GetFloat(key string) (float64, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
 	val, exists := w.Values[key]
 	if !exists {
 		return 0, false
@@ -47,3 +63,21 @@ func (w *Weights) GetFloat(key string) (float64, bool) {
 		return 0, false
 	}
 }
+
+// clone returns a deep copy of the weights; a nil receiver yields empty weights
+func (w *Weights) clone() *Weights {
+	clone := &Weights{
+		Values: make(map[string]interface{}),
+	}
+	if w == nil {
+		return clone
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	for key, val := range w.Values {
+		clone.Values[key] = val
+	}
+	return clone
+}